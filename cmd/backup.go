@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var exportOutput string
+
+// ExportCmd represents the export command
+var ExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export storages, users, metas, settings and ssh keys as json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		b, err := op.Export()
+		if err != nil {
+			return errors.WithMessage(err, "failed to export")
+		}
+		data, err := utils.Json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return errors.WithMessage(err, "failed to marshal backup")
+		}
+		if exportOutput == "" || exportOutput == "-" {
+			_, _ = os.Stdout.Write(append(data, '\n'))
+			return nil
+		}
+		// the backup has the password hashes and the credentials of the storages
+		if err = os.WriteFile(exportOutput, data, 0600); err != nil {
+			return errors.WithMessage(err, "failed to write backup")
+		}
+		utils.Log.Infof("Configuration has been exported to %s", exportOutput)
+		return nil
+	},
+}
+
+var importOverwrite bool
+
+// ImportCmd represents the import command
+var ImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the json exported, from stdin if the file is omitted or -",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var data []byte
+		var err error
+		if len(args) == 0 || args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return errors.WithMessage(err, "failed to read backup")
+		}
+		var b model.Backup
+		if err = utils.Json.Unmarshal(data, &b); err != nil {
+			return errors.WithMessage(err, "failed to parse backup")
+		}
+		Init()
+		defer Release()
+		if err = op.Import(&b, importOverwrite); err != nil {
+			return errors.WithMessage(err, "failed to import")
+		}
+		utils.Log.Infof("Configuration has been imported, restart alist to apply it")
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(ExportCmd)
+	RootCmd.AddCommand(ImportCmd)
+	ExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write, stdout if omitted")
+	ImportCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "Overwrite the storages, users, metas and ssh keys existing")
+}
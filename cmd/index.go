@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/search"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// indexCmd represents the index command
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Build or clear the search index, alist should be stopped first",
+}
+
+// initSearch opens the searcher of the search_index setting
+func initSearch() error {
+	mode := setting.GetStr(conf.SearchIndex)
+	if mode == "none" {
+		return errors.New("search index is not enabled")
+	}
+	return errors.WithMessage(search.Init(mode), "failed to init search index")
+}
+
+var (
+	indexPaths    []string
+	indexMaxDepth int
+)
+
+var buildIndexCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build the search index, the whole index is rebuilt unless the paths are given",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		if err := initSearch(); err != nil {
+			return err
+		}
+		ctx := context.Background()
+		storages, err := db.GetEnabledStorages()
+		if err != nil {
+			return errors.WithMessage(err, "failed to query storages")
+		}
+		for i := range storages {
+			if err := op.LoadStorage(ctx, storages[i]); err != nil {
+				utils.Log.Errorf("failed to load storage [%s]: %+v", storages[i].MountPath, err)
+			}
+		}
+		paths, count := indexPaths, false
+		if len(paths) == 0 {
+			if err = search.Clear(ctx); err != nil {
+				return errors.WithMessage(err, "failed to clear index")
+			}
+			paths, count = []string{"/"}, true
+		}
+		maxDepth := indexMaxDepth
+		if maxDepth == 0 {
+			maxDepth = setting.GetInt(conf.MaxIndexDepth, 20)
+		}
+		if err = search.BuildIndex(ctx, paths, conf.SlicesMap[conf.IgnorePaths], maxDepth, count); err != nil {
+			return errors.WithMessage(err, "failed to build index")
+		}
+		utils.Log.Infof("Index has been built")
+		return nil
+	},
+}
+
+var clearIndexCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear the search index",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		if err := initSearch(); err != nil {
+			return err
+		}
+		if err := search.Clear(context.Background()); err != nil {
+			return errors.WithMessage(err, "failed to clear index")
+		}
+		search.WriteProgress(&model.IndexProgress{
+			ObjCount: 0,
+			IsDone:   true,
+		})
+		utils.Log.Infof("Index has been cleared")
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(buildIndexCmd, clearIndexCmd)
+	buildIndexCmd.Flags().StringSliceVar(&indexPaths, "paths", nil, "Paths to update in the index")
+	buildIndexCmd.Flags().IntVar(&indexMaxDepth, "max-depth", 0, "Max depth of the index, 0 means the max_index_depth setting")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// metaCmd represents the meta command
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Manage metas",
+}
+
+var listMetaCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all metas",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		metas, _, err := op.GetMetas(1, -1)
+		if err != nil {
+			return errors.WithMessage(err, "failed to query metas")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tPath\tPassword\tWrite\tHide")
+		for _, m := range metas {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%t\t%t\t%t\n", m.ID, m.Path, m.Password != "", m.Write, m.Hide != "")
+		}
+		_ = w.Flush()
+		return nil
+	},
+}
+
+var setMetaCmd = &cobra.Command{
+	Use:     "set",
+	Short:   "Create or replace the meta of a path with the json of it",
+	Example: `  alist meta set /share '{"password":"123","p_sub":true}'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("path and json of the meta are required")
+		}
+		var meta model.Meta
+		if err := utils.Json.UnmarshalFromString(args[1], &meta); err != nil {
+			return errors.WithMessage(err, "failed to parse meta")
+		}
+		meta.Path = utils.FixAndCleanPath(args[0])
+		Init()
+		defer Release()
+		old, err := db.GetMetaByPath(meta.Path)
+		switch {
+		case err == nil:
+			meta.ID = old.ID
+			err = op.UpdateMeta(&meta)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			meta.ID = 0
+			err = op.CreateMeta(&meta)
+		}
+		if err != nil {
+			return errors.WithMessage(err, "failed to save meta")
+		}
+		utils.Log.Infof("Meta of [%s] has been saved", meta.Path)
+		return nil
+	},
+}
+
+var deleteMetaCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete the meta of a path",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("path is required")
+		}
+		path := utils.FixAndCleanPath(args[0])
+		Init()
+		defer Release()
+		meta, err := db.GetMetaByPath(path)
+		if err != nil {
+			return errors.WithMessage(err, "failed to query meta")
+		}
+		if err = op.DeleteMetaById(meta.ID); err != nil {
+			return errors.WithMessage(err, "failed to delete meta")
+		}
+		utils.Log.Infof("Meta of [%s] has been deleted", path)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(metaCmd)
+	metaCmd.AddCommand(listMetaCmd, setMetaCmd, deleteMetaCmd)
+}
@@ -17,6 +17,9 @@ var RootCmd = &cobra.Command{
 	Long: `A file list program that supports multiple storage,
 built with love by Xhofe and friends in Go/Solid.js.
 Complete documentation is available at https://alist.nn.ci/`,
+	// the errors of the commands are printed once by Execute, without the usage
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 func Execute() {
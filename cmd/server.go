@@ -13,14 +13,14 @@ import (
 	"syscall"
 	"time"
 
-	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
-	"github.com/KirCute/sftpd-alist"
 	"github.com/alist-org/alist/v3/cmd/flags"
 	"github.com/alist-org/alist/v3/internal/bootstrap"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/fs"
+	ftpserver "github.com/alist-org/alist/v3/pkg/ftpserverlib"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server"
+	"github.com/alist-org/alist/v3/server/sftp"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -41,6 +41,11 @@ the address is defined in config file`,
 			time.Sleep(time.Duration(conf.Conf.DelayedStart) * time.Second)
 		}
 		bootstrap.InitOfflineDownloadTools()
+		bootstrap.InitPlugins()
+		bootstrap.InitBlockCache()
+		bootstrap.InitThumbnails()
+		bootstrap.InitHLS()
+		bootstrap.InitProxyPool()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
 		if !flags.Debug && !flags.Dev {
@@ -140,7 +145,7 @@ the address is defined in config file`,
 			}
 		}
 		var sftpDriver *server.SftpDriver
-		var sftpServer *sftpd.SftpServer
+		var sftpServer *sftp.Server
 		if conf.Conf.SFTP.Listen != "" && conf.Conf.SFTP.Enable {
 			var err error
 			sftpDriver, err = server.NewSftpDriver()
@@ -149,8 +154,8 @@ the address is defined in config file`,
 			} else {
 				utils.Log.Infof("start sftp server on %s", conf.Conf.SFTP.Listen)
 				go func() {
-					sftpServer = sftpd.NewSftpServer(sftpDriver)
-					err = sftpServer.RunServer()
+					sftpServer = sftp.NewServer(conf.Conf.SFTP.Listen, sftpDriver)
+					err = sftpServer.ListenAndServe()
 					if err != nil {
 						utils.Log.Fatalf("problem sftp server listening: %s", err.Error())
 					}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// settingCmd represents the setting command
+var settingCmd = &cobra.Command{
+	Use:   "setting",
+	Short: "Get or set a setting",
+}
+
+var getSettingCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Print the value of a setting",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("key is required")
+		}
+		Init()
+		defer Release()
+		item, err := op.GetSettingItemByKey(args[0])
+		if err != nil {
+			return errors.WithMessage(err, "failed to query setting")
+		}
+		fmt.Println(item.Value)
+		return nil
+	},
+}
+
+var setSettingCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the value of a setting, restart alist to apply it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("key and value are required")
+		}
+		Init()
+		defer Release()
+		item, err := op.GetSettingItemByKey(args[0])
+		if err != nil {
+			return errors.WithMessage(err, "failed to query setting")
+		}
+		if item.Flag == model.READONLY {
+			return errors.Errorf("setting [%s] is readonly", item.Key)
+		}
+		item.Value = args[1]
+		if err = op.SaveSettingItem(item); err != nil {
+			return errors.WithMessage(err, "failed to save setting")
+		}
+		utils.Log.Infof("Setting [%s] has been set", item.Key)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(settingCmd)
+	settingCmd.AddCommand(getSettingCmd, setSettingCmd)
+}
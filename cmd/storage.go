@@ -6,12 +6,17 @@ package cmd
 import (
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -29,7 +34,7 @@ var disableStorageCmd = &cobra.Command{
 			utils.Log.Errorf("mount path is required")
 			return
 		}
-		mountPath := args[0]
+		mountPath := utils.FixAndCleanPath(args[0])
 		Init()
 		defer Release()
 		storage, err := db.GetStorageByMountPath(mountPath)
@@ -47,17 +52,195 @@ var disableStorageCmd = &cobra.Command{
 	},
 }
 
+var enableStorageCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable a storage",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("mount path is required")
+		}
+		mountPath := utils.FixAndCleanPath(args[0])
+		Init()
+		defer Release()
+		storage, err := db.GetStorageByMountPath(mountPath)
+		if err != nil {
+			return errors.WithMessage(err, "failed to query storage")
+		}
+		storage.Disabled = false
+		if err = db.UpdateStorage(storage); err != nil {
+			return errors.WithMessage(err, "failed to update storage")
+		}
+		utils.Log.Infof("Storage with mount path [%s] have been enabled, restart alist to load it", mountPath)
+		return nil
+	},
+}
+
+var (
+	storageDriver   string
+	storageAddition string
+	storageOrder    int
+	storageRemark   string
+	storageNewPath  string
+)
+
+// storageAdditionDefaults fills the items missing in the addition with the defaults of the driver
+func storageAdditionDefaults(driverName string, addition map[string]any) error {
+	if _, err := op.GetDriver(driverName); err != nil {
+		return err
+	}
+	info := op.GetDriverInfoMap()[driverName]
+	for _, item := range info.Additional {
+		if _, ok := addition[item.Name]; ok || item.Default == "" {
+			continue
+		}
+		switch item.Type {
+		case conf.TypeBool:
+			addition[item.Name] = item.Default == "true"
+		case conf.TypeNumber, "int", "int64", "float64":
+			n, err := strconv.ParseFloat(item.Default, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid default of %s", item.Name)
+			}
+			addition[item.Name] = n
+		default:
+			addition[item.Name] = item.Default
+		}
+	}
+	return nil
+}
+
+func parseAddition(s string) (map[string]any, error) {
+	addition := make(map[string]any)
+	if s == "" {
+		return addition, nil
+	}
+	if err := utils.Json.UnmarshalFromString(s, &addition); err != nil {
+		return nil, errors.Wrap(err, "the addition must be a json object")
+	}
+	return addition, nil
+}
+
+var addStorageCmd = &cobra.Command{
+	Use:     "add",
+	Short:   "Add a storage with the driver and the json addition",
+	Example: `  alist storage add /local --driver Local --addition '{"root_folder_path":"/data"}'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("mount path is required")
+		}
+		addition, err := parseAddition(storageAddition)
+		if err != nil {
+			return err
+		}
+		Init()
+		defer Release()
+		if err = storageAdditionDefaults(storageDriver, addition); err != nil {
+			return errors.WithMessage(err, "failed to check driver")
+		}
+		additionStr, err := utils.Json.MarshalToString(addition)
+		if err != nil {
+			return errors.WithMessage(err, "failed to marshal addition")
+		}
+		storage := model.Storage{
+			MountPath:       utils.FixAndCleanPath(args[0]),
+			Order:           storageOrder,
+			Driver:          storageDriver,
+			CacheExpiration: 30,
+			Addition:        additionStr,
+			Remark:          storageRemark,
+			Modified:        time.Now(),
+		}
+		info := op.GetDriverInfoMap()[storageDriver]
+		storage.WebdavPolicy = "302_redirect"
+		if info.Config.OnlyProxy || info.Config.OnlyLocal {
+			storage.WebdavPolicy = "native_proxy"
+		}
+		if err = db.CreateStorage(&storage); err != nil {
+			return errors.WithMessage(err, "failed to create storage")
+		}
+		utils.Log.Infof("Storage with mount path [%s] have been added, restart alist to load it", storage.MountPath)
+		return nil
+	},
+}
+
+var updateStorageCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update a storage, the json addition is merged into the current one",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("mount path is required")
+		}
+		addition, err := parseAddition(storageAddition)
+		if err != nil {
+			return err
+		}
+		Init()
+		defer Release()
+		storage, err := db.GetStorageByMountPath(utils.FixAndCleanPath(args[0]))
+		if err != nil {
+			return errors.WithMessage(err, "failed to query storage")
+		}
+		current, err := parseAddition(storage.Addition)
+		if err != nil {
+			return errors.WithMessage(err, "failed to parse current addition")
+		}
+		for k, v := range addition {
+			current[k] = v
+		}
+		if storage.Addition, err = utils.Json.MarshalToString(current); err != nil {
+			return errors.WithMessage(err, "failed to marshal addition")
+		}
+		if cmd.Flags().Changed("mount-path") {
+			storage.MountPath = utils.FixAndCleanPath(storageNewPath)
+		}
+		if cmd.Flags().Changed("order") {
+			storage.Order = storageOrder
+		}
+		if cmd.Flags().Changed("remark") {
+			storage.Remark = storageRemark
+		}
+		storage.Modified = time.Now()
+		if err = db.UpdateStorage(storage); err != nil {
+			return errors.WithMessage(err, "failed to update storage")
+		}
+		utils.Log.Infof("Storage with mount path [%s] have been updated, restart alist to reload it", storage.MountPath)
+		return nil
+	},
+}
+
+var deleteStorageCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a storage",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("mount path is required")
+		}
+		mountPath := utils.FixAndCleanPath(args[0])
+		Init()
+		defer Release()
+		storage, err := db.GetStorageByMountPath(mountPath)
+		if err != nil {
+			return errors.WithMessage(err, "failed to query storage")
+		}
+		if err = db.DeleteStorageById(storage.ID); err != nil {
+			return errors.WithMessage(err, "failed to delete storage")
+		}
+		utils.Log.Infof("Storage with mount path [%s] have been deleted", mountPath)
+		return nil
+	},
+}
+
 var baseStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
 	BorderForeground(lipgloss.Color("240"))
 
-type model struct {
+type tableModel struct {
 	table table.Model
 }
 
-func (m model) Init() tea.Cmd { return nil }
+func (m tableModel) Init() tea.Cmd { return nil }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m tableModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -80,7 +263,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func (m model) View() string {
+func (m tableModel) View() string {
 	return baseStyle.Render(m.table.View()) + "\n"
 }
 
@@ -136,7 +319,7 @@ var listStorageCmd = &cobra.Command{
 				Bold(false)
 			t.SetStyles(s)
 
-			m := model{t}
+			m := tableModel{t}
 			if _, err := tea.NewProgram(m).Run(); err != nil {
 				utils.Log.Errorf("failed to run program: %+v", err)
 				os.Exit(1)
@@ -150,6 +333,18 @@ func init() {
 	RootCmd.AddCommand(storageCmd)
 	storageCmd.AddCommand(disableStorageCmd)
 	storageCmd.AddCommand(listStorageCmd)
+	storageCmd.AddCommand(enableStorageCmd)
+	storageCmd.AddCommand(addStorageCmd)
+	storageCmd.AddCommand(updateStorageCmd)
+	storageCmd.AddCommand(deleteStorageCmd)
+	addStorageCmd.Flags().StringVar(&storageDriver, "driver", "", "Driver of the storage")
+	_ = addStorageCmd.MarkFlagRequired("driver")
+	for _, c := range []*cobra.Command{addStorageCmd, updateStorageCmd} {
+		c.Flags().StringVar(&storageAddition, "addition", "", "Addition of the driver in json")
+		c.Flags().IntVar(&storageOrder, "order", 0, "Order of the storage")
+		c.Flags().StringVar(&storageRemark, "remark", "", "Remark of the storage")
+	}
+	updateStorageCmd.Flags().StringVar(&storageNewPath, "mount-path", "", "New mount path of the storage")
 	storageCmd.PersistentFlags().IntVarP(&storageTableHeight, "height", "H", 10, "Table height")
 	// Here you will define your flags and configuration settings.
 
@@ -3,13 +3,23 @@ package cmd
 import (
 	"crypto/tls"
 	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
 	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 )
 
 func DelAdminCacheOnline() {
@@ -50,3 +60,224 @@ func DelUserCacheOnline(username string) {
 	}
 	utils.Log.Debugf("[del_user_cache_online] del user [%s] cache success", username)
 }
+
+// permissionNames are the bits of model.User.Permission in order
+var permissionNames = []string{
+	"see-hides", "access-without-password", "offline-download", "write",
+	"rename", "move", "copy", "remove", "webdav-read", "webdav-manage",
+	"ftp-access", "ftp-manage", "read-archives", "decompress",
+}
+
+// parsePermission parses a number or a comma separated list of permissionNames
+func parsePermission(s string) (int32, error) {
+	if n, err := strconv.ParseInt(s, 10, 32); err == nil {
+		return int32(n), nil
+	}
+	var perm int32
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		i := slices.Index(permissionNames, name)
+		if i < 0 {
+			return 0, fmt.Errorf("unknown permission: %s, available: %s", name, strings.Join(permissionNames, ","))
+		}
+		perm |= 1 << i
+	}
+	return perm, nil
+}
+
+func formatPermission(perm int32) string {
+	var names []string
+	for i, name := range permissionNames {
+		if (perm>>i)&1 == 1 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// userCmd represents the user command
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage users",
+}
+
+var listUserCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all users",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		users, _, err := op.GetUsers(1, -1)
+		if err != nil {
+			return errors.WithMessage(err, "failed to query users")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tUsername\tBase Path\tDisabled\tPermission")
+		for _, u := range users {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\n", u.ID, u.Username, u.BasePath, u.Disabled, formatPermission(u.Permission))
+		}
+		_ = w.Flush()
+		return nil
+	},
+}
+
+var (
+	userBasePath     string
+	userPermission   string
+	userLimitProfile string
+)
+
+var createUserCmd = &cobra.Command{
+	Use:     "create",
+	Short:   "Create a user with the username and the password",
+	Example: `  alist user create alice PASSWORD --base-path /alice --permission write,rename,webdav-read`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("username and password are required")
+		}
+		perm, err := parsePermission(userPermission)
+		if err != nil {
+			return err
+		}
+		Init()
+		defer Release()
+		user := &model.User{
+			Username:   args[0],
+			BasePath:   userBasePath,
+			Role:       model.GENERAL,
+			Permission: perm,
+			Authn:      "[]",
+		}
+		if userLimitProfile != "" {
+			profile, err := db.GetLimitProfileByName(userLimitProfile)
+			if err != nil {
+				return errors.WithMessage(err, "failed to query limit profile")
+			}
+			user.LimitProfileID = profile.ID
+		}
+		user.SetPassword(args[1])
+		if err = op.CreateUser(user); err != nil {
+			return errors.WithMessage(err, "failed to create user")
+		}
+		utils.Log.Infof("User [%s] has been created", user.Username)
+		return nil
+	},
+}
+
+// updateUser loads the user named by the first arg, applies f and saves it
+func updateUser(args []string, f func(u *model.User) error) error {
+	if len(args) < 1 {
+		return errors.New("username is required")
+	}
+	Init()
+	defer Release()
+	user, err := op.GetUserByName(args[0])
+	if err != nil {
+		return errors.WithMessage(err, "failed to query user")
+	}
+	if err = f(user); err != nil {
+		return err
+	}
+	if err = op.UpdateUser(user); err != nil {
+		return errors.WithMessage(err, "failed to update user")
+	}
+	utils.Log.Infof("User [%s] has been updated", user.Username)
+	DelUserCacheOnline(user.Username)
+	return nil
+}
+
+var permUserCmd = &cobra.Command{
+	Use:   "perm",
+	Short: "Set the permission of a user, a number or a comma separated list of names",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("username and permission are required")
+		}
+		perm, err := parsePermission(args[1])
+		if err != nil {
+			return err
+		}
+		return updateUser(args, func(u *model.User) error {
+			u.Permission = perm
+			return nil
+		})
+	},
+}
+
+var disableUserCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable a user",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateUser(args, func(u *model.User) error {
+			if u.IsAdmin() {
+				return errors.New("admin user can not be disabled")
+			}
+			u.Disabled = true
+			return nil
+		})
+	},
+}
+
+var enableUserCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable a user",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateUser(args, func(u *model.User) error {
+			u.Disabled = false
+			return nil
+		})
+	},
+}
+
+var passwdUserCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Reset the password of a user, a random one is set if it's omitted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pwd := random.String(8)
+		if len(args) > 1 {
+			pwd = args[1]
+		}
+		err := updateUser(args, func(u *model.User) error {
+			u.SetPassword(pwd)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		utils.Log.Infof("password: %s", pwd)
+		return nil
+	},
+}
+
+var deleteUserCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a user",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("username is required")
+		}
+		Init()
+		defer Release()
+		user, err := op.GetUserByName(args[0])
+		if err != nil {
+			return errors.WithMessage(err, "failed to query user")
+		}
+		if err = op.DeleteUserById(user.ID); err != nil {
+			return errors.WithMessage(err, "failed to delete user")
+		}
+		utils.Log.Infof("User [%s] has been deleted", user.Username)
+		DelUserCacheOnline(user.Username)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(userCmd)
+	userCmd.AddCommand(listUserCmd, createUserCmd, permUserCmd, disableUserCmd, enableUserCmd, passwdUserCmd, deleteUserCmd)
+	createUserCmd.Flags().StringVar(&userBasePath, "base-path", "/", "Base path of the user")
+	createUserCmd.Flags().StringVar(&userPermission, "permission", "0", "Permission of the user, a number or a comma separated list of names")
+	createUserCmd.Flags().StringVar(&userLimitProfile, "limit-profile", "", "Name of the limit profile of the user")
+}
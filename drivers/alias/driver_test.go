@@ -0,0 +1,64 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/driver/drivertest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var remoteRoot string
+
+func TestMain(m *testing.M) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+	remoteRoot, err = os.MkdirTemp("", "alias")
+	if err != nil {
+		panic(err)
+	}
+	_, err = op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/remote",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"show_hidden":true,"mkdir_perm":"777","recycle_bin_path":"delete permanently"}`, remoteRoot),
+	})
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(remoteRoot)
+	os.Exit(code)
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, drivertest.Options{
+		New: func(t *testing.T) driver.Driver {
+			dir, err := os.MkdirTemp(remoteRoot, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := &Alias{Addition: Addition{
+				Paths:    "/remote/" + filepath.Base(dir),
+				Writable: true,
+			}}
+			if err = d.Init(context.Background()); err != nil {
+				t.Fatalf("failed init alias: %+v", err)
+			}
+			return d
+		},
+	})
+}
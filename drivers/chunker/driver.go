@@ -0,0 +1,293 @@
+package chunker
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// A file larger than the chunk size is stored as a folder named <name>.chunks,
+// holding meta.json and the chunks 00000<ext>, 00001<ext>...
+// so moving, renaming or removing the folder applies to the whole chunk set.
+const (
+	chunksSuffix = ".chunks"
+	tmpSuffix    = ".uploading"
+	metaName     = "meta.json"
+)
+
+type Chunker struct {
+	model.Storage
+	Addition
+	remoteStorage driver.Driver
+}
+
+func (d *Chunker) Config() driver.Config {
+	return config
+}
+
+func (d *Chunker) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Chunker) Init(ctx context.Context) error {
+	if d.ChunkSize <= 0 {
+		return fmt.Errorf("chunk size must be positive")
+	}
+	d.RemotePath = utils.FixAndCleanPath(d.RemotePath)
+	if utils.IsSubPath(d.RemotePath, d.MountPath) || utils.IsSubPath(d.MountPath, d.RemotePath) {
+		return fmt.Errorf("remote path overlaps the chunker itself")
+	}
+	storage, err := fs.GetStorage(d.RemotePath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return fmt.Errorf("can't find remote storage: %w", err)
+	}
+	d.remoteStorage = storage
+	return nil
+}
+
+func (d *Chunker) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Chunker) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	path := dir.GetPath()
+	objs, err := fs.List(ctx, d.getPathForRemote(path), &fs.ListArgs{NoLog: true, Refresh: args.Refresh})
+	if err != nil {
+		return nil, err
+	}
+	var result []model.Obj
+	for _, obj := range objs {
+		name := obj.GetName()
+		if !obj.IsDir() {
+			result = append(result, toObj(stdpath.Join(path, name), obj))
+			continue
+		}
+		if strings.HasSuffix(name, tmpSuffix) {
+			continue
+		}
+		if strings.HasSuffix(name, chunksSuffix) {
+			file, err := d.chunkedObj(ctx, stdpath.Join(path, strings.TrimSuffix(name, chunksSuffix)), args.Refresh)
+			if err != nil {
+				// skip the chunk sets which are broken
+				continue
+			}
+			result = append(result, file)
+			continue
+		}
+		result = append(result, toObj(stdpath.Join(path, name), obj))
+	}
+	return result, nil
+}
+
+func (d *Chunker) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	obj, err := fs.Get(ctx, d.getPathForRemote(path), &fs.GetArgs{NoLog: true})
+	if err == nil {
+		return toObj(path, obj), nil
+	}
+	if !errs.IsObjectNotFound(err) {
+		return nil, err
+	}
+	return d.chunkedObj(ctx, path, false)
+}
+
+func (d *Chunker) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	remoteActualPath, err := d.getActualPathForRemote(file.GetPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	if !d.isChunked(ctx, file.GetPath()) {
+		link, _, err := op.Link(ctx, d.remoteStorage, remoteActualPath, args)
+		return link, err
+	}
+	chunksPath := remoteActualPath + chunksSuffix
+	meta, err := d.readMeta(ctx, chunksPath)
+	if err != nil {
+		return nil, err
+	}
+	rangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		if httpRange.Length < 0 || httpRange.Start+httpRange.Length > meta.Size {
+			httpRange.Length = meta.Size - httpRange.Start
+		}
+		return &chunksReader{
+			ctx:    ctx,
+			d:      d,
+			path:   chunksPath,
+			meta:   meta,
+			args:   args,
+			offset: httpRange.Start,
+			remain: httpRange.Length,
+		}, nil
+	}
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{RangeReader: rangeReader},
+	}, nil
+}
+
+func (d *Chunker) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	dstDirActualPath, err := d.getActualPathForRemote(parentDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return op.MakeDir(ctx, d.remoteStorage, stdpath.Join(dstDirActualPath, dirName))
+}
+
+func (d *Chunker) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcRemoteActualPath, err := d.getActualPathForObj(ctx, srcObj)
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	dstRemoteActualPath, err := d.getActualPathForRemote(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return op.Move(ctx, d.remoteStorage, srcRemoteActualPath, dstRemoteActualPath)
+}
+
+func (d *Chunker) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	remoteActualPath, err := d.getActualPathForObj(ctx, srcObj)
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	if strings.HasSuffix(remoteActualPath, chunksSuffix) {
+		newName += chunksSuffix
+	}
+	return op.Rename(ctx, d.remoteStorage, remoteActualPath, newName)
+}
+
+func (d *Chunker) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcRemoteActualPath, err := d.getActualPathForObj(ctx, srcObj)
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	dstRemoteActualPath, err := d.getActualPathForRemote(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return op.Copy(ctx, d.remoteStorage, srcRemoteActualPath, dstRemoteActualPath)
+}
+
+func (d *Chunker) Remove(ctx context.Context, obj model.Obj) error {
+	remoteActualPath, err := d.getActualPathForObj(ctx, obj)
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return op.Remove(ctx, d.remoteStorage, remoteActualPath)
+}
+
+func (d *Chunker) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up driver.UpdateProgress) error {
+	dstDirActualPath, err := d.getActualPathForRemote(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	name := s.GetName()
+	if s.GetSize() <= d.ChunkSize*utils.MB {
+		if err = op.Put(ctx, d.remoteStorage, dstDirActualPath, s, up, false); err != nil {
+			return err
+		}
+		// the file may be chunked before
+		return op.Remove(ctx, d.remoteStorage, stdpath.Join(dstDirActualPath, name+chunksSuffix))
+	}
+
+	// upload into a temporary folder first, so the old chunks are kept if it fails
+	tmpPath := stdpath.Join(dstDirActualPath, name+chunksSuffix+tmpSuffix)
+	if err = op.Remove(ctx, d.remoteStorage, tmpPath); err != nil {
+		return err
+	}
+	if err = op.MakeDir(ctx, d.remoteStorage, tmpPath); err != nil {
+		return err
+	}
+	err = d.putChunks(ctx, tmpPath, s, up)
+	if err == nil {
+		err = op.Remove(ctx, d.remoteStorage, stdpath.Join(dstDirActualPath, name))
+	}
+	if err == nil {
+		err = op.Remove(ctx, d.remoteStorage, stdpath.Join(dstDirActualPath, name+chunksSuffix))
+	}
+	if err == nil {
+		err = op.Rename(ctx, d.remoteStorage, tmpPath, name+chunksSuffix)
+	}
+	if err != nil {
+		_ = op.Remove(context.WithoutCancel(ctx), d.remoteStorage, tmpPath)
+		return err
+	}
+	return nil
+}
+
+func (d *Chunker) putChunks(ctx context.Context, dirPath string, s model.FileStreamer, up driver.UpdateProgress) error {
+	chunkSize := d.ChunkSize * utils.MB
+	meta := Meta{
+		Version:   1,
+		Size:      s.GetSize(),
+		ChunkSize: chunkSize,
+		Chunks:    int((s.GetSize() + chunkSize - 1) / chunkSize),
+		Modified:  s.ModTime(),
+	}
+	for i := 0; i < meta.Chunks; i++ {
+		length := meta.chunkLength(i)
+		var (
+			reader io.Reader = io.LimitReader(s, length)
+			h      hash.Hash
+		)
+		if d.ChunkHash {
+			h = md5.New()
+			reader = io.TeeReader(reader, h)
+		}
+		chunk := &stream.FileStream{
+			Obj: &model.Object{
+				Name:     chunkName(i, d.ChunkExt),
+				Size:     length,
+				Modified: s.ModTime(),
+			},
+			Reader:            reader,
+			Mimetype:          "application/octet-stream",
+			WebPutAsTask:      s.NeedStore(),
+			ForceStreamUpload: true,
+		}
+		err := op.Put(ctx, d.remoteStorage, dirPath, chunk, func(p float64) {
+			up((float64(i) + p/100) / float64(meta.Chunks) * 100)
+		}, false)
+		if err != nil {
+			return fmt.Errorf("failed to put chunk %d: %w", i, err)
+		}
+		if h != nil {
+			meta.MD5 = append(meta.MD5, hex.EncodeToString(h.Sum(nil)))
+		}
+	}
+	data, err := utils.Json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return op.Put(ctx, d.remoteStorage, dirPath, &stream.FileStream{
+		Obj: &model.Object{
+			Name:     metaName,
+			Size:     int64(len(data)),
+			Modified: s.ModTime(),
+		},
+		Reader:   bytes.NewReader(data),
+		Mimetype: "application/json",
+	}, func(float64) {}, false)
+}
+
+var _ driver.Driver = (*Chunker)(nil)
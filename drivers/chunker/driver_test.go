@@ -0,0 +1,151 @@
+package chunker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/driver/drivertest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var remoteRoot string
+
+func TestMain(m *testing.M) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+	remoteRoot, err = os.MkdirTemp("", "chunker")
+	if err != nil {
+		panic(err)
+	}
+	_, err = op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/remote",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"show_hidden":true,"mkdir_perm":"777","recycle_bin_path":"delete permanently"}`, remoteRoot),
+	})
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(remoteRoot)
+	os.Exit(code)
+}
+
+func newChunker(t *testing.T) *Chunker {
+	dir, err := os.MkdirTemp(remoteRoot, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Chunker{Addition: Addition{
+		RemotePath: "/remote/" + filepath.Base(dir),
+		ChunkSize:  1,
+		ChunkExt:   ".bin",
+		ChunkHash:  true,
+	}}
+	d.MountPath = "/chunker"
+	if err = d.Init(context.Background()); err != nil {
+		t.Fatalf("failed init chunker: %+v", err)
+	}
+	return d
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, drivertest.Options{
+		New: func(t *testing.T) driver.Driver {
+			return newChunker(t)
+		},
+	})
+}
+
+func TestChunked(t *testing.T) {
+	d := newChunker(t)
+	d.MountPath = "/chunker_chunked"
+	d.Status = op.WORK
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("0123456789abcdef"), 5*utils.MB/32)
+	file := &stream.FileStream{
+		Obj:    &model.Object{Name: "big.dat", Size: int64(len(data)), Modified: time.Now()},
+		Reader: bytes.NewReader(data),
+	}
+	if err := op.Put(ctx, d, "/", file, func(float64) {}); err != nil {
+		t.Fatalf("failed put: %+v", err)
+	}
+	chunks, err := os.ReadDir(filepath.Join(remoteRoot, filepath.Base(d.RemotePath), "big.dat"+chunksSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 chunks and the meta
+	if len(chunks) != 4 {
+		t.Fatalf("expect 4 files in the chunks folder, got %d", len(chunks))
+	}
+
+	obj, err := op.Get(ctx, d, "/big.dat")
+	if err != nil {
+		t.Fatalf("failed get: %+v", err)
+	}
+	if obj.IsDir() || obj.GetSize() != int64(len(data)) {
+		t.Fatalf("expect a file of size %d, got dir=%v size=%d", len(data), obj.IsDir(), obj.GetSize())
+	}
+	link, _, err := op.Link(ctx, d, "/big.dat", model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed link: %+v", err)
+	}
+	for _, r := range []http_range.Range{
+		{Start: 0, Length: -1},
+		{Start: utils.MB - 10, Length: 20},
+		{Start: 100, Length: 2*utils.MB + 10},
+	} {
+		rc, err := link.RangeReadCloser.RangeRead(ctx, r)
+		if err != nil {
+			t.Fatalf("failed range read %+v: %+v", r, err)
+		}
+		got, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("failed read %+v: %+v", r, err)
+		}
+		end := int64(len(data))
+		if r.Length >= 0 {
+			end = r.Start + r.Length
+		}
+		if !bytes.Equal(got, data[r.Start:end]) {
+			t.Fatalf("range %+v mismatch", r)
+		}
+	}
+
+	if err = op.Rename(ctx, d, "/big.dat", "big2.dat"); err != nil {
+		t.Fatalf("failed rename: %+v", err)
+	}
+	if _, err = op.Get(ctx, d, "/big2.dat"); err != nil {
+		t.Fatalf("renamed file not found: %+v", err)
+	}
+	if err = op.Remove(ctx, d, "/big2.dat"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(remoteRoot, filepath.Base(d.RemotePath)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expect the chunks removed, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,27 @@
+package chunker
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	RemotePath string `json:"remote_path" required:"true" help:"This is where the chunks store"`
+	ChunkSize  int64  `json:"chunk_size" type:"number" required:"true" default:"100" help:"Unit: MB, files not larger than it are stored as they are"`
+	ChunkExt   string `json:"chunk_ext" default:".bin" help:"Extension of the chunk files, for the storages limiting file types"`
+	ChunkHash  bool   `json:"chunk_hash" type:"bool" default:"true" help:"Store the md5 of every chunk and verify it when a whole chunk is read"`
+}
+
+var config = driver.Config{
+	Name:        "Chunker",
+	LocalSort:   true,
+	OnlyProxy:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Chunker{}
+	})
+}
@@ -0,0 +1,18 @@
+package chunker
+
+import "time"
+
+// Meta is stored along with the chunks of a file
+type Meta struct {
+	Version   int       `json:"version"`
+	Size      int64     `json:"size"`
+	ChunkSize int64     `json:"chunk_size"`
+	Chunks    int       `json:"chunks"`
+	Modified  time.Time `json:"modified"`
+	// md5 of every chunk, empty if chunk_hash is off
+	MD5 []string `json:"md5,omitempty"`
+}
+
+func (m *Meta) chunkLength(i int) int64 {
+	return min(m.ChunkSize, m.Size-int64(i)*m.ChunkSize)
+}
@@ -0,0 +1,227 @@
+package chunker
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func chunkName(i int, ext string) string {
+	return fmt.Sprintf("%05d%s", i, ext)
+}
+
+func toObj(path string, obj model.Obj) model.Obj {
+	return &model.Object{
+		Path:     path,
+		Name:     stdpath.Base(path),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}
+}
+
+func (d *Chunker) getPathForRemote(path string) string {
+	return stdpath.Join(d.RemotePath, path)
+}
+
+// actual path is used for internal only. any link for user should come from remoteFullPath
+func (d *Chunker) getActualPathForRemote(path string) (string, error) {
+	_, remoteActualPath, err := op.GetStorageAndActualPath(d.getPathForRemote(path))
+	return remoteActualPath, err
+}
+
+// getActualPathForObj returns the path of the chunks folder if the obj is chunked
+func (d *Chunker) getActualPathForObj(ctx context.Context, obj model.Obj) (string, error) {
+	remoteActualPath, err := d.getActualPathForRemote(obj.GetPath())
+	if err != nil {
+		return "", err
+	}
+	if !obj.IsDir() && d.isChunked(ctx, obj.GetPath()) {
+		return remoteActualPath + chunksSuffix, nil
+	}
+	return remoteActualPath, nil
+}
+
+func (d *Chunker) isChunked(ctx context.Context, path string) bool {
+	obj, err := fs.Get(ctx, d.getPathForRemote(path)+chunksSuffix, &fs.GetArgs{NoLog: true})
+	return err == nil && obj.IsDir()
+}
+
+// chunkedObj builds the file from its chunks folder without reading the meta
+func (d *Chunker) chunkedObj(ctx context.Context, path string, refresh bool) (model.Obj, error) {
+	chunks, err := fs.List(ctx, d.getPathForRemote(path)+chunksSuffix, &fs.ListArgs{NoLog: true, Refresh: refresh})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		size     int64
+		modified time.Time
+		hasMeta  bool
+	)
+	for _, chunk := range chunks {
+		if chunk.IsDir() {
+			continue
+		}
+		if chunk.GetName() == metaName {
+			hasMeta = true
+			continue
+		}
+		size += chunk.GetSize()
+		if chunk.ModTime().After(modified) {
+			modified = chunk.ModTime()
+		}
+	}
+	if !hasMeta {
+		return nil, errs.ObjectNotFound
+	}
+	return &model.Object{
+		Path:     path,
+		Name:     stdpath.Base(path),
+		Size:     size,
+		Modified: modified,
+	}, nil
+}
+
+func (d *Chunker) readMeta(ctx context.Context, chunksPath string) (*Meta, error) {
+	rc, err := d.open(ctx, stdpath.Join(chunksPath, metaName), model.LinkArgs{}, http_range.Range{Length: -1})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open meta")
+	}
+	defer rc.Close()
+	var meta Meta
+	if err = utils.Json.NewDecoder(rc).Decode(&meta); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode meta")
+	}
+	if meta.Version != 1 || meta.ChunkSize <= 0 ||
+		int64(meta.Chunks) != (meta.Size+meta.ChunkSize-1)/meta.ChunkSize ||
+		(len(meta.MD5) != 0 && len(meta.MD5) != meta.Chunks) {
+		return nil, fmt.Errorf("invalid meta of %s", chunksPath)
+	}
+	return &meta, nil
+}
+
+// open reads the range of a file in the remote storage
+func (d *Chunker) open(ctx context.Context, path string, args model.LinkArgs, r http_range.Range) (io.ReadCloser, error) {
+	link, file, err := op.Link(ctx, d.remoteStorage, path, args)
+	if err != nil {
+		return nil, err
+	}
+	if r.Length < 0 {
+		r.Length = file.GetSize() - r.Start
+	}
+	if link.MFile != nil {
+		return utils.NewReadCloser(io.NewSectionReader(link.MFile, r.Start, r.Length), link.MFile.Close), nil
+	}
+	rrc := link.RangeReadCloser
+	if len(link.URL) > 0 {
+		rrc, err = stream.GetRangeReadCloserFromLink(file.GetSize(), link)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rrc == nil {
+		return nil, fmt.Errorf("the remote storage driver need to be enhanced to support chunker")
+	}
+	rc, err := rrc.RangeRead(ctx, r)
+	if err != nil {
+		_ = rrc.Close()
+		return nil, err
+	}
+	return utils.NewReadCloser(rc, func() error {
+		err := rc.Close()
+		_ = rrc.Close()
+		return err
+	}), nil
+}
+
+// chunksReader stitches the chunks, opening each chunk only when it's reached
+type chunksReader struct {
+	ctx    context.Context
+	d      *Chunker
+	path   string
+	meta   *Meta
+	args   model.LinkArgs
+	offset int64
+	remain int64
+
+	cur    io.ReadCloser
+	curEnd int64
+	hash   hash.Hash
+	md5    string
+}
+
+func (r *chunksReader) next() error {
+	i := int(r.offset / r.meta.ChunkSize)
+	start := r.offset - int64(i)*r.meta.ChunkSize
+	length := min(r.meta.chunkLength(i)-start, r.remain)
+	rc, err := r.d.open(r.ctx, stdpath.Join(r.path, chunkName(i, r.d.ChunkExt)), r.args, http_range.Range{Start: start, Length: length})
+	if err != nil {
+		return fmt.Errorf("failed to open chunk %d: %w", i, err)
+	}
+	r.cur = rc
+	r.curEnd = r.offset + length
+	r.hash = nil
+	// only a whole chunk can be verified
+	if len(r.meta.MD5) > 0 && start == 0 && length == r.meta.chunkLength(i) {
+		r.hash = md5.New()
+		r.md5 = r.meta.MD5[i]
+	}
+	return nil
+}
+
+func (r *chunksReader) Read(p []byte) (int, error) {
+	if r.remain <= 0 {
+		return 0, io.EOF
+	}
+	if r.cur == nil {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	p = p[:min(int64(len(p)), r.curEnd-r.offset)]
+	n, err := r.cur.Read(p)
+	if r.hash != nil {
+		r.hash.Write(p[:n])
+	}
+	r.offset += int64(n)
+	r.remain -= int64(n)
+	if r.offset < r.curEnd {
+		if err == io.EOF {
+			return n, io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+	// the chunk is done
+	_ = r.cur.Close()
+	r.cur = nil
+	if r.hash != nil && !strings.EqualFold(hex.EncodeToString(r.hash.Sum(nil)), r.md5) {
+		return n, fmt.Errorf("md5 mismatch of chunk %d", (r.offset-1)/r.meta.ChunkSize)
+	}
+	return n, nil
+}
+
+func (r *chunksReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
@@ -0,0 +1,25 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/driver/drivertest"
+)
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, drivertest.Options{
+		New: func(t *testing.T) driver.Driver {
+			d := &Local{}
+			d.RootFolderPath = t.TempDir()
+			d.MkdirPerm = "777"
+			d.ShowHidden = true
+			d.RecycleBinPath = "delete permanently"
+			if err := d.Init(context.Background()); err != nil {
+				t.Fatalf("failed init local: %+v", err)
+			}
+			return d
+		},
+	})
+}
@@ -0,0 +1,257 @@
+package union
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Union struct {
+	model.Storage
+	Addition
+	upstreams []string
+	rrIndex   atomic.Uint32
+}
+
+func (d *Union) Config() driver.Config {
+	return config
+}
+
+func (d *Union) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Union) Init(ctx context.Context) error {
+	d.upstreams = nil
+	for _, path := range strings.Split(d.Upstreams, "\n") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		path = utils.FixAndCleanPath(path)
+		if utils.IsSubPath(path, d.MountPath) || utils.IsSubPath(d.MountPath, path) {
+			return errors.Errorf("upstream %s overlaps the union itself", path)
+		}
+		d.upstreams = append(d.upstreams, path)
+	}
+	if len(d.upstreams) == 0 {
+		return errors.New("upstreams is required")
+	}
+	if !utils.SliceContains([]string{"ff", "epff", "mfs", "epmfs", "rr"}, d.CreatePolicy) {
+		return errors.Errorf("unknown create policy: %s", d.CreatePolicy)
+	}
+	if !utils.SliceContains([]string{"ff", "newest", "largest"}, d.SearchPolicy) {
+		return errors.Errorf("unknown search policy: %s", d.SearchPolicy)
+	}
+	return nil
+}
+
+func (d *Union) Drop(ctx context.Context) error {
+	d.upstreams = nil
+	return nil
+}
+
+func (d *Union) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+			Modified: d.Modified,
+		}, nil
+	}
+	found := d.find(ctx, path)
+	if len(found) == 0 {
+		return nil, errs.ObjectNotFound
+	}
+	obj := d.pick(found).obj
+	return &model.Object{
+		Path:     path,
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}, nil
+}
+
+func (d *Union) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	path := dir.GetPath()
+	fsArgs := &fs.ListArgs{NoLog: true, Refresh: args.Refresh}
+	var (
+		names  []string
+		merged = make(map[string][]upstreamObj)
+		listed bool
+		err    error
+	)
+	for _, upstream := range d.upstreams {
+		var objs []model.Obj
+		objs, err = fs.List(ctx, stdpath.Join(upstream, path), fsArgs)
+		if err != nil {
+			continue
+		}
+		listed = true
+		for _, obj := range objs {
+			name := obj.GetName()
+			if _, ok := merged[name]; !ok {
+				names = append(names, name)
+			}
+			merged[name] = append(merged[name], upstreamObj{upstream: upstream, obj: obj})
+		}
+	}
+	if !listed {
+		return nil, err
+	}
+	res := make([]model.Obj, 0, len(names))
+	for _, name := range names {
+		res = append(res, toObj(path, d.pick(merged[name]).obj))
+	}
+	return res, nil
+}
+
+func (d *Union) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	found := d.find(ctx, file.GetPath())
+	if len(found) == 0 {
+		return nil, errs.ObjectNotFound
+	}
+	link, err := d.link(ctx, stdpath.Join(d.pick(found).upstream, file.GetPath()), args)
+	if err != nil {
+		return nil, err
+	}
+	if !args.Redirect && len(link.URL) > 0 {
+		if d.DownloadConcurrency > 0 {
+			link.Concurrency = d.DownloadConcurrency
+		}
+		if d.DownloadPartSize > 0 {
+			link.PartSize = d.DownloadPartSize * utils.KB
+		}
+	}
+	return link, nil
+}
+
+func (d *Union) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	upstream, err := d.create(ctx, parentDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return fs.MakeDir(ctx, stdpath.Join(upstream, parentDir.GetPath(), dirName))
+}
+
+func (d *Union) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	// every copy is moved inside its own upstream, so nothing is transferred between upstreams,
+	// the dst dir may only exist in the other upstreams so that it's made first
+	return d.forEach(ctx, srcObj.GetPath(), func(upstream string) error {
+		dst := stdpath.Join(upstream, dstDir.GetPath())
+		if err := fs.MakeDir(ctx, dst); err != nil {
+			return err
+		}
+		return fs.Move(ctx, stdpath.Join(upstream, srcObj.GetPath()), dst)
+	})
+}
+
+func (d *Union) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	return d.forEach(ctx, srcObj.GetPath(), func(upstream string) error {
+		return fs.Rename(ctx, stdpath.Join(upstream, srcObj.GetPath()), newName)
+	})
+}
+
+func (d *Union) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	found := d.find(ctx, srcObj.GetPath())
+	if len(found) == 0 {
+		return errs.ObjectNotFound
+	}
+	upstream := d.pick(found).upstream
+	dst := stdpath.Join(upstream, dstDir.GetPath())
+	if err := fs.MakeDir(ctx, dst); err != nil {
+		return err
+	}
+	_, err := fs.Copy(ctx, stdpath.Join(upstream, srcObj.GetPath()), dst)
+	return err
+}
+
+func (d *Union) Remove(ctx context.Context, obj model.Obj) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	return d.forEach(ctx, obj.GetPath(), func(upstream string) error {
+		return fs.Remove(ctx, stdpath.Join(upstream, obj.GetPath()))
+	})
+}
+
+func (d *Union) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up driver.UpdateProgress) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	upstream, err := d.createFile(ctx, dstDir.GetPath(), s.GetName())
+	if err != nil {
+		return err
+	}
+	return fs.PutDirectly(ctx, stdpath.Join(upstream, dstDir.GetPath()), s)
+}
+
+func (d *Union) PutURL(ctx context.Context, dstDir model.Obj, name, url string) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	upstream, err := d.createFile(ctx, dstDir.GetPath(), name)
+	if err != nil {
+		return err
+	}
+	return fs.PutURL(ctx, stdpath.Join(upstream, dstDir.GetPath()), name, url)
+}
+
+// GetDetails sums the space of the upstream storages, each storage is counted once
+func (d *Union) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	var (
+		details model.StorageDetails
+		counted = make(map[string]struct{})
+		ok      bool
+	)
+	for _, upstream := range d.upstreams {
+		storage, _, err := op.GetStorageAndActualPath(upstream)
+		if err != nil {
+			continue
+		}
+		if _, c := counted[storage.GetStorage().MountPath]; c {
+			continue
+		}
+		counted[storage.GetStorage().MountPath] = struct{}{}
+		s, err := op.GetStorageDetails(ctx, storage)
+		if err != nil {
+			continue
+		}
+		ok = true
+		details.TotalSpace += s.TotalSpace
+		details.FreeSpace += s.FreeSpace
+	}
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	return &details, nil
+}
+
+var _ driver.Driver = (*Union)(nil)
+var _ driver.PutURL = (*Union)(nil)
+var _ driver.WithDetails = (*Union)(nil)
@@ -0,0 +1,137 @@
+package union
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/driver/drivertest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var roots = map[string]string{"/a": "", "/b": ""}
+
+func TestMain(m *testing.M) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+	for mountPath := range roots {
+		root, err := os.MkdirTemp("", "union")
+		if err != nil {
+			panic(err)
+		}
+		roots[mountPath] = root
+		_, err = op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q,"show_hidden":true,"mkdir_perm":"777","recycle_bin_path":"delete permanently"}`, root),
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	code := m.Run()
+	for _, root := range roots {
+		_ = os.RemoveAll(root)
+	}
+	os.Exit(code)
+}
+
+// newUnion returns a union over an empty folder in each of the upstreams
+func newUnion(t *testing.T) (*Union, map[string]string) {
+	dirs := make(map[string]string)
+	upstreams := ""
+	for _, mountPath := range []string{"/a", "/b"} {
+		dir, err := os.MkdirTemp(roots[mountPath], "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs[mountPath] = dir
+		upstreams += mountPath + "/" + filepath.Base(dir) + "\n"
+	}
+	d := &Union{Addition: Addition{
+		Upstreams:    upstreams,
+		CreatePolicy: "ff",
+		SearchPolicy: "ff",
+		Writable:     true,
+	}}
+	d.MountPath = "/union"
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatalf("failed init union: %+v", err)
+	}
+	return d, dirs
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, drivertest.Options{
+		New: func(t *testing.T) driver.Driver {
+			d, _ := newUnion(t)
+			return d
+		},
+	})
+}
+
+func TestMoveToDirOfOtherUpstream(t *testing.T) {
+	d, dirs := newUnion(t)
+	ctx := context.Background()
+	// the file is only in /b, the dst dir only in /a
+	if err := os.WriteFile(filepath.Join(dirs["/b"], "f.txt"), []byte("hello"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dirs["/a"], "dst"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	src, err := d.Get(ctx, "/f.txt")
+	if err != nil {
+		t.Fatalf("failed get src: %+v", err)
+	}
+	dst, err := d.Get(ctx, "/dst")
+	if err != nil {
+		t.Fatalf("failed get dst: %+v", err)
+	}
+	if err = d.Move(ctx, src, dst); err != nil {
+		t.Fatalf("failed move: %+v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dirs["/b"], "dst", "f.txt"))
+	if err != nil || string(b) != "hello" {
+		t.Fatalf("the file is not moved inside its upstream: %q, %v", b, err)
+	}
+	if _, err = d.Get(ctx, "/f.txt"); err == nil {
+		t.Fatal("the src is still there after move")
+	}
+	if _, err = d.Get(ctx, "/dst/f.txt"); err != nil {
+		t.Fatalf("the moved file is not listed: %+v", err)
+	}
+}
+
+func TestCopyToDirOfOtherUpstream(t *testing.T) {
+	d, dirs := newUnion(t)
+	ctx := context.Background()
+	if err := os.WriteFile(filepath.Join(dirs["/b"], "f.txt"), []byte("hello"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dirs["/a"], "dst"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := d.Get(ctx, "/f.txt")
+	dst, _ := d.Get(ctx, "/dst")
+	if err := d.Copy(ctx, src, dst); err != nil {
+		t.Fatalf("failed copy: %+v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dirs["/b"], "dst", "f.txt"))
+	if err != nil || string(b) != "hello" {
+		t.Fatalf("the file is not copied inside its upstream: %q, %v", b, err)
+	}
+}
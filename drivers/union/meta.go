@@ -0,0 +1,29 @@
+package union
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	Upstreams           string `json:"upstreams" required:"true" type:"text" help:"One alist path per line, the first has the highest priority"`
+	CreatePolicy        string `json:"create_policy" type:"select" options:"ff,epff,mfs,epmfs,rr" default:"epff" help:"ff: first found, mfs: most free space, rr: round robin, ep: only upstreams where the parent path exists"`
+	SearchPolicy        string `json:"search_policy" type:"select" options:"ff,newest,largest" default:"ff" help:"Which one to use when a file exists in several upstreams"`
+	Writable            bool   `json:"writable" type:"bool" default:"false"`
+	DownloadConcurrency int    `json:"download_concurrency" default:"0" required:"false" type:"number" help:"Need to enable proxy"`
+	DownloadPartSize    int    `json:"download_part_size" default:"0" type:"number" required:"false" help:"Need to enable proxy. Unit: KB"`
+}
+
+var config = driver.Config{
+	Name:             "Union",
+	LocalSort:        true,
+	NoCache:          true,
+	DefaultRoot:      "/",
+	ProxyRangeOption: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Union{}
+	})
+}
@@ -0,0 +1,179 @@
+package union
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+)
+
+// upstreamObj is an obj found in one of the upstreams
+type upstreamObj struct {
+	upstream string
+	obj      model.Obj
+}
+
+func toObj(dir string, obj model.Obj) model.Obj {
+	objRes := model.Object{
+		Path:     stdpath.Join(dir, obj.GetName()),
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}
+	thumb, ok := model.GetThumb(obj)
+	if !ok {
+		return &objRes
+	}
+	return &model.ObjThumb{
+		Object: objRes,
+		Thumbnail: model.Thumbnail{
+			Thumbnail: thumb,
+		},
+	}
+}
+
+// find gets the path in every upstream, in the order of upstreams
+func (d *Union) find(ctx context.Context, path string) []upstreamObj {
+	var found []upstreamObj
+	for _, upstream := range d.upstreams {
+		obj, err := fs.Get(ctx, stdpath.Join(upstream, path), &fs.GetArgs{NoLog: true})
+		if err == nil {
+			found = append(found, upstreamObj{upstream: upstream, obj: obj})
+		}
+	}
+	return found
+}
+
+// pick dedupes the same-name objs found in several upstreams.
+// The first found one decides whether it's a folder or a file,
+// objs of the other type are hidden. Folders are merged, so the first one is returned,
+// files are chosen by the search policy.
+func (d *Union) pick(found []upstreamObj) upstreamObj {
+	res := found[0]
+	if res.obj.IsDir() {
+		return res
+	}
+	for _, o := range found[1:] {
+		if o.obj.IsDir() {
+			continue
+		}
+		switch d.SearchPolicy {
+		case "newest":
+			if o.obj.ModTime().After(res.obj.ModTime()) {
+				res = o
+			}
+		case "largest":
+			if o.obj.GetSize() > res.obj.GetSize() {
+				res = o
+			}
+		}
+	}
+	return res
+}
+
+// forEach calls fn with every upstream the path exists in
+func (d *Union) forEach(ctx context.Context, path string, fn func(upstream string) error) error {
+	found := d.find(ctx, path)
+	if len(found) == 0 {
+		return errs.ObjectNotFound
+	}
+	var err error
+	for _, o := range found {
+		err = stderrors.Join(err, fn(o.upstream))
+	}
+	return err
+}
+
+// create chooses the upstream to create a new obj in the dir by the create policy
+func (d *Union) create(ctx context.Context, dir string) (string, error) {
+	candidates := d.upstreams
+	if d.CreatePolicy == "epff" || d.CreatePolicy == "epmfs" {
+		// path preserving, only the upstreams that already have the dir
+		candidates = nil
+		for _, o := range d.find(ctx, dir) {
+			if o.obj.IsDir() {
+				candidates = append(candidates, o.upstream)
+			}
+		}
+		if len(candidates) == 0 {
+			return "", errs.ObjectNotFound
+		}
+	}
+	switch d.CreatePolicy {
+	case "mfs", "epmfs":
+		return d.mostFreeSpace(ctx, candidates), nil
+	case "rr":
+		i := d.rrIndex.Add(1) - 1
+		return candidates[int(i%uint32(len(candidates)))], nil
+	default:
+		return candidates[0], nil
+	}
+}
+
+// createFile writes to the upstream that has the file already so that it is overwritten,
+// otherwise chooses by the create policy
+func (d *Union) createFile(ctx context.Context, dir, name string) (string, error) {
+	found := d.find(ctx, stdpath.Join(dir, name))
+	if len(found) > 0 {
+		return d.pick(found).upstream, nil
+	}
+	return d.create(ctx, dir)
+}
+
+// mostFreeSpace returns the candidate with the most free space,
+// the upstreams not reporting their space are skipped, fall back to the first one
+func (d *Union) mostFreeSpace(ctx context.Context, candidates []string) string {
+	res := candidates[0]
+	var most int64 = -1
+	for _, upstream := range candidates {
+		storage, _, err := op.GetStorageAndActualPath(upstream)
+		if err != nil {
+			continue
+		}
+		details, err := op.GetStorageDetails(ctx, storage)
+		if err != nil {
+			continue
+		}
+		if details.FreeSpace > most {
+			most = details.FreeSpace
+			res = upstream
+		}
+	}
+	return res
+}
+
+func (d *Union) link(ctx context.Context, reqPath string, args model.LinkArgs) (*model.Link, error) {
+	storage, reqActualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := storage.(*Union); !ok && !args.Redirect {
+		link, _, err := op.Link(ctx, storage, reqActualPath, args)
+		return link, err
+	}
+	if common.ShouldProxy(storage, stdpath.Base(reqPath)) {
+		link := &model.Link{
+			URL: fmt.Sprintf("%s/p%s?sign=%s",
+				common.GetApiUrl(args.HttpReq),
+				utils.EncodePath(reqPath, true),
+				sign.Sign(reqPath)),
+		}
+		if args.HttpReq != nil && d.ProxyRange {
+			link.RangeReadCloser = common.NoProxyRange
+		}
+		return link, nil
+	}
+	link, _, err := op.Link(ctx, storage, reqActualPath, args)
+	return link, err
+}
@@ -0,0 +1,211 @@
+// Package bandwidth limits the transfers of every user by its limit profile,
+// the global client limits are shared fairly between the users transferring
+package bandwidth
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+type Direction int
+
+const (
+	Download Direction = iota
+	Upload
+)
+
+// minBurst keeps the waits of the small limits from being too fine-grained
+const minBurst = 32 * 1024
+
+type userState struct {
+	profile   [2]rate.Limit
+	limiters  [2]*rate.Limiter
+	active    [2]int
+	transfers int
+}
+
+var (
+	mu    sync.Mutex
+	users = map[uint]*userState{}
+)
+
+func global(d Direction) stream.Limiter {
+	if d == Download {
+		return stream.ClientDownloadLimit
+	}
+	return stream.ClientUploadLimit
+}
+
+func speed(kb int) rate.Limit {
+	if kb <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(kb * 1024)
+}
+
+func burst(l rate.Limit) int {
+	if l == rate.Inf || l > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return max(int(l), minBurst)
+}
+
+// Transfer is a download or an upload of a user, it must be released when it's done
+type Transfer struct {
+	state *userState
+	id    uint
+	dir   Direction
+	once  sync.Once
+}
+
+// Acquire starts a transfer of the user, a nil user has no limit profile,
+// errs.TooManyTransfers is returned if the user reaches the max transfers of its profile
+func Acquire(user *model.User, d Direction) (*Transfer, error) {
+	var profile *model.LimitProfile
+	var id uint
+	if user != nil {
+		id = user.ID
+		if user.LimitProfileID != 0 {
+			p, err := op.GetLimitProfileById(user.LimitProfileID)
+			if err != nil {
+				log.Warnf("failed get limit profile of user [%s]: %+v", user.Username, err)
+			} else {
+				profile = p
+			}
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	u, ok := users[id]
+	if !ok {
+		u = &userState{limiters: [2]*rate.Limiter{
+			rate.NewLimiter(rate.Inf, math.MaxInt32),
+			rate.NewLimiter(rate.Inf, math.MaxInt32),
+		}}
+	}
+	u.profile = [2]rate.Limit{rate.Inf, rate.Inf}
+	if profile != nil {
+		if profile.MaxTransfers > 0 && u.transfers >= profile.MaxTransfers {
+			return nil, errs.TooManyTransfers
+		}
+		u.profile = [2]rate.Limit{speed(profile.DownloadSpeed), speed(profile.UploadSpeed)}
+	}
+	users[id] = u
+	u.transfers++
+	u.active[d]++
+	rebalance(d)
+	return &Transfer{state: u, id: id, dir: d}, nil
+}
+
+// Release ends the transfer, the shares of the other users grow
+func (t *Transfer) Release() {
+	t.once.Do(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		u := t.state
+		u.transfers--
+		u.active[t.dir]--
+		if u.transfers == 0 {
+			delete(users, t.id)
+		}
+		rebalance(t.dir)
+	})
+}
+
+// rebalance sets the limit of every user transferring in the direction
+// to the lower one of its profile and its share of the global limit
+func rebalance(d Direction) {
+	n := 0
+	for _, u := range users {
+		if u.active[d] > 0 {
+			n++
+		}
+	}
+	share := rate.Inf
+	if g := global(d); g != nil && g.Limit() != rate.Inf && n > 0 {
+		share = g.Limit() / rate.Limit(n)
+	}
+	for _, u := range users {
+		if u.active[d] == 0 {
+			continue
+		}
+		l := min(u.profile[d], share)
+		u.limiters[d].SetLimit(l)
+		u.limiters[d].SetBurst(burst(l))
+	}
+}
+
+// WaitN blocks until n bytes may be transferred by both the user and the global limit
+func (t *Transfer) WaitN(ctx context.Context, n int) error {
+	l := t.state.limiters[t.dir]
+	for rest := n; rest > 0; {
+		b := min(rest, l.Burst())
+		if err := l.WaitN(ctx, b); err != nil {
+			return err
+		}
+		rest -= b
+	}
+	if g := global(t.dir); g != nil {
+		return g.WaitN(ctx, n)
+	}
+	return nil
+}
+
+// Reader limits the reading of r, closing the returned reader releases the transfer
+func (t *Transfer) Reader(ctx context.Context, r io.Reader) io.ReadCloser {
+	return &reader{Reader: r, t: t, ctx: ctx}
+}
+
+// Writer limits the writing of w
+func (t *Transfer) Writer(ctx context.Context, w io.Writer) io.Writer {
+	return &writer{Writer: w, t: t, ctx: ctx}
+}
+
+type reader struct {
+	io.Reader
+	t   *Transfer
+	ctx context.Context
+}
+
+func (r *reader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	if n > 0 {
+		if e := r.t.WaitN(r.ctx, n); e != nil {
+			return n, e
+		}
+	}
+	return
+}
+
+func (r *reader) Close() error {
+	r.t.Release()
+	if c, ok := r.Reader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+type writer struct {
+	io.Writer
+	t   *Transfer
+	ctx context.Context
+}
+
+func (w *writer) Write(p []byte) (n int, err error) {
+	n, err = w.Writer.Write(p)
+	if n > 0 {
+		if e := w.t.WaitN(w.ctx, n); e != nil {
+			return n, e
+		}
+	}
+	return
+}
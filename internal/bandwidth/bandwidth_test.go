@@ -0,0 +1,91 @@
+package bandwidth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"golang.org/x/time/rate"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestFairShare(t *testing.T) {
+	old := stream.ClientDownloadLimit
+	defer func() { stream.ClientDownloadLimit = old }()
+	stream.ClientDownloadLimit = rate.NewLimiter(1000, 1000)
+
+	a, err := Acquire(&model.User{ID: 100}, Download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := a.state.limiters[Download].Limit(); l != 1000 {
+		t.Errorf("limit of the only user: got %v, want 1000", l)
+	}
+	b, err := Acquire(&model.User{ID: 101}, Download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second transfer of a user doesn't take a larger share
+	a2, err := Acquire(&model.User{ID: 100}, Download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tr := range []*Transfer{a, b, a2} {
+		if l := tr.state.limiters[Download].Limit(); l != 500 {
+			t.Errorf("limit of user %d: got %v, want 500", tr.id, l)
+		}
+	}
+	b.Release()
+	b.Release()
+	if l := a.state.limiters[Download].Limit(); l != 1000 {
+		t.Errorf("limit after release: got %v, want 1000", l)
+	}
+	a.Release()
+	a2.Release()
+	if len(users) != 0 {
+		t.Errorf("users left after release: %d", len(users))
+	}
+}
+
+func TestMaxTransfers(t *testing.T) {
+	p := &model.LimitProfile{Name: "two", MaxTransfers: 2}
+	if err := op.CreateLimitProfile(p); err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 200, LimitProfileID: p.ID}
+	a, err := Acquire(user, Download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the uploads and the downloads are counted together
+	b, err := Acquire(user, Upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Acquire(user, Download); !errors.Is(err, errs.TooManyTransfers) {
+		t.Errorf("third transfer: got %v, want %v", err, errs.TooManyTransfers)
+	}
+	a.Release()
+	c, err := Acquire(user, Download)
+	if err != nil {
+		t.Errorf("transfer after release: %v", err)
+	} else {
+		c.Release()
+	}
+	b.Release()
+}
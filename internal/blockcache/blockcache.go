@@ -0,0 +1,62 @@
+package blockcache
+
+import (
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	log "github.com/sirupsen/logrus"
+)
+
+var instance atomic.Pointer[Cache]
+
+// Init opens the cache in dir, a capacity <= 0 disables it
+func Init(dir string, capacity int64) error {
+	c, err := New(dir, max(capacity, 0))
+	if err != nil {
+		return err
+	}
+	instance.Store(c)
+	return nil
+}
+
+func Instance() *Cache {
+	return instance.Load()
+}
+
+func Enabled() bool {
+	c := instance.Load()
+	return c != nil && c.Stats().Capacity > 0
+}
+
+// Wrap returns a copy of the link of the file at the mount path whose ranges are read through the cache,
+// the link is returned as is if it can't be cached
+func Wrap(path string, file model.Obj, link *model.Link) *model.Link {
+	c := instance.Load()
+	if c == nil || link.MFile != nil || file.GetSize() <= 0 || !Enabled() {
+		return link
+	}
+	upstream := link.RangeReadCloser
+	// a RangeReadCloser without reader only asks not to proxy the range
+	if rrc, ok := upstream.(*model.RangeReadCloser); ok && rrc.RangeReader == nil {
+		upstream = nil
+	}
+	if upstream == nil {
+		if link.URL == "" {
+			return link
+		}
+		var err error
+		if upstream, err = stream.GetRangeReadCloserFromLink(file.GetSize(), link); err != nil {
+			log.Warnf("failed cache %s: %+v", path, err)
+			return link
+		}
+	}
+	l := *link
+	l.RangeReadCloser = &rangeReadCloser{
+		RangeReadCloserIF: upstream,
+		c:                 c,
+		key:               Key(path, file.GetSize(), file.ModTime()),
+		size:              file.GetSize(),
+	}
+	return &l
+}
@@ -0,0 +1,240 @@
+package blockcache
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// BlockSize is the size of the blocks the files are cached in, the last block of a file may be shorter
+const BlockSize = 1 << 20
+
+// Cache keeps the blocks of the proxied files on disk and evicts the least recently used ones
+type Cache struct {
+	dir string
+
+	mu       sync.Mutex
+	capacity int64
+	used     int64
+	lru      *list.List // the front is the most recently used
+	blocks   map[string]*list.Element
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type block struct {
+	id   string // <key>/<index>
+	size int64
+}
+
+type Stats struct {
+	Dir       string `json:"dir"`
+	Capacity  int64  `json:"capacity"`
+	Used      int64  `json:"used"`
+	Blocks    int    `json:"blocks"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// New opens the cache in dir, the blocks left by the last run are kept
+func New(dir string, capacity int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c := &Cache{
+		dir:      dir,
+		capacity: capacity,
+		lru:      list.New(),
+		blocks:   make(map[string]*list.Element),
+	}
+	type found struct {
+		block
+		modTime time.Time
+	}
+	var blocks []found
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if _, e := strconv.ParseInt(parts[len(parts)-1], 10, 64); e != nil || len(parts) != 3 {
+			// unfinished writes
+			return os.Remove(path)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, found{block{id: parts[1] + "/" + parts[2], size: info.Size()}, info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].modTime.After(blocks[j].modTime)
+	})
+	for _, b := range blocks {
+		c.blocks[b.id] = c.lru.PushBack(&b.block)
+		c.used += b.size
+	}
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Key identifies a version of a file, the blocks of the other versions are never hit again and get evicted
+func Key(path string, size int64, modTime time.Time) string {
+	h := sha1.Sum([]byte(path + "\n" + strconv.FormatInt(size, 10) + "\n" + strconv.FormatInt(modTime.UnixNano(), 10)))
+	return hex.EncodeToString(h[:])
+}
+
+func (c *Cache) path(id string) string {
+	return filepath.Join(c.dir, id[:2], filepath.FromSlash(id))
+}
+
+// get reads the block into buf, which must be large enough
+func (c *Cache) get(key string, index int64, buf []byte) ([]byte, bool) {
+	id := key + "/" + strconv.FormatInt(index, 10)
+	c.mu.Lock()
+	e, ok := c.blocks[id]
+	if !ok {
+		c.mu.Unlock()
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	size := e.Value.(*block).size
+	c.mu.Unlock()
+	f, err := os.Open(c.path(id))
+	if err == nil {
+		defer f.Close()
+		_, err = io.ReadFull(f, buf[:size])
+	}
+	if err != nil {
+		log.Warnf("failed read cached block %s: %+v", id, err)
+		c.mu.Lock()
+		if e, ok := c.blocks[id]; ok {
+			c.remove(e)
+		}
+		c.mu.Unlock()
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return buf[:size], true
+}
+
+// put stores a block, the file is renamed into place so that readers never see a partial block
+func (c *Cache) put(key string, index int64, data []byte) {
+	size := int64(len(data))
+	c.mu.Lock()
+	capacity := c.capacity
+	c.mu.Unlock()
+	if size > capacity {
+		return
+	}
+	id := key + "/" + strconv.FormatInt(index, 10)
+	p := c.path(id)
+	err := os.MkdirAll(filepath.Dir(p), 0o777)
+	if err != nil {
+		log.Warnf("failed cache block %s: %+v", id, err)
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), "tmp-*")
+	if err != nil {
+		log.Warnf("failed cache block %s: %+v", id, err)
+		return
+	}
+	_, err = f.Write(data)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		log.Warnf("failed cache block %s: %+v", id, err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.blocks[id]; ok {
+		c.used -= e.Value.(*block).size
+		e.Value.(*block).size = size
+		c.lru.MoveToFront(e)
+	} else {
+		c.blocks[id] = c.lru.PushFront(&block{id: id, size: size})
+	}
+	c.used += size
+	c.evict()
+}
+
+// remove must be called with mu held
+func (c *Cache) remove(e *list.Element) {
+	b := c.lru.Remove(e).(*block)
+	delete(c.blocks, b.id)
+	c.used -= b.size
+	if err := os.Remove(c.path(b.id)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed remove cached block %s: %+v", b.id, err)
+	}
+	// the folder of the file is left when its last block goes
+	_ = os.Remove(filepath.Dir(c.path(b.id)))
+}
+
+// evict must be called with mu held
+func (c *Cache) evict() {
+	for c.used > c.capacity && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *Cache) SetCapacity(capacity int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capacity = capacity
+	c.evict()
+}
+
+// Purge removes all the blocks
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Dir:       c.dir,
+		Capacity:  c.capacity,
+		Used:      c.used,
+		Blocks:    c.lru.Len(),
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
@@ -0,0 +1,80 @@
+package blockcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+func readRange(t *testing.T, rrc model.RangeReadCloserIF, start, length int64) []byte {
+	rc, err := rrc.RangeRead(context.Background(), http_range.Range{Start: start, Length: length})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRangeRead(t *testing.T) {
+	content := make([]byte, BlockSize*5/2)
+	rand.New(rand.NewSource(1)).Read(content)
+	var requests int
+	upstream := &model.RangeReadCloser{RangeReader: func(ctx context.Context, r http_range.Range) (io.ReadCloser, error) {
+		requests++
+		return io.NopCloser(bytes.NewReader(content[r.Start : r.Start+r.Length])), nil
+	}}
+	dir := t.TempDir()
+	c, err := New(dir, 2*BlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rrc := &rangeReadCloser{RangeReadCloserIF: upstream, c: c, key: Key("/a", int64(len(content)), time.Time{}), size: int64(len(content))}
+
+	// the first two blocks are fetched by a single request
+	start, length := int64(100), int64(BlockSize+200)
+	if b := readRange(t, rrc, start, length); !bytes.Equal(b, content[start:start+length]) {
+		t.Fatal("unexpected content")
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+	// a partial hit, only the last block is fetched
+	if b := readRange(t, rrc, BlockSize, -1); !bytes.Equal(b, content[BlockSize:]) {
+		t.Fatal("unexpected content")
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	// the first block is the least recently used one
+	stats := c.Stats()
+	if stats.Blocks != 2 || stats.Evictions != 1 || stats.Used != BlockSize+BlockSize/2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// the blocks are found again after a restart
+	c, err = New(dir, 2*BlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rrc.c = c
+	if b := readRange(t, rrc, BlockSize*2, -1); !bytes.Equal(b, content[BlockSize*2:]) {
+		t.Fatal("unexpected content")
+	}
+	if requests != 2 || c.Stats().Hits != 1 {
+		t.Errorf("expected a hit, got %d requests and %+v", requests, c.Stats())
+	}
+	c.Purge()
+	if stats := c.Stats(); stats.Blocks != 0 || stats.Used != 0 {
+		t.Errorf("unexpected stats after purge %+v", stats)
+	}
+}
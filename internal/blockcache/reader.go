@@ -0,0 +1,113 @@
+package blockcache
+
+import (
+	"context"
+	"io"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// rangeReadCloser serves the ranges from the cached blocks and fills the missing ones from the upstream
+type rangeReadCloser struct {
+	model.RangeReadCloserIF
+	c    *Cache
+	key  string
+	size int64
+}
+
+func (r *rangeReadCloser) RangeRead(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+	if httpRange.Length < 0 || httpRange.Start+httpRange.Length > r.size {
+		httpRange.Length = r.size - httpRange.Start
+	}
+	return &reader{
+		rrc: r,
+		ctx: ctx,
+		pos: httpRange.Start,
+		end: httpRange.Start + httpRange.Length,
+	}, nil
+}
+
+type reader struct {
+	rrc      *rangeReadCloser
+	ctx      context.Context
+	pos, end int64
+	buf      []byte
+	// data is the rest of the current block to be read
+	data []byte
+	// upstream reads the missing blocks in a row, upPos is aligned to the blocks
+	upstream io.ReadCloser
+	upPos    int64
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		if r.pos >= r.end {
+			return 0, io.EOF
+		}
+		if err := r.load(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	r.pos += int64(n)
+	return n, nil
+}
+
+// load reads the block at pos
+func (r *reader) load() error {
+	if utils.IsCanceled(r.ctx) {
+		return r.ctx.Err()
+	}
+	if r.buf == nil {
+		r.buf = make([]byte, BlockSize)
+	}
+	index := r.pos / BlockSize
+	start := index * BlockSize
+	size := min(int64(BlockSize), r.rrc.size-start)
+	var data []byte
+	// keep reading the upstream opened if it's right there, the block is hardly cached meanwhile
+	if r.upstream == nil || r.upPos != start {
+		var ok bool
+		if data, ok = r.rrc.c.get(r.rrc.key, index, r.buf); !ok {
+			if err := r.open(start); err != nil {
+				return err
+			}
+		}
+	}
+	if data == nil {
+		n, err := io.ReadFull(r.upstream, r.buf[:size])
+		r.upPos += int64(n)
+		if err != nil {
+			return err
+		}
+		data = r.buf[:size]
+		r.rrc.c.put(r.rrc.key, index, data)
+	}
+	r.data = data[r.pos-start : min(int64(len(data)), r.end-start)]
+	return nil
+}
+
+// open requests the upstream from start to the end of the block of the range end
+func (r *reader) open(start int64) error {
+	if r.upstream != nil {
+		_ = r.upstream.Close()
+		r.upstream = nil
+	}
+	end := min((r.end+BlockSize-1)/BlockSize*BlockSize, r.rrc.size)
+	rc, err := r.rrc.RangeReadCloserIF.RangeRead(r.ctx, http_range.Range{Start: start, Length: end - start})
+	if err != nil {
+		return err
+	}
+	r.upstream, r.upPos = rc, start
+	return nil
+}
+
+func (r *reader) Close() error {
+	if r.upstream != nil {
+		return r.upstream.Close()
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package bootstrap
+
+import (
+	"path/filepath"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/blockcache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+func blockCacheCapacity() int64 {
+	return int64(setting.GetInt(conf.BlockCacheSize, 0)) * 1024 * 1024
+}
+
+func InitBlockCache() {
+	dir := conf.Conf.BlockCacheDir
+	// the config files written before don't have it
+	if dir == "" {
+		dir = filepath.Join(flags.DataDir, "block_cache")
+	}
+	if err := blockcache.Init(dir, blockCacheCapacity()); err != nil {
+		log.Errorf("failed init block cache: %+v", err)
+		return
+	}
+	op.RegisterSettingChangingCallback(func() {
+		blockcache.Instance().SetCapacity(max(blockCacheCapacity(), 0))
+	})
+}
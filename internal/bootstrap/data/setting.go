@@ -142,6 +142,16 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.AudioAutoplay, Value: "true", Type: conf.TypeBool, Group: model.PREVIEW},
 		{Key: conf.VideoAutoplay, Value: "true", Type: conf.TypeBool, Group: model.PREVIEW},
 		{Key: conf.PreviewArchivesByDefault, Value: "true", Type: conf.TypeBool, Group: model.PREVIEW},
+		{Key: conf.ThumbnailEnabled, Value: "false", Type: conf.TypeBool, Group: model.PREVIEW, Help: `generate the thumbnails of the images and videos for the storages without them`},
+		{Key: conf.ThumbnailConcurrency, Value: "4", Type: conf.TypeNumber, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `the number of the thumbnails generated at the same time`},
+		{Key: conf.ThumbnailCacheSize, Value: "1024", Type: conf.TypeNumber, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `MB of the disk cache for the thumbnails and the resized images, the least recently used ones are removed beyond it, 0 for no limit`},
+		{Key: conf.RenditionMaxDimension, Value: "4096", Type: conf.TypeNumber, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `the largest width or height of the resized images asked by w and h of the proxy links`},
+		{Key: conf.RenditionSizes, Value: "64,128,256,320,480,640,800,1024,1280,1600,1920,2560,3840", Type: conf.TypeString, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `the widths and heights the resized images can be asked in, separated by commas, empty for any`},
+		{Key: conf.RenditionQualities, Value: "50,75,85,95", Type: conf.TypeString, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `the qualities the resized images can be asked in, separated by commas, empty for any`},
+		{Key: conf.HLSEnabled, Value: "false", Type: conf.TypeBool, Group: model.PREVIEW, Help: `transcode the videos into HLS by ffmpeg for the browsers can't play them`},
+		{Key: conf.HLSMaxTranscodes, Value: "2", Type: conf.TypeNumber, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `the number of the videos transcoded at the same time`},
+		{Key: conf.HLSCacheMaxAge, Value: "24", Type: conf.TypeNumber, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `the hours the transcoded segments are kept`},
+		{Key: conf.MediaInfoEnabled, Value: "false", Type: conf.TypeBool, Group: model.PREVIEW, Help: `read the tags of audios, the exif of images and the streams of videos`},
 		{Key: conf.ReadMeAutoRender, Value: "true", Type: conf.TypeBool, Group: model.PREVIEW},
 		{Key: conf.FilterReadMeScripts, Value: "true", Type: conf.TypeBool, Group: model.PREVIEW},
 		// global settings
@@ -167,7 +177,11 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.AutoUpdateIndex, Value: "false", Type: conf.TypeBool, Group: model.INDEX},
 		{Key: conf.IgnorePaths, Value: "", Type: conf.TypeText, Group: model.INDEX, Flag: model.PRIVATE, Help: `one path per line`},
 		{Key: conf.MaxIndexDepth, Value: "20", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max depth of index`},
+		{Key: conf.IndexMediaInfo, Value: "false", Type: conf.TypeBool, Group: model.INDEX, Flag: model.PRIVATE, Help: `index the media info too, such as the artists of songs and the cameras of photos, which reads every media file`},
+		{Key: conf.IndexContent, Value: "false", Type: conf.TypeBool, Group: model.INDEX, Flag: model.PRIVATE, Help: `index the text of the text files, the office documents and the pdfs, which is searched by bleve and meilisearch only`},
+		{Key: conf.IndexContentMaxSize, Value: "10", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `the size in MB of the largest file whose content is indexed`},
 		{Key: conf.IndexProgress, Value: "{}", Type: conf.TypeText, Group: model.SINGLE, Flag: model.PRIVATE},
+		{Key: conf.DedupProgress, Value: "{}", Type: conf.TypeText, Group: model.SINGLE, Flag: model.PRIVATE},
 
 		// SSO settings
 		{Key: conf.SSOLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.SSO, Flag: model.PUBLIC},
@@ -222,6 +236,19 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.StreamMaxClientUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.BlockCacheSize, Value: "0", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `MB of the disk cache for the proxied downloads, 0 to disable`},
+		{Key: conf.DownProxyHealthCheckInterval, Value: "60", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `seconds between the health checks of the down proxies, 0 to disable`},
+
+		// security settings
+		{Key: conf.LoginMaxAttempts, Value: "5", Type: conf.TypeNumber, Group: model.SECURITY, Flag: model.PRIVATE,
+			Help: "failed attempts of a username before it's locked, 0 to disable"},
+		{Key: conf.LoginMaxAttemptsPerIP, Value: "20", Type: conf.TypeNumber, Group: model.SECURITY, Flag: model.PRIVATE,
+			Help: "failed attempts from an ip before it's locked, 0 to disable"},
+		{Key: conf.LoginBackoffSeconds, Value: "1", Type: conf.TypeNumber, Group: model.SECURITY, Flag: model.PRIVATE,
+			Help: "the wait after the second failure, doubled on every further failure"},
+		{Key: conf.LoginLockoutMinutes, Value: "15", Type: conf.TypeNumber, Group: model.SECURITY, Flag: model.PRIVATE},
+		{Key: conf.ClamdAddress, Value: "", Type: conf.TypeString, Group: model.SECURITY, Flag: model.PRIVATE,
+			Help: "tcp://host:port or unix:///path/to/clamd.ctl, used by the virus scan of metas"},
 	}
 	initialSettingItems = append(initialSettingItems, tool.Tools.Items()...)
 	if flags.Dev {
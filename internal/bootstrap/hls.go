@@ -0,0 +1,15 @@
+package bootstrap
+
+import (
+	"path/filepath"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/hls"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitHLS() {
+	if err := hls.Init(filepath.Join(conf.Conf.TempDir, "hls")); err != nil {
+		log.Errorf("failed init hls: %+v", err)
+	}
+}
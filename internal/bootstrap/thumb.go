@@ -0,0 +1,31 @@
+package bootstrap
+
+import (
+	"path/filepath"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/thumb"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitThumbnails() {
+	dir := conf.Conf.ThumbCacheDir
+	// the config files written before don't have it
+	if dir == "" {
+		dir = filepath.Join(flags.DataDir, "thumbnails")
+	}
+	if err := thumb.Init(dir, setting.GetInt(conf.ThumbnailConcurrency, 4)); err != nil {
+		log.Errorf("failed init thumbnails: %+v", err)
+		return
+	}
+	concurrency := setting.GetInt(conf.ThumbnailConcurrency, 4)
+	op.RegisterSettingChangingCallback(func() {
+		if n := setting.GetInt(conf.ThumbnailConcurrency, 4); n != concurrency {
+			concurrency = n
+			thumb.SetConcurrency(n)
+		}
+	})
+}
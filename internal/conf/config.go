@@ -104,6 +104,9 @@ type Config struct {
 	Scheme                Scheme      `json:"scheme"`
 	TempDir               string      `json:"temp_dir" env:"TEMP_DIR"`
 	BleveDir              string      `json:"bleve_dir" env:"BLEVE_DIR"`
+	PluginDir             string      `json:"plugin_dir" env:"PLUGIN_DIR"`
+	BlockCacheDir         string      `json:"block_cache_dir" env:"BLOCK_CACHE_DIR"`
+	ThumbCacheDir         string      `json:"thumb_cache_dir" env:"THUMB_CACHE_DIR"`
 	DistDir               string      `json:"dist_dir"`
 	Log                   LogConfig   `json:"log"`
 	DelayedStart          int         `json:"delayed_start" env:"DELAYED_START"`
@@ -121,6 +124,9 @@ type Config struct {
 func DefaultConfig() *Config {
 	tempDir := filepath.Join(flags.DataDir, "temp")
 	indexDir := filepath.Join(flags.DataDir, "bleve")
+	pluginDir := filepath.Join(flags.DataDir, "plugins")
+	blockCacheDir := filepath.Join(flags.DataDir, "block_cache")
+	thumbCacheDir := filepath.Join(flags.DataDir, "thumbnails")
 	logPath := filepath.Join(flags.DataDir, "log/log.log")
 	dbPath := filepath.Join(flags.DataDir, "data.db")
 	return &Config{
@@ -145,7 +151,10 @@ func DefaultConfig() *Config {
 		Meilisearch: Meilisearch{
 			Host: "http://localhost:7700",
 		},
-		BleveDir: indexDir,
+		BleveDir:      indexDir,
+		PluginDir:     pluginDir,
+		BlockCacheDir: blockCacheDir,
+		ThumbCacheDir: thumbCacheDir,
 		Log: LogConfig{
 			Enable:     true,
 			Name:       logPath,
@@ -31,6 +31,16 @@ const (
 	AudioAutoplay            = "audio_autoplay"
 	VideoAutoplay            = "video_autoplay"
 	PreviewArchivesByDefault = "preview_archives_by_default"
+	ThumbnailEnabled         = "thumbnail_enabled"
+	ThumbnailConcurrency     = "thumbnail_concurrency"
+	ThumbnailCacheSize       = "thumbnail_cache_size"
+	RenditionMaxDimension    = "rendition_max_dimension"
+	RenditionSizes           = "rendition_sizes"
+	RenditionQualities       = "rendition_qualities"
+	HLSEnabled               = "hls_enabled"
+	HLSMaxTranscodes         = "hls_max_transcodes"
+	HLSCacheMaxAge           = "hls_cache_max_age"
+	MediaInfoEnabled         = "media_info_enabled"
 	ReadMeAutoRender         = "readme_autorender"
 	FilterReadMeScripts      = "filter_readme_scripts"
 	// global
@@ -47,10 +57,13 @@ const (
 	WebauthnLoginEnabled    = "webauthn_login_enabled"
 
 	// index
-	SearchIndex     = "search_index"
-	AutoUpdateIndex = "auto_update_index"
-	IgnorePaths     = "ignore_paths"
-	MaxIndexDepth   = "max_index_depth"
+	SearchIndex         = "search_index"
+	AutoUpdateIndex     = "auto_update_index"
+	IgnorePaths         = "ignore_paths"
+	MaxIndexDepth       = "max_index_depth"
+	IndexMediaInfo      = "index_media_info"
+	IndexContent        = "index_content"
+	IndexContentMaxSize = "index_content_max_size"
 
 	// aria2
 	Aria2Uri    = "aria2_uri"
@@ -72,6 +85,7 @@ const (
 	// single
 	Token         = "token"
 	IndexProgress = "index_progress"
+	DedupProgress = "dedup_progress"
 
 	// SSO
 	SSOClientId          = "sso_client_id"
@@ -129,6 +143,15 @@ const (
 	StreamMaxClientUploadSpeed            = "max_client_upload_speed"
 	StreamMaxServerDownloadSpeed          = "max_server_download_speed"
 	StreamMaxServerUploadSpeed            = "max_server_upload_speed"
+	BlockCacheSize                        = "block_cache_size"
+	DownProxyHealthCheckInterval          = "down_proxy_health_check_interval"
+
+	// security
+	LoginMaxAttempts      = "login_max_attempts"
+	LoginMaxAttemptsPerIP = "login_max_attempts_per_ip"
+	LoginBackoffSeconds   = "login_backoff_seconds"
+	LoginLockoutMinutes   = "login_lockout_minutes"
+	ClamdAddress          = "clamd_address"
 )
 
 const (
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func ClearDuplicates() error {
+	return errors.WithStack(db.Where("1 = 1").Delete(&model.Duplicate{}).Error)
+}
+
+func CreateDuplicates(duplicates []model.Duplicate) error {
+	if len(duplicates) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.CreateInBatches(duplicates, 100).Error)
+}
+
+// GetDuplicateGroups returns the groups of duplicates, the ones wasting the most space first
+func GetDuplicateGroups(pageIndex, pageSize int) (groups []model.DuplicateGroup, count int64, err error) {
+	hash, size := columnName("hash"), columnName("size")
+	groupDB := db.Model(&model.Duplicate{}).Select(fmt.Sprintf("%s, %s, count(*) as %s", hash, size, columnName("count"))).
+		Group(fmt.Sprintf("%s, %s", hash, size)).Having("count(*) > 1")
+	if err = db.Table("(?) as g", groupDB).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get duplicate groups count")
+	}
+	if err = groupDB.Order(fmt.Sprintf("%s * (count(*) - 1) desc, %s", size, hash)).
+		Offset((pageIndex - 1) * pageSize).Limit(pageSize).Scan(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find duplicate groups")
+	}
+	return groups, count, nil
+}
+
+func GetDuplicatesByHash(hashes []string) (duplicates []model.Duplicate, err error) {
+	if err = db.Where(columnName("hash")+" in ?", hashes).Order(columnName("path")).Find(&duplicates).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find duplicates")
+	}
+	return duplicates, nil
+}
+
+func DeleteDuplicateByPath(path string) error {
+	return errors.WithStack(db.Where(columnName("path")+" = ?", path).Delete(&model.Duplicate{}).Error)
+}
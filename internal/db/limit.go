@@ -0,0 +1,48 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetLimitProfileById(id uint) (*model.LimitProfile, error) {
+	var p model.LimitProfile
+	if err := db.First(&p, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get limit profile")
+	}
+	return &p, nil
+}
+
+func GetLimitProfiles() (profiles []model.LimitProfile, err error) {
+	if err = db.Order(columnName("id")).Find(&profiles).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get limit profiles")
+	}
+	return profiles, nil
+}
+
+func CreateLimitProfile(p *model.LimitProfile) error {
+	return errors.WithStack(db.Create(p).Error)
+}
+
+func UpdateLimitProfile(p *model.LimitProfile) error {
+	return errors.WithStack(db.Save(p).Error)
+}
+
+// DeleteLimitProfileById deletes the profile and detaches it from the users
+func DeleteLimitProfileById(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("limit_profile_id = ?", id).Update("limit_profile_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.LimitProfile{}, id).Error
+	}))
+}
+
+func GetLimitProfileByName(name string) (*model.LimitProfile, error) {
+	p := model.LimitProfile{Name: name}
+	if err := db.Where(p).First(&p).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find limit profile")
+	}
+	return &p, nil
+}
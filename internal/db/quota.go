@@ -0,0 +1,49 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetUserUsage(userID uint) (*model.UserUsage, error) {
+	usage := model.UserUsage{UserID: userID}
+	err := db.Where(usage).First(&usage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &usage, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get user usage")
+	}
+	return &usage, nil
+}
+
+// AddUserUsage adds delta to the usage of the user atomically
+func AddUserUsage(userID uint, delta int64) error {
+	res := db.Model(&model.UserUsage{}).Where(columnName("user_id")+" = ?", userID).
+		Update("used", gorm.Expr(columnName("used")+" + ?", delta))
+	if res.Error != nil {
+		return errors.Wrapf(res.Error, "failed update user usage")
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+	if delta < 0 {
+		delta = 0
+	}
+	return errors.WithStack(db.Create(&model.UserUsage{UserID: userID, Used: delta}).Error)
+}
+
+func SetUserUsage(userID uint, used int64) error {
+	return errors.WithStack(db.Save(&model.UserUsage{
+		UserID:         userID,
+		Used:           used,
+		RecalculatedAt: time.Now(),
+	}).Error)
+}
+
+func DeleteUserUsage(userID uint) error {
+	return errors.WithStack(db.Delete(&model.UserUsage{}, userID).Error)
+}
@@ -59,7 +59,8 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 	if !useFullText || conf.Conf.Database.Type == "sqlite3" {
 		keywordsClause := db.Where("1 = 1")
 		for _, keyword := range strings.Fields(req.Keywords) {
-			keywordsClause = keywordsClause.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
+			keyword = fmt.Sprintf("%%%s%%", keyword)
+			keywordsClause = keywordsClause.Where(db.Where("name LIKE ?", keyword).Or("media LIKE ?", keyword))
 		}
 		searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).Where(keywordsClause)
 	} else {
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateSession(s *model.Session) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func GetSessionById(id string) (*model.Session, error) {
+	var s model.Session
+	if err := db.Where(columnName("id")+" = ?", id).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get session")
+	}
+	return &s, nil
+}
+
+func GetSessionsByUserId(userId uint) (sessions []model.Session, err error) {
+	err = db.Where(columnName("user_id")+" = ?", userId).Order(columnName("last_seen_at") + " desc").Find(&sessions).Error
+	return sessions, errors.Wrapf(err, "failed get user's sessions")
+}
+
+// UpdateSessionSeen only updates the last seen columns
+func UpdateSessionSeen(id string, seenAt time.Time, ip string) error {
+	return errors.WithStack(db.Model(&model.Session{ID: id}).Updates(map[string]any{
+		"last_seen_at": seenAt,
+		"ip":           ip,
+	}).Error)
+}
+
+func DeleteSessionById(id string) error {
+	return errors.WithStack(db.Where(columnName("id")+" = ?", id).Delete(&model.Session{}).Error)
+}
+
+func DeleteSessionsByUserId(userId uint) error {
+	return errors.WithStack(db.Where(columnName("user_id")+" = ?", userId).Delete(&model.Session{}).Error)
+}
+
+func DeleteExpiredSessions(userId uint) error {
+	return errors.WithStack(db.Where(columnName("user_id")+" = ? AND "+columnName("expires_at")+" < ?", userId, time.Now()).
+		Delete(&model.Session{}).Error)
+}
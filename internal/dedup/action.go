@@ -0,0 +1,147 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkGroup makes sure that keep and the paths are copies of each other and keep is not touched,
+// the files may have changed since the scan, so they are read again and compared by their sizes and hashes
+func checkGroup(ctx context.Context, hash, keep string, paths []string) error {
+	duplicates, err := db.GetDuplicatesByHash([]string{hash})
+	if err != nil {
+		return err
+	}
+	inGroup := make(map[string]struct{}, len(duplicates))
+	for _, d := range duplicates {
+		inGroup[d.Path] = struct{}{}
+	}
+	if _, ok := inGroup[keep]; !ok {
+		return errors.Errorf("%s is not in the group", keep)
+	}
+	for _, path := range paths {
+		if path == keep {
+			return errors.Errorf("%s is the copy kept", keep)
+		}
+		if _, ok := inGroup[path]; !ok {
+			return errors.Errorf("%s is not in the group", path)
+		}
+	}
+	size := duplicates[0].Size
+	for _, path := range append([]string{keep}, paths...) {
+		if err := checkFile(ctx, path, hash, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFile makes sure that the file still has the size and the hash of the group
+func checkFile(ctx context.Context, path, hash string, size int64) error {
+	name, want, _ := strings.Cut(hash, ":")
+	var ht *utils.HashType
+	for _, t := range hashTypes {
+		if t.Name == name {
+			ht = t
+		}
+	}
+	if ht == nil {
+		return errors.Errorf("unknown hash %s", hash)
+	}
+	// the cache of the listing may be older than the file
+	if _, err := fs.List(ctx, stdpath.Dir(path), &fs.ListArgs{Refresh: true, NoLog: true}); err != nil {
+		return err
+	}
+	obj, err := fs.Get(ctx, path, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return err
+	}
+	if obj.IsDir() || obj.GetSize() != size {
+		return errors.Errorf("%s has changed since the scan, scan it again", path)
+	}
+	h := obj.GetHash().GetHash(ht)
+	if h == "" {
+		if h, err = computeHash(ctx, path, obj, ht); err != nil {
+			return errors.WithMessagef(err, "failed hash %s", path)
+		}
+	}
+	if !strings.EqualFold(h, want) {
+		return errors.Errorf("%s has changed since the scan, scan it again", path)
+	}
+	return nil
+}
+
+// Delete removes the copies in paths of the group, keep is the copy left
+func Delete(ctx context.Context, hash, keep string, paths []string) error {
+	if err := checkGroup(ctx, hash, keep, paths); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := fs.Remove(ctx, path); err != nil {
+			return err
+		}
+		if err := db.DeleteDuplicateByPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceWithLink replaces the copies in paths with links to keep, which are served under apiURL.
+// Only the storages supporting PutURL can hold a link
+func ReplaceWithLink(ctx context.Context, hash, keep string, paths []string, apiURL string) error {
+	// the link is fetched by whoever reads the copies, a sign bound to the ip of one client can't serve them
+	if rule := common.GetAccessRule(keep); rule != nil && rule.BindIP > 0 {
+		return errs.NewErr(errs.NotSupport, "the signs of %s are bound to the ips of the clients, it can't be linked", keep)
+	}
+	if err := checkGroup(ctx, hash, keep, paths); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		storage, _, err := op.GetStorageAndActualPath(path)
+		if err != nil {
+			return errors.WithMessage(err, "failed get storage")
+		}
+		_, ok := storage.(driver.PutURL)
+		_, okResult := storage.(driver.PutURLResult)
+		if !ok && !okResult {
+			return errs.NewErr(errs.NotImplement, "%s can't hold a link", path)
+		}
+	}
+	url := fmt.Sprintf("%s/d%s?sign=%s", apiURL, utils.EncodePath(keep, true), sign.NotExpired(keep))
+	for _, path := range paths {
+		// the link is put aside first, so the copy is only removed once it can be replaced
+		dir, name := stdpath.Split(path)
+		tempName := name + ".alist_link"
+		tempPath := stdpath.Join(dir, tempName)
+		if err := fs.PutURL(ctx, dir, tempName, url); err != nil {
+			return errors.WithMessagef(err, "failed to put the link of %s", path)
+		}
+		if err := fs.Remove(ctx, path); err != nil {
+			if err := fs.Remove(ctx, tempPath); err != nil {
+				log.Errorf("failed remove the link %s: %+v", tempPath, err)
+			}
+			return err
+		}
+		if err := fs.Rename(ctx, tempPath, name); err != nil {
+			return errors.WithMessagef(err, "%s was removed but failed to rename the link %s", path, tempPath)
+		}
+		if err := db.DeleteDuplicateByPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
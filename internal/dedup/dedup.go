@@ -0,0 +1,179 @@
+package dedup
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	Quit = atomic.Pointer[chan struct{}]{}
+
+	errStopped = errors.New("stopped")
+)
+
+func Running() bool {
+	return Quit.Load() != nil
+}
+
+type file struct {
+	path string
+	obj  model.Obj
+}
+
+// Scan walks the paths, groups the files by size and then by hash,
+// and replaces the stored duplicates with the groups found
+func Scan(ctx context.Context, paths []string, minSize int64) error {
+	quit := make(chan struct{}, 1)
+	if !Quit.CompareAndSwap(nil, &quit) {
+		return errs.DedupIsRunning
+	}
+	defer Quit.Store(nil)
+	log.Infof("find duplicates in: %+v", paths)
+	progress := &model.DedupProgress{}
+	WriteProgress(progress)
+	err := scan(ctx, paths, max(minSize, 1), quit, progress)
+	now := time.Now()
+	progress.IsDone = true
+	progress.LastDoneTime = &now
+	if err != nil {
+		progress.Error = err.Error()
+	}
+	WriteProgress(progress)
+	return err
+}
+
+func scan(ctx context.Context, paths []string, minSize int64, quit chan struct{}, progress *model.DedupProgress) error {
+	bySize := make(map[int64][]file)
+	seen := make(map[string]struct{})
+	for _, path := range paths {
+		path = utils.FixAndCleanPath(path)
+		root, err := fs.Get(ctx, path, &fs.GetArgs{})
+		if err != nil {
+			return errors.WithMessagef(err, "failed get %s", path)
+		}
+		err = fs.WalkFS(ctx, -1, path, root, func(reqPath string, obj model.Obj) error {
+			select {
+			case <-quit:
+				return errStopped
+			default:
+			}
+			if obj.IsDir() {
+				// the versions are meant to be copies
+				if obj.GetName() == op.VersionsDirName {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			progress.ObjCount++
+			if progress.ObjCount%1000 == 0 {
+				WriteProgress(progress)
+			}
+			if _, ok := seen[reqPath]; ok || obj.GetSize() < minSize {
+				return nil
+			}
+			seen[reqPath] = struct{}{}
+			bySize[obj.GetSize()] = append(bySize[obj.GetSize()], file{path: reqPath, obj: obj})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	var duplicates []model.Duplicate
+	for size, files := range bySize {
+		if len(files) < 2 {
+			continue
+		}
+		progress.Candidates += uint64(len(files))
+		byHash := make(map[string][]file)
+		for i, key := range hashKeys(ctx, files) {
+			if key != "" {
+				byHash[key] = append(byHash[key], files[i])
+			}
+		}
+		for key, files := range byHash {
+			if len(files) < 2 {
+				continue
+			}
+			progress.GroupCount++
+			for _, f := range files {
+				duplicates = append(duplicates, model.Duplicate{
+					Hash:     key,
+					Size:     size,
+					Path:     f.path,
+					Modified: f.obj.ModTime(),
+				})
+			}
+		}
+		select {
+		case <-quit:
+			return errStopped
+		default:
+		}
+	}
+	if err := db.ClearDuplicates(); err != nil {
+		return err
+	}
+	return db.CreateDuplicates(duplicates)
+}
+
+func Progress() (*model.DedupProgress, error) {
+	p := setting.GetStr(conf.DedupProgress)
+	var progress model.DedupProgress
+	err := utils.Json.UnmarshalFromString(p, &progress)
+	return &progress, err
+}
+
+func WriteProgress(progress *model.DedupProgress) {
+	p, err := utils.Json.MarshalToString(progress)
+	if err != nil {
+		log.Errorf("marshal progress error: %+v", err)
+	}
+	err = op.SaveSettingItem(&model.SettingItem{
+		Key:   conf.DedupProgress,
+		Value: p,
+		Type:  conf.TypeText,
+		Group: model.SINGLE,
+		Flag:  model.PRIVATE,
+	})
+	if err != nil {
+		log.Errorf("save progress error: %+v", err)
+	}
+}
+
+// GetGroups returns a page of the duplicate report with the files of each group
+func GetGroups(pageIndex, pageSize int) ([]model.DuplicateGroup, int64, error) {
+	groups, total, err := db.GetDuplicateGroups(pageIndex, pageSize)
+	if err != nil || len(groups) == 0 {
+		return groups, total, err
+	}
+	hashes := make([]string, len(groups))
+	for i, g := range groups {
+		hashes[i] = g.Hash
+	}
+	duplicates, err := db.GetDuplicatesByHash(hashes)
+	if err != nil {
+		return nil, 0, err
+	}
+	files := make(map[string][]model.Duplicate)
+	for _, d := range duplicates {
+		files[d.Hash] = append(files[d.Hash], d)
+	}
+	for i := range groups {
+		groups[i].Files = files[groups[i].Hash]
+	}
+	return groups, total, nil
+}
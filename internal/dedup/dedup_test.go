@@ -0,0 +1,132 @@
+package dedup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/dedup"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.txt":     "same content",
+		"sub/b.txt": "same content",
+		"c.txt":     "diff content",
+		"d.txt":     "unique",
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"mkdir_perm":"777","recycle_bin_path":"delete permanently"}`, dir),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	if err = dedup.Scan(ctx, []string{"/local"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	groups, total, err := dedup.GetGroups(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// c.txt has the same size but another content
+	if total != 1 || len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Fatalf("expected a group of 2 files, got %d: %+v", total, groups)
+	}
+	g := groups[0]
+	if err = dedup.Delete(ctx, g.Hash, "/local/a.txt", []string{"/local/a.txt"}); err == nil {
+		t.Error("deleting the copy kept should fail")
+	}
+	// a copy changed since the scan is not removed
+	b := filepath.Join(dir, "sub/b.txt")
+	if err = os.WriteFile(b, []byte("SAME CONTENT"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err = dedup.Delete(ctx, g.Hash, "/local/a.txt", []string{"/local/sub/b.txt"}); err == nil {
+		t.Error("deleting a changed copy should fail")
+	}
+	if err = os.WriteFile(b, []byte("same content"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err = dedup.Delete(ctx, g.Hash, "/local/a.txt", []string{"/local/sub/b.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "sub/b.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected b.txt removed, got %v", err)
+	}
+	if _, total, _ = dedup.GetGroups(1, 10); total != 0 {
+		t.Errorf("expected no group left, got %d", total)
+	}
+}
+
+func TestReplaceWithLinkBindIP(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("same content"), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/bound",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"mkdir_perm":"777","recycle_bin_path":"delete permanently"}`, dir),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	if err = op.CreateMeta(&model.Meta{Path: "/bound", AccessRule: model.AccessRule{BindIP: 24}, ASub: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err = dedup.Scan(ctx, []string{"/bound"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	groups, _, err := dedup.GetGroups(1, 10)
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("expected a group, got %+v: %v", groups, err)
+	}
+	err = dedup.ReplaceWithLink(ctx, groups[0].Hash, "/bound/a.txt", []string{"/bound/b.txt"}, "http://localhost")
+	if !errors.Is(err, errs.NotSupport) {
+		t.Errorf("expected the link refused, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "b.txt")); err != nil {
+		t.Errorf("expected b.txt kept, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package dedup
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// hashTypes are the hashes comparable across storages, in the order of preference
+var hashTypes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+func hashKey(ht *utils.HashType, h string) string {
+	return ht.Name + ":" + strings.ToLower(h)
+}
+
+// hashKeys returns the keys of the files of the same size, the key is empty if it can't be known.
+// The hash reported by all the storages is used, otherwise md5 is computed for the files without it
+func hashKeys(ctx context.Context, files []file) []string {
+	keys := make([]string, len(files))
+	for _, ht := range hashTypes {
+		all := true
+		for i, f := range files {
+			h := f.obj.GetHash().GetHash(ht)
+			if h == "" {
+				all = false
+				break
+			}
+			keys[i] = hashKey(ht, h)
+		}
+		if all {
+			return keys
+		}
+	}
+	for i, f := range files {
+		if h := f.obj.GetHash().GetHash(utils.MD5); h != "" {
+			keys[i] = hashKey(utils.MD5, h)
+			continue
+		}
+		h, err := computeHash(ctx, f.path, f.obj, utils.MD5)
+		if err != nil {
+			log.Warnf("failed hash %s: %+v", f.path, err)
+			keys[i] = ""
+			continue
+		}
+		keys[i] = hashKey(utils.MD5, h)
+	}
+	return keys
+}
+
+// computeHash reads the whole content through a ranged reader of the link
+func computeHash(ctx context.Context, path string, obj model.Obj, ht *utils.HashType) (string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return "", err
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get stream")
+	}
+	defer ss.Close()
+	r, err := ss.RangeRead(http_range.Range{Start: 0, Length: -1})
+	if err != nil {
+		return "", err
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	h := ht.NewFunc()
+	if _, err = io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
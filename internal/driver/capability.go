@@ -0,0 +1,81 @@
+package driver
+
+// Capabilities reports which optional interfaces a driver implements,
+// so that clients don't need to guess by trying the operations
+type Capabilities struct {
+	GetRooter               bool `json:"get_rooter"`
+	Getter                  bool `json:"getter"`
+	Other                   bool `json:"other"`
+	Mkdir                   bool `json:"mkdir"`
+	MkdirResult             bool `json:"mkdir_result"`
+	Move                    bool `json:"move"`
+	MoveResult              bool `json:"move_result"`
+	Rename                  bool `json:"rename"`
+	RenameResult            bool `json:"rename_result"`
+	Copy                    bool `json:"copy"`
+	CopyResult              bool `json:"copy_result"`
+	Remove                  bool `json:"remove"`
+	Put                     bool `json:"put"`
+	PutResult               bool `json:"put_result"`
+	PutURL                  bool `json:"put_url"`
+	PutURLResult            bool `json:"put_url_result"`
+	ArchiveReader           bool `json:"archive_reader"`
+	ArchiveGetter           bool `json:"archive_getter"`
+	ArchiveDecompress       bool `json:"archive_decompress"`
+	ArchiveDecompressResult bool `json:"archive_decompress_result"`
+	Reference               bool `json:"reference"`
+	WithDetails             bool `json:"with_details"`
+	SetModTime              bool `json:"set_mod_time"`
+}
+
+func GetCapabilities(d Driver) Capabilities {
+	var c Capabilities
+	_, c.GetRooter = d.(GetRooter)
+	_, c.Getter = d.(Getter)
+	_, c.Other = d.(Other)
+	_, c.Mkdir = d.(Mkdir)
+	_, c.MkdirResult = d.(MkdirResult)
+	_, c.Move = d.(Move)
+	_, c.MoveResult = d.(MoveResult)
+	_, c.Rename = d.(Rename)
+	_, c.RenameResult = d.(RenameResult)
+	_, c.Copy = d.(Copy)
+	_, c.CopyResult = d.(CopyResult)
+	_, c.Remove = d.(Remove)
+	_, c.Put = d.(Put)
+	_, c.PutResult = d.(PutResult)
+	_, c.PutURL = d.(PutURL)
+	_, c.PutURLResult = d.(PutURLResult)
+	_, c.ArchiveReader = d.(ArchiveReader)
+	_, c.ArchiveGetter = d.(ArchiveGetter)
+	_, c.ArchiveDecompress = d.(ArchiveDecompress)
+	_, c.ArchiveDecompressResult = d.(ArchiveDecompressResult)
+	_, c.Reference = d.(Reference)
+	_, c.WithDetails = d.(WithDetails)
+	_, c.SetModTime = d.(SetModTime)
+	return c
+}
+
+func (c Capabilities) CanMkdir() bool {
+	return c.Mkdir || c.MkdirResult
+}
+
+func (c Capabilities) CanMove() bool {
+	return c.Move || c.MoveResult
+}
+
+func (c Capabilities) CanRename() bool {
+	return c.Rename || c.RenameResult
+}
+
+func (c Capabilities) CanCopy() bool {
+	return c.Copy || c.CopyResult
+}
+
+func (c Capabilities) CanPut() bool {
+	return c.Put || c.PutResult
+}
+
+func (c Capabilities) CanPutURL() bool {
+	return c.PutURL || c.PutURLResult
+}
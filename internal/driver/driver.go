@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
 )
@@ -205,6 +206,16 @@ type ArchiveDecompressResult interface {
 	ArchiveDecompress(ctx context.Context, srcObj, dstDir model.Obj, args model.ArchiveDecompressArgs) ([]model.Obj, error)
 }
 
+type WithDetails interface {
+	// GetDetails get the space of the storage, used by union placement policies and webdav quota
+	GetDetails(ctx context.Context) (*model.StorageDetails, error)
+}
+
+type SetModTime interface {
+	// SetModTime changes the modification time of the obj, used by clients like rsync to keep times
+	SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error
+}
+
 type Reference interface {
 	InitReference(storage Driver) error
 }
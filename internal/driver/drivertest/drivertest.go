@@ -0,0 +1,378 @@
+// Package drivertest is a conformance suite for drivers.
+//
+// A driver test only needs to build an initialized driver whose root is an
+// empty folder it may write to, then call Run:
+//
+//	func TestConformance(t *testing.T) {
+//		drivertest.Run(t, drivertest.Options{
+//			New: func(t *testing.T) driver.Driver {
+//				d := &Local{Addition: Addition{RootPath: driver.RootPath{RootFolderPath: t.TempDir()}}}
+//				...
+//				return d
+//			},
+//		})
+//	}
+//
+// Checks for optional interfaces the driver does not implement are skipped,
+// so the same suite works for read-only and writable drivers.
+package drivertest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	stdpath "path"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type Options struct {
+	// New returns an initialized driver whose root is an empty folder
+	New func(t *testing.T) driver.Driver
+	// Settle is waited after every write, for backends with eventual consistency
+	Settle time.Duration
+	// NoOverwrite should be set if the driver can't replace an existing file
+	NoOverwrite bool
+}
+
+// Run runs the conformance suite, every check gets a fresh driver
+func Run(t *testing.T, opts Options) {
+	initDB(t)
+	checks := []struct {
+		name string
+		fn   func(t *testing.T, s *suite)
+	}{
+		{"List", testList},
+		{"Put", testPut},
+		{"LinkRange", testLinkRange},
+		{"Overwrite", testOverwrite},
+		{"Rename", testRename},
+		{"Move", testMove},
+		{"Remove", testRemove},
+		{"Cancel", testCancel},
+	}
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			d := opts.New(t)
+			storage := d.GetStorage()
+			// op caches objs by mount path, keep them apart between checks
+			storage.MountPath = "/drivertest/" + t.Name()
+			storage.Status = op.WORK
+			d.SetStorage(*storage)
+			t.Cleanup(func() {
+				op.ClearCache(d, "/")
+				_ = d.Drop(context.Background())
+			})
+			c.fn(t, &suite{Options: opts, d: d, caps: driver.GetCapabilities(d)})
+		})
+	}
+}
+
+// initDB opens an in-memory db if the test hasn't, op looks up metas while writing
+func initDB(t *testing.T) {
+	if db.GetDb() != nil {
+		return
+	}
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %+v", err)
+	}
+	if conf.Conf == nil {
+		conf.Conf = conf.DefaultConfig()
+	}
+	db.Init(dB)
+}
+
+type suite struct {
+	Options
+	d    driver.Driver
+	caps driver.Capabilities
+}
+
+func (s *suite) settle() {
+	if s.Settle > 0 {
+		time.Sleep(s.Settle)
+	}
+}
+
+func (s *suite) requirePut(t *testing.T) {
+	if !s.caps.CanPut() || s.d.Config().NoUpload {
+		t.Skip("driver doesn't support put")
+	}
+}
+
+func (s *suite) put(t *testing.T, ctx context.Context, dir, name string, content []byte) error {
+	t.Helper()
+	file := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     int64(len(content)),
+			Modified: time.Now(),
+		},
+		Reader:   bytes.NewReader(content),
+		Mimetype: utils.GetMimeType(name),
+	}
+	err := op.Put(ctx, s.d, dir, file, nil)
+	s.settle()
+	return err
+}
+
+func (s *suite) mustPut(t *testing.T, dir, name string, content []byte) {
+	t.Helper()
+	if err := s.put(t, context.Background(), dir, name, content); err != nil {
+		t.Fatalf("failed put %s: %+v", stdpath.Join(dir, name), err)
+	}
+}
+
+func (s *suite) list(t *testing.T, dir string) map[string]model.Obj {
+	t.Helper()
+	objs, err := op.List(context.Background(), s.d, dir, model.ListArgs{Refresh: true})
+	if err != nil {
+		t.Fatalf("failed list %s: %+v", dir, err)
+	}
+	res := make(map[string]model.Obj, len(objs))
+	for _, obj := range objs {
+		res[obj.GetName()] = obj
+	}
+	return res
+}
+
+func (s *suite) mustExist(t *testing.T, path string, size int64) {
+	t.Helper()
+	dir, name := stdpath.Split(path)
+	obj, ok := s.list(t, dir)[name]
+	if !ok {
+		t.Fatalf("expect %s to exist", path)
+	}
+	if size >= 0 && obj.GetSize() != size {
+		t.Fatalf("expect size of %s to be %d, got %d", path, size, obj.GetSize())
+	}
+}
+
+func (s *suite) mustNotExist(t *testing.T, path string) {
+	t.Helper()
+	dir, name := stdpath.Split(path)
+	if _, ok := s.list(t, dir)[name]; ok {
+		t.Fatalf("expect %s not to exist", path)
+	}
+}
+
+// read reads [start, start+length) of the file, length -1 means to the end
+func (s *suite) read(t *testing.T, path string, start, length int64) []byte {
+	t.Helper()
+	ctx := context.Background()
+	link, obj, err := op.Link(ctx, s.d, path, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		t.Fatalf("failed link %s: %+v", path, err)
+	}
+	if length < 0 {
+		length = obj.GetSize() - start
+	}
+	var rc io.ReadCloser
+	switch {
+	case link.MFile != nil:
+		rc = struct {
+			io.Reader
+			io.Closer
+		}{io.NewSectionReader(link.MFile, start, length), link.MFile}
+	case link.RangeReadCloser != nil:
+		rc, err = link.RangeReadCloser.RangeRead(ctx, http_range.Range{Start: start, Length: length})
+	case link.URL != "":
+		var rrc model.RangeReadCloserIF
+		rrc, err = stream.GetRangeReadCloserFromLink(obj.GetSize(), link)
+		if err == nil {
+			rc, err = rrc.RangeRead(ctx, http_range.Range{Start: start, Length: length})
+		}
+	default:
+		t.Fatalf("link of %s is empty", path)
+	}
+	if err != nil {
+		t.Fatalf("failed read %s: %+v", path, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, length))
+	if err != nil {
+		t.Fatalf("failed read %s: %+v", path, err)
+	}
+	return data
+}
+
+func testList(t *testing.T, s *suite) {
+	if objs := s.list(t, "/"); len(objs) != 0 {
+		t.Fatalf("expect root to be empty, got %d objs", len(objs))
+	}
+	if !s.caps.CanMkdir() {
+		return
+	}
+	if err := op.MakeDir(context.Background(), s.d, "/a/b"); err != nil {
+		t.Fatalf("failed mkdir: %+v", err)
+	}
+	s.settle()
+	obj, ok := s.list(t, "/a")["b"]
+	if !ok || !obj.IsDir() {
+		t.Fatalf("expect /a/b to be a folder")
+	}
+	if _, err := op.Get(context.Background(), s.d, "/a/not_exist"); !errs.IsObjectNotFound(err) {
+		t.Fatalf("expect object not found, got %v", err)
+	}
+}
+
+func testPut(t *testing.T, s *suite) {
+	s.requirePut(t)
+	content := []byte("hello, alist")
+	s.mustPut(t, "/", "hello.txt", content)
+	s.mustExist(t, "/hello.txt", int64(len(content)))
+	if got := s.read(t, "/hello.txt", 0, -1); !bytes.Equal(got, content) {
+		t.Fatalf("expect content %q, got %q", content, got)
+	}
+	// put into a folder that doesn't exist yet
+	s.mustPut(t, "/sub/dir", "nested.txt", content)
+	s.mustExist(t, "/sub/dir/nested.txt", int64(len(content)))
+}
+
+func testLinkRange(t *testing.T, s *suite) {
+	s.requirePut(t)
+	content := make([]byte, 64*1024+17)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	s.mustPut(t, "/", "range.bin", content)
+	ranges := []struct{ start, length int64 }{
+		{0, 1},
+		{0, int64(len(content))},
+		{1, 10},
+		{4096, 4096},
+		{int64(len(content)) - 17, 17},
+		{int64(len(content)) - 100, -1},
+	}
+	for _, r := range ranges {
+		want := content[r.start:]
+		if r.length >= 0 {
+			want = content[r.start : r.start+r.length]
+		}
+		if got := s.read(t, "/range.bin", r.start, r.length); !bytes.Equal(got, want) {
+			t.Fatalf("range %d-%d mismatch, got %d bytes", r.start, r.length, len(got))
+		}
+	}
+}
+
+func testOverwrite(t *testing.T, s *suite) {
+	s.requirePut(t)
+	if s.NoOverwrite {
+		t.Skip("driver doesn't support overwrite")
+	}
+	s.mustPut(t, "/", "file.txt", []byte("first version"))
+	second := []byte("second")
+	s.mustPut(t, "/", "file.txt", second)
+	objs := s.list(t, "/")
+	if len(objs) != 1 {
+		t.Fatalf("expect only one obj after overwrite, got %d", len(objs))
+	}
+	s.mustExist(t, "/file.txt", int64(len(second)))
+	if got := s.read(t, "/file.txt", 0, -1); !bytes.Equal(got, second) {
+		t.Fatalf("expect content %q, got %q", second, got)
+	}
+}
+
+func testRename(t *testing.T, s *suite) {
+	s.requirePut(t)
+	if !s.caps.CanRename() {
+		t.Skip("driver doesn't support rename")
+	}
+	content := []byte("rename me")
+	s.mustPut(t, "/", "old.txt", content)
+	if err := op.Rename(context.Background(), s.d, "/old.txt", "new.txt"); err != nil {
+		t.Fatalf("failed rename: %+v", err)
+	}
+	s.settle()
+	s.mustNotExist(t, "/old.txt")
+	s.mustExist(t, "/new.txt", int64(len(content)))
+	if s.caps.CanMkdir() {
+		if err := op.MakeDir(context.Background(), s.d, "/folder"); err != nil {
+			t.Fatalf("failed mkdir: %+v", err)
+		}
+		if err := op.Rename(context.Background(), s.d, "/folder", "renamed"); err != nil {
+			t.Fatalf("failed rename folder: %+v", err)
+		}
+		s.settle()
+		s.mustNotExist(t, "/folder")
+		s.mustExist(t, "/renamed", -1)
+	}
+}
+
+func testMove(t *testing.T, s *suite) {
+	s.requirePut(t)
+	if !s.caps.CanMove() || !s.caps.CanMkdir() {
+		t.Skip("driver doesn't support move")
+	}
+	content := []byte("move me")
+	s.mustPut(t, "/src", "file.txt", content)
+	if err := op.MakeDir(context.Background(), s.d, "/dst"); err != nil {
+		t.Fatalf("failed mkdir: %+v", err)
+	}
+	if err := op.Move(context.Background(), s.d, "/src/file.txt", "/dst"); err != nil {
+		t.Fatalf("failed move: %+v", err)
+	}
+	s.settle()
+	s.mustNotExist(t, "/src/file.txt")
+	s.mustExist(t, "/dst/file.txt", int64(len(content)))
+	if got := s.read(t, "/dst/file.txt", 0, -1); !bytes.Equal(got, content) {
+		t.Fatalf("expect content %q, got %q", content, got)
+	}
+	// move a folder with its children
+	if err := op.Move(context.Background(), s.d, "/dst", "/src"); err != nil {
+		t.Fatalf("failed move folder: %+v", err)
+	}
+	s.settle()
+	s.mustNotExist(t, "/dst")
+	s.mustExist(t, "/src/dst/file.txt", int64(len(content)))
+}
+
+func testRemove(t *testing.T, s *suite) {
+	s.requirePut(t)
+	if !s.caps.Remove {
+		t.Skip("driver doesn't support remove")
+	}
+	s.mustPut(t, "/dir", "file.txt", []byte("remove me"))
+	if err := op.Remove(context.Background(), s.d, "/dir/file.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	s.settle()
+	s.mustNotExist(t, "/dir/file.txt")
+	if err := op.Remove(context.Background(), s.d, "/dir"); err != nil {
+		t.Fatalf("failed remove folder: %+v", err)
+	}
+	s.settle()
+	s.mustNotExist(t, "/dir")
+	// removing an obj that doesn't exist is not an error
+	if err := op.Remove(context.Background(), s.d, "/dir"); err != nil {
+		t.Fatalf("expect removing a missing obj to succeed, got %+v", err)
+	}
+}
+
+func testCancel(t *testing.T, s *suite) {
+	s.requirePut(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	content := bytes.Repeat([]byte{'x'}, 1024*1024)
+	if err := s.put(t, ctx, "/", "canceled.bin", content); err == nil {
+		t.Fatalf("expect put with a canceled context to fail")
+	}
+	// a canceled upload must not leave a complete file behind
+	if obj, ok := s.list(t, "/")["canceled.bin"]; ok && obj.GetSize() == int64(len(content)) {
+		t.Fatalf("expect canceled put not to store the whole file")
+	}
+}
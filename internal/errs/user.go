@@ -7,4 +7,11 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	QuotaExceeded      = errors.New("storage quota exceeded")
+	InvalidAPIToken    = errors.New("api token is invalid or revoked")
+	ExpiredAPIToken    = errors.New("api token has expired")
+
+	TooManyLoginAttempts = errors.New("too many unsuccessful sign-in attempts")
+	UploadRejected       = errors.New("upload is rejected by the policy")
+	TooManyTransfers     = errors.New("too many concurrent transfers")
 )
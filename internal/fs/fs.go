@@ -4,6 +4,7 @@ import (
 	"context"
 	log "github.com/sirupsen/logrus"
 	"io"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
@@ -97,6 +98,14 @@ func Remove(ctx context.Context, path string) error {
 	return err
 }
 
+func SetModTime(ctx context.Context, path string, modTime time.Time) error {
+	err := setModTime(ctx, path, modTime)
+	if err != nil && !errs.IsNotImplement(err) {
+		log.Errorf("failed set mod time of %s: %+v", path, err)
+	}
+	return err
+}
+
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, lazyCache ...bool) error {
 	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
 	if err != nil {
@@ -41,6 +41,11 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 		om.InitHideReg(meta.Hide)
 	}
 	objs := om.Merge(_objs, virtualFiles...)
+	if user != nil {
+		// versions are reached through the versions api only,
+		// the internal walks without user still count them
+		objs = hideVersions(objs)
+	}
 	return objs, nil
 }
 
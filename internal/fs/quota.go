@@ -0,0 +1,35 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// RecalculateUsage walks the base path of the user and
+// replaces the incrementally tracked usage with the real total size
+func RecalculateUsage(ctx context.Context, user *model.User) (int64, error) {
+	// walk without user, so that hidden files are counted too
+	root, err := Get(ctx, user.BasePath, &GetArgs{})
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed get base path of user [%s]", user.Username)
+	}
+	var used int64
+	err = WalkFS(ctx, -1, user.BasePath, root, func(reqPath string, info model.Obj) error {
+		if !info.IsDir() {
+			used += info.GetSize()
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err = op.SetUserUsage(user.ID, used); err != nil {
+		return 0, err
+	}
+	log.Infof("recalculated usage of user [%s]: %d bytes", user.Username, used)
+	return used, nil
+}
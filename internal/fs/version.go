@@ -0,0 +1,57 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func hideVersions(objs []model.Obj) []model.Obj {
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if obj.GetName() != op.VersionsDirName {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
+
+// ListVersions lists the old versions of the file, the latest first
+func ListVersions(ctx context.Context, path string) ([]model.Obj, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	versions, err := op.ListVersions(ctx, storage, actualPath)
+	if err != nil {
+		log.Errorf("failed list versions of %s: %+v", path, err)
+	}
+	return versions, err
+}
+
+// VersionPath returns the path of a version of the file, which can be linked like other files
+func VersionPath(path, id string) string {
+	dir, name := stdpath.Split(path)
+	return stdpath.Join(dir, op.VersionsDirName, name, id)
+}
+
+// RestoreVersion makes the version the current content of the file
+func RestoreVersion(ctx context.Context, path, id string) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if storage.Config().NoUpload {
+		return errors.WithStack(errs.UploadNotSupported)
+	}
+	err = op.RestoreVersion(ctx, storage, actualPath, id)
+	if err != nil {
+		log.Errorf("failed restore version %s of %s: %+v", id, path, err)
+	}
+	return err
+}
@@ -0,0 +1,274 @@
+// Package hls transcodes the videos the browsers can't play into HLS renditions on demand with ffmpeg
+package hls
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+const (
+	MasterPlaylist = "master.m3u8"
+	IndexPlaylist  = "index.m3u8"
+	// SegmentDuration is the target duration of the segments in seconds
+	SegmentDuration = 6
+)
+
+// Rendition is a variant stream of the master playlist
+type Rendition struct {
+	Name    string
+	Height  int
+	Bitrate int // kbps of the video
+}
+
+var Renditions = []Rendition{
+	{Name: "1080p", Height: 1080, Bitrate: 5000},
+	{Name: "720p", Height: 720, Bitrate: 2800},
+	{Name: "480p", Height: 480, Bitrate: 1400},
+	{Name: "360p", Height: 360, Bitrate: 800},
+}
+
+const audioBitrate = 128
+
+func GetRendition(name string) (Rendition, bool) {
+	for _, r := range Renditions {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Rendition{}, false
+}
+
+var segmentRegexp = regexp.MustCompile(`^seg_\d{5}\.ts$`)
+
+// SplitPath splits the path of a request into the path of the video and the file asked,
+// which is master.m3u8, <rendition>/index.m3u8 or <rendition>/seg_<n>.ts
+func SplitPath(path string) (string, string, bool) {
+	if video, ok := strings.CutSuffix(path, "/"+MasterPlaylist); ok && video != "" {
+		return video, MasterPlaylist, true
+	}
+	i := strings.LastIndex(path, "/")
+	j := strings.LastIndex(path[:max(i, 0)], "/")
+	if j <= 0 {
+		return "", "", false
+	}
+	rendition, name := path[j+1:i], path[i+1:]
+	if _, ok := GetRendition(rendition); !ok || name != IndexPlaylist && !segmentRegexp.MatchString(name) {
+		return "", "", false
+	}
+	return path[:j], rendition + "/" + name, true
+}
+
+func Enabled() bool {
+	return setting.GetBool(conf.HLSEnabled)
+}
+
+// Source is what the renditions are made from
+type Source struct {
+	Width    int
+	Height   int
+	Duration float64
+}
+
+var sources = cache.NewMemCache(cache.WithShards[*Source](16))
+
+func key(path string, obj model.Obj) string {
+	h := sha1.Sum([]byte(path + "\n" + strconv.FormatInt(obj.GetSize(), 10) + "\n" + strconv.FormatInt(obj.ModTime().UnixNano(), 10)))
+	return hex.EncodeToString(h[:])
+}
+
+// input is what ffmpeg reads the video from, ffmpeg reads and seeks the link itself if it can,
+// otherwise the video is piped to it from the start like the video thumbnails
+type input struct {
+	name string
+	args ffmpeg.KwArgs
+	ss   *stream.SeekableStream
+	r    io.Reader
+}
+
+// open returns the input of ffmpeg for the video, it must be closed
+func open(ctx context.Context, path string, obj model.Obj) (*input, error) {
+	l, _, err := fs.Link(ctx, path, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return nil, err
+	}
+	if stream.FFmpegReads(l) {
+		name, args, err := stream.FFmpegInput(l)
+		if err != nil {
+			return nil, err
+		}
+		return &input{name: name, args: args}, nil
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, l)
+	if err != nil {
+		return nil, err
+	}
+	return &input{name: "pipe:", args: ffmpeg.KwArgs{}, ss: ss}, nil
+}
+
+// reader returns the video read from the start to be piped to ffmpeg,
+// nil if ffmpeg reads the link itself
+func (in *input) reader() (io.Reader, error) {
+	if in.ss == nil {
+		return nil, nil
+	}
+	r, err := in.ss.RangeRead(http_range.Range{Start: 0, Length: -1})
+	if err != nil {
+		return nil, err
+	}
+	in.r = r
+	return r, nil
+}
+
+func (in *input) Close() {
+	if c, ok := in.r.(io.Closer); ok {
+		_ = c.Close()
+	}
+	if in.ss != nil {
+		_ = in.ss.Close()
+	}
+}
+
+// Probe returns the size and the duration of the video
+func Probe(ctx context.Context, path string, obj model.Obj) (*Source, error) {
+	k := key(path, obj)
+	if src, ok := sources.Get(k); ok {
+		return src, nil
+	}
+	in, err := open(ctx, path, obj)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	stdin, err := in.reader()
+	if err != nil {
+		return nil, err
+	}
+	var out string
+	if stdin != nil {
+		out, err = ffmpeg.ProbeReaderWithTimeout(stdin, time.Minute, in.args)
+	} else {
+		out, err = ffmpeg.ProbeWithTimeout(in.name, time.Minute, in.args)
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to probe the video")
+	}
+	src, err := parseProbe(out)
+	if err != nil {
+		return nil, err
+	}
+	sources.Set(k, src, cache.WithEx[*Source](time.Hour))
+	return src, nil
+}
+
+func parseProbe(out string) (*Source, error) {
+	var probe struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal([]byte(out), &probe); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the probe")
+	}
+	src := &Source{}
+	src.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	for _, s := range probe.Streams {
+		if s.CodecType == "video" && s.Height > 0 {
+			src.Width, src.Height = s.Width, s.Height
+			return src, nil
+		}
+	}
+	return nil, errors.New("no video stream")
+}
+
+// renditions returns the renditions not larger than the source, the smallest is always kept
+func (s *Source) renditions() []Rendition {
+	var rs []Rendition
+	for _, r := range Renditions {
+		if r.Height <= s.Height {
+			rs = append(rs, r)
+		}
+	}
+	if len(rs) == 0 {
+		rs = Renditions[len(Renditions)-1:]
+	}
+	return rs
+}
+
+// Master returns the master playlist of the source, query is appended to the uri of the variants
+func (s *Source) Master(query string) string {
+	if query != "" {
+		query = "?" + query
+	}
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, r := range s.renditions() {
+		width := s.Width * r.Height / s.Height
+		width += width % 2
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,NAME=\"%s\"\n%s/%s%s\n",
+			(r.Bitrate+audioBitrate)*1000, width, r.Height, r.Name, r.Name, IndexPlaylist, query)
+	}
+	return b.String()
+}
+
+// Playlist returns the playlist of a rendition of the source, all the segments are listed up front,
+// so that the players can seek to any of them. It's empty if the duration is unknown
+func (s *Source) Playlist() string {
+	if s.Duration <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n", SegmentDuration)
+	for i := 0; float64(i*SegmentDuration) < s.Duration; i++ {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n%s\n", min(s.Duration-float64(i*SegmentDuration), SegmentDuration), segmentName(i))
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String()
+}
+
+func segmentName(i int) string {
+	return fmt.Sprintf("seg_%05d.ts", i)
+}
+
+// segmentIndex returns the index of the segment named seg_<n>.ts
+func segmentIndex(name string) int {
+	i, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "seg_"), ".ts"))
+	return i
+}
+
+// SignPlaylist appends query to the uri of the segments in the playlist
+func SignPlaylist(playlist []byte, query string) []byte {
+	if query == "" {
+		return playlist
+	}
+	lines := strings.Split(string(playlist), "\n")
+	for i, line := range lines {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines[i] = line + "?" + query
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,145 @@
+package hls
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSplitPath(t *testing.T) {
+	testCases := []struct {
+		path, video, file string
+		ok                bool
+	}{
+		{"/a/b.mkv/master.m3u8", "/a/b.mkv", MasterPlaylist, true},
+		{"/a/b.mkv/720p/index.m3u8", "/a/b.mkv", "720p/index.m3u8", true},
+		{"/a/b.mkv/360p/seg_00012.ts", "/a/b.mkv", "360p/seg_00012.ts", true},
+		{"/a/b.mkv/999p/index.m3u8", "", "", false},
+		{"/a/b.mkv/720p/../x.ts", "", "", false},
+		{"/master.m3u8", "", "", false},
+	}
+	for _, tc := range testCases {
+		video, file, ok := SplitPath(tc.path)
+		if video != tc.video || file != tc.file || ok != tc.ok {
+			t.Errorf("%s: got %q %q %v", tc.path, video, file, ok)
+		}
+	}
+}
+
+func TestMaster(t *testing.T) {
+	src, err := parseProbe(`{"streams":[{"codec_type":"audio"},{"codec_type":"video","width":1280,"height":720}],"format":{"duration":"61.5"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Duration != 61.5 {
+		t.Errorf("unexpected duration %v", src.Duration)
+	}
+	master := src.Master("sign=x")
+	if strings.Contains(master, "1080p") || !strings.Contains(master, "RESOLUTION=854x480") ||
+		!strings.Contains(master, "\n720p/index.m3u8?sign=x\n") {
+		t.Errorf("unexpected master playlist:\n%s", master)
+	}
+	playlist := string(SignPlaylist([]byte("#EXTM3U\n#EXTINF:6.0,\nseg_00000.ts\n"), "sign=x"))
+	if playlist != "#EXTM3U\n#EXTINF:6.0,\nseg_00000.ts?sign=x\n" {
+		t.Errorf("unexpected playlist:\n%s", playlist)
+	}
+}
+
+func TestPlaylist(t *testing.T) {
+	src := &Source{Height: 720, Duration: 13}
+	playlist := src.Playlist()
+	if !strings.Contains(playlist, "#EXT-X-PLAYLIST-TYPE:VOD\n") || !strings.HasSuffix(playlist, "#EXTINF:1.000,\nseg_00002.ts\n#EXT-X-ENDLIST\n") ||
+		strings.Count(playlist, "#EXTINF:6.000,\n") != 2 {
+		t.Errorf("unexpected playlist:\n%s", playlist)
+	}
+	if (&Source{Height: 720}).Playlist() != "" {
+		t.Error("a playlist is made without the duration")
+	}
+}
+
+func TestSeek(t *testing.T) {
+	dir := t.TempDir()
+	write := func(start int, segs ...int) {
+		playlist := "#EXTM3U\n#EXT-X-PLAYLIST-TYPE:EVENT\n"
+		for _, seg := range segs {
+			playlist += "#EXTINF:6.000000,\n" + segmentName(seg) + "\n"
+		}
+		if err := os.WriteFile(filepath.Join(dir, jobPlaylist(start)), []byte(playlist), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a killed job from the start and a running one from the segment 100
+	write(0, 0, 1, 2)
+	write(100, 100, 101)
+	j := &job{start: 100, done: make(chan struct{})}
+	for seg, want := range map[int]bool{2: true, 3: false, 101: true, 102: false} {
+		if got := ready(dir, segmentName(seg)); got != want {
+			t.Errorf("ready(%d) = %v, want %v", seg, got, want)
+		}
+	}
+	for seg, want := range map[int]bool{3: false, 102: true, 101 + seekAhead: true, 102 + seekAhead: false} {
+		if got := j.makes(dir, seg); got != want {
+			t.Errorf("makes(%d) = %v, want %v", seg, got, want)
+		}
+	}
+}
+
+func TestJobs(t *testing.T) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+	dir := t.TempDir()
+	run := func(start int) *job {
+		cmd := exec.Command("sleep", "60")
+		if err := cmd.Start(); err != nil {
+			t.Skipf("failed start a process: %v", err)
+		}
+		j := &job{cmd: cmd, start: start, done: make(chan struct{})}
+		go func() {
+			_ = cmd.Wait()
+			close(j.done)
+		}()
+		j.lastAccess.Store(time.Now().Unix())
+		jobs[jobKey{dir: dir, start: start}] = j
+		t.Cleanup(func() { j.kill(dir) })
+		return j
+	}
+	// two viewers of the video apart, as many jobs as allowed
+	first, second := run(0), run(100)
+	t.Cleanup(func() { jobs = map[jobKey]*job{} })
+	if findJob(dir, 3) != first || findJob(dir, 102) != second || findJob(dir, 50) != nil {
+		t.Error("the segments are not served by the jobs started before them")
+	}
+	if reclaim() {
+		t.Fatal("a job being watched is stopped")
+	}
+	// the first viewer has left, the second one keeps asking the segments made
+	first.lastAccess.Store(time.Now().Add(-2 * reclaimIdle).Unix())
+	second.lastAccess.Store(time.Now().Add(-2 * reclaimIdle).Unix())
+	touch(dir, 150)
+	if !reclaim() {
+		t.Fatal("the idle job is not stopped for a new one")
+	}
+	if _, ok := jobs[jobKey{dir: dir, start: 0}]; ok {
+		t.Error("the idle job is kept")
+	}
+	if _, ok := jobs[jobKey{dir: dir, start: 100}]; !ok {
+		t.Error("the job being watched is stopped")
+	}
+	select {
+	case <-first.done:
+	case <-time.After(5 * time.Second):
+		t.Error("the process of the idle job is not killed")
+	}
+}
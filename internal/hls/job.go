@@ -0,0 +1,380 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+const (
+	// idleTimeout is how long a transcode goes on without its files being asked
+	idleTimeout = 2 * time.Minute
+	// waitTimeout is how long a request waits for the file it asks to be transcoded
+	waitTimeout = time.Minute
+	// seekAhead is how many segments past the progress of a transcode are waited for,
+	// another transcode is started at a segment asked further ahead
+	seekAhead = 30 / SegmentDuration
+	// reclaimIdle is how long a transcode goes on without its files being asked
+	// before it's stopped for a new one when there are too many,
+	// the players ask a segment every SegmentDuration while playing
+	reclaimIdle = 30 * time.Second
+)
+
+var ErrBusy = errors.New("too many videos are being transcoded")
+
+// jobKey is the dir of the rendition a job makes and the segment it's started at
+type jobKey struct {
+	dir   string
+	start int
+}
+
+// job is an ffmpeg process transcoding a video into a rendition from the segment start on
+type job struct {
+	cmd        *exec.Cmd
+	start      int
+	done       chan struct{}
+	err        error
+	lastAccess atomic.Int64
+}
+
+var (
+	root string
+	mu   sync.Mutex
+	// jobs by the dir of their rendition and their start, several viewers of a video
+	// watching apart are served by the jobs started at where they are
+	jobs = map[jobKey]*job{}
+)
+
+func Init(dir string) error {
+	// the transcodes killed by a restart are not complete
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return err
+	}
+	root = dir
+	go func() {
+		for range time.Tick(time.Minute) {
+			clean()
+		}
+	}()
+	return nil
+}
+
+// Get returns the local path of the file of a rendition, which is <rendition>/index.m3u8 or <rendition>/seg_<n>.ts,
+// the video is transcoded if the file is not there yet. The index is the playlist written by ffmpeg,
+// it's only asked when the duration of the video is unknown, see Source.Playlist
+func Get(ctx context.Context, path string, obj model.Obj, file string) (string, error) {
+	name, f, _ := strings.Cut(file, "/")
+	r, ok := GetRendition(name)
+	if !ok {
+		return "", errors.WithStack(errs.ObjectNotFound)
+	}
+	dir := filepath.Join(root, key(path, obj), r.Name)
+	seg := 0
+	if f != IndexPlaylist {
+		seg = segmentIndex(f)
+		if ready(dir, f) {
+			touch(dir, seg)
+			return filepath.Join(dir, f), nil
+		}
+	}
+	j, err := getJob(ctx, path, obj, dir, r, seg)
+	if err != nil {
+		return "", err
+	}
+	if f == IndexPlaylist {
+		f = jobPlaylist(j.start)
+	}
+	return filepath.Join(dir, f), j.wait(ctx, dir, f)
+}
+
+// getJob returns the job making the segment seg of the rendition,
+// a new one is started at seg if no job makes it soon
+func getJob(ctx context.Context, path string, obj model.Obj, dir string, r Rendition, seg int) (*job, error) {
+	mu.Lock()
+	j := findJob(dir, seg)
+	mu.Unlock()
+	if j != nil {
+		return j, nil
+	}
+	// the job reads the video after the request is done
+	in, err := open(context.WithoutCancel(ctx), path, obj)
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if j = findJob(dir, seg); j != nil {
+		in.Close()
+		return j, nil
+	}
+	if !reclaim() {
+		in.Close()
+		return nil, ErrBusy
+	}
+	// the segments made by the jobs before are kept, they are listed in their playlists
+	if err = os.MkdirAll(dir, 0o777); err != nil {
+		in.Close()
+		return nil, err
+	}
+	j, err = start(in, dir, r, seg)
+	if err != nil {
+		return nil, err
+	}
+	jobs[jobKey{dir: dir, start: seg}] = j
+	return j, nil
+}
+
+// findJob returns the job of the rendition making the segment seg, mu must be held
+func findJob(dir string, seg int) *job {
+	for k, j := range jobs {
+		if k.dir == dir && j.makes(dir, seg) {
+			j.lastAccess.Store(time.Now().Unix())
+			return j
+		}
+	}
+	return nil
+}
+
+// touch keeps the job that made the segment seg from being stopped as idle,
+// the viewer asking the segments it has made is still watching
+func touch(dir string, seg int) {
+	mu.Lock()
+	defer mu.Unlock()
+	var last *job
+	for k, j := range jobs {
+		if k.dir == dir && k.start <= seg && (last == nil || k.start > last.start) {
+			last = j
+		}
+	}
+	if last != nil {
+		last.lastAccess.Store(time.Now().Unix())
+	}
+}
+
+// reclaim reports whether another job can be started, the least recently asked job
+// idle for reclaimIdle is stopped if there are too many. mu must be held
+func reclaim() bool {
+	var (
+		running int
+		idleKey jobKey
+		idle    *job
+	)
+	for k, j := range jobs {
+		select {
+		case <-j.done:
+			continue
+		default:
+		}
+		running++
+		if idle == nil || j.lastAccess.Load() < idle.lastAccess.Load() {
+			idleKey, idle = k, j
+		}
+	}
+	if running < setting.GetInt(conf.HLSMaxTranscodes, 2) {
+		return true
+	}
+	if idle == nil || idle.lastAccess.Load() > time.Now().Add(-reclaimIdle).Unix() {
+		return false
+	}
+	idle.kill(idleKey.dir)
+	delete(jobs, idleKey)
+	return true
+}
+
+// makes reports whether the job makes the segment seg soon, or has failed making it
+func (j *job) makes(dir string, seg int) bool {
+	if seg < j.start {
+		return false
+	}
+	select {
+	case <-j.done:
+		return true
+	default:
+		return seg <= j.progress(dir)+seekAhead
+	}
+}
+
+// progress returns the last segment the job has made
+func (j *job) progress(dir string) int {
+	playlist, err := os.ReadFile(filepath.Join(dir, jobPlaylist(j.start)))
+	if err != nil {
+		return j.start - 1
+	}
+	lines := strings.Split(strings.TrimSpace(string(playlist)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if segmentRegexp.MatchString(lines[i]) {
+			return segmentIndex(lines[i])
+		}
+	}
+	return j.start - 1
+}
+
+func (j *job) kill(dir string) {
+	select {
+	case <-j.done:
+	default:
+		if err := j.cmd.Process.Kill(); err != nil {
+			log.Warnf("failed kill transcode %s: %+v", dir, err)
+		}
+	}
+}
+
+// start starts a job reading the input, which is closed when the job ends
+func start(in *input, dir string, r Rendition, seg int) (*job, error) {
+	offset := seg * SegmentDuration
+	if seg > 0 {
+		in.args["ss"] = offset
+	}
+	cmd := ffmpeg.Input(in.name, in.args).
+		Output(filepath.Join(dir, jobPlaylist(seg)), ffmpeg.KwArgs{
+			"map":                  []string{"0:v:0", "0:a:0?"},
+			"c:v":                  "libx264",
+			"preset":               "veryfast",
+			"vf":                   "scale=-2:" + strconv.Itoa(r.Height),
+			"b:v":                  strconv.Itoa(r.Bitrate) + "k",
+			"maxrate":              strconv.Itoa(r.Bitrate) + "k",
+			"bufsize":              strconv.Itoa(r.Bitrate*2) + "k",
+			"c:a":                  "aac",
+			"b:a":                  strconv.Itoa(audioBitrate) + "k",
+			"ac":                   2,
+			"force_key_frames":     fmt.Sprintf("expr:gte(t,n_forced*%d)", SegmentDuration),
+			"output_ts_offset":     offset,
+			"f":                    "hls",
+			"hls_time":             SegmentDuration,
+			"hls_playlist_type":    "event",
+			"hls_flags":            "temp_file",
+			"start_number":         seg,
+			"hls_segment_filename": filepath.Join(dir, "seg_%05d.ts"),
+		}).
+		GlobalArgs("-loglevel", "error").Silent(true).Compile()
+	var stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = nil, &stderr
+	stdin, err := in.reader()
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if err = cmd.Start(); err != nil {
+		in.Close()
+		return nil, errors.WithMessage(err, "failed to start ffmpeg")
+	}
+	j := &job{cmd: cmd, start: seg, done: make(chan struct{})}
+	j.lastAccess.Store(time.Now().Unix())
+	go func() {
+		defer in.Close()
+		if err := cmd.Wait(); err != nil {
+			j.err = errors.Errorf("ffmpeg: %v: %s", err, strings.TrimSpace(stderr.String()))
+			log.Warnf("failed transcode %s: %+v", dir, j.err)
+		}
+		close(j.done)
+	}()
+	return j, nil
+}
+
+// wait waits for the file f of the rendition to be written
+func (j *job) wait(ctx context.Context, dir, f string) error {
+	timeout := time.NewTimer(waitTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if ready(dir, f) {
+			return nil
+		}
+		select {
+		case <-j.done:
+			if ready(dir, f) {
+				return nil
+			}
+			if j.err != nil {
+				return j.err
+			}
+			return errors.WithStack(errs.ObjectNotFound)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout.C:
+			return errors.New("timeout waiting for the transcode")
+		case <-ticker.C:
+		}
+	}
+}
+
+// ready reports whether the file f is written, a playlist is written once it's there,
+// a segment is complete once it's in the playlist of a job
+func ready(dir, f string) bool {
+	if !segmentRegexp.MatchString(f) {
+		return utils.Exists(filepath.Join(dir, f))
+	}
+	playlists, _ := filepath.Glob(filepath.Join(dir, "job_*.m3u8"))
+	for _, p := range playlists {
+		playlist, err := os.ReadFile(p)
+		if err == nil && bytes.Contains(playlist, []byte("\n"+f+"\n")) {
+			return true
+		}
+	}
+	return false
+}
+
+// jobPlaylist is the playlist written by the job started at the segment seg
+func jobPlaylist(seg int) string {
+	return fmt.Sprintf("job_%05d.m3u8", seg)
+}
+
+// clean kills the idle transcodes and removes the renditions older than the max age
+func clean() {
+	mu.Lock()
+	defer mu.Unlock()
+	idle := time.Now().Add(-idleTimeout).Unix()
+	running := make(map[string]bool)
+	for k, j := range jobs {
+		select {
+		case <-j.done:
+			delete(jobs, k)
+			continue
+		default:
+		}
+		if j.lastAccess.Load() < idle {
+			j.kill(k.dir)
+			delete(jobs, k)
+			continue
+		}
+		running[k.dir] = true
+	}
+	expire := time.Now().Add(-time.Duration(setting.GetInt(conf.HLSCacheMaxAge, 24)) * time.Hour)
+	dirs, _ := filepath.Glob(filepath.Join(root, "*", "*"))
+	for _, dir := range dirs {
+		if running[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.ModTime().Before(expire) {
+			if err = os.RemoveAll(dir); err != nil {
+				log.Warnf("failed remove rendition %s: %+v", dir, err)
+			}
+			// remove the video dir if it's empty now
+			_ = os.Remove(filepath.Dir(dir))
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package loginlimit throttles password attempts of every protocol,
+// the failures are counted by username and by client ip separately.
+package loginlimit
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	TypeUser = "user"
+	TypeIP   = "ip"
+)
+
+type Lock struct {
+	Key         string    `json:"key"`
+	Type        string    `json:"type"`
+	Value       string    `json:"value"`
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// maxLocks bounds the records, the failures with made-up usernames or ips add one each
+const maxLocks = 100000
+
+var (
+	mu    sync.Mutex
+	locks = make(map[string]*Lock)
+	// the stale records are pruned every minute once there is one
+	pruneOnce sync.Once
+)
+
+type policy struct {
+	maxAttempts int
+	backoff     time.Duration
+	lockout     time.Duration
+}
+
+func getPolicy(typ string) policy {
+	p := policy{
+		maxAttempts: setting.GetInt(conf.LoginMaxAttempts, 5),
+		backoff:     time.Duration(setting.GetInt(conf.LoginBackoffSeconds, 1)) * time.Second,
+		lockout:     time.Duration(setting.GetInt(conf.LoginLockoutMinutes, 15)) * time.Minute,
+	}
+	if typ == TypeIP {
+		p.maxAttempts = setting.GetInt(conf.LoginMaxAttemptsPerIP, 20)
+	}
+	return p
+}
+
+// delay is how long the next attempt has to wait after the failures,
+// it doubles on every failure and becomes the lockout at the max attempts
+func (p policy) delay(failures int) time.Duration {
+	if failures >= p.maxAttempts {
+		return p.lockout
+	}
+	if p.backoff <= 0 || failures <= 1 {
+		return 0
+	}
+	d := p.backoff << min(failures-2, 30)
+	return min(d, p.lockout)
+}
+
+func key(typ, value string) string {
+	return typ + ":" + value
+}
+
+// normalizeIP drops the port of remote addresses
+func normalizeIP(ip string) string {
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		return host
+	}
+	return ip
+}
+
+// get returns the active record, the stale ones are dropped
+func get(typ, value string, now time.Time) (*Lock, policy) {
+	p := getPolicy(typ)
+	k := key(typ, value)
+	l, ok := locks[k]
+	if !ok {
+		return nil, p
+	}
+	if l.stale(p, now) {
+		delete(locks, k)
+		return nil, p
+	}
+	return l, p
+}
+
+func (l *Lock) stale(p policy, now time.Time) bool {
+	return now.After(l.LockedUntil) && now.Sub(l.LastFailure) > p.lockout
+}
+
+func prune() {
+	now := time.Now()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(locks) == 0 {
+		return
+	}
+	policies := map[string]policy{TypeUser: getPolicy(TypeUser), TypeIP: getPolicy(TypeIP)}
+	for k, l := range locks {
+		if l.stale(policies[l.Type], now) {
+			delete(locks, k)
+		}
+	}
+}
+
+// makeRoom drops a tenth of the records not locked, the ones failed longest ago first,
+// the locked ones are kept so that a flood of failures can't unlock them.
+// It reports whether there is room for a new record
+func makeRoom(now time.Time) bool {
+	if len(locks) < maxLocks {
+		return true
+	}
+	unlocked := make([]*Lock, 0, len(locks))
+	for _, l := range locks {
+		if !now.Before(l.LockedUntil) {
+			unlocked = append(unlocked, l)
+		}
+	}
+	sort.Slice(unlocked, func(i, j int) bool {
+		return unlocked[i].LastFailure.Before(unlocked[j].LastFailure)
+	})
+	for _, l := range unlocked[:min(len(unlocked), max(maxLocks/10, 1))] {
+		delete(locks, l.Key)
+	}
+	return len(locks) < maxLocks
+}
+
+// Check returns an error if the username or the ip has to wait before the next attempt
+func Check(username, ip string) error {
+	ip = normalizeIP(ip)
+	now := time.Now()
+	mu.Lock()
+	defer mu.Unlock()
+	for _, t := range [][2]string{{TypeUser, username}, {TypeIP, ip}} {
+		if t[1] == "" {
+			continue
+		}
+		l, p := get(t[0], t[1], now)
+		if l == nil || p.maxAttempts <= 0 {
+			continue
+		}
+		if now.Before(l.LockedUntil) {
+			return errors.WithStack(fmt.Errorf("%w, retry after %s", errs.TooManyLoginAttempts,
+				l.LockedUntil.Sub(now).Round(time.Second)))
+		}
+	}
+	return nil
+}
+
+// Fail records a failed attempt of the protocol
+func Fail(protocol, username, ip string) {
+	ip = normalizeIP(ip)
+	log.Warnf("[%s] failed login attempt of user [%s] from %s", protocol, username, ip)
+	pruneOnce.Do(func() {
+		go func() {
+			for range time.Tick(time.Minute) {
+				prune()
+			}
+		}()
+	})
+	now := time.Now()
+	mu.Lock()
+	defer mu.Unlock()
+	for _, t := range [][2]string{{TypeUser, username}, {TypeIP, ip}} {
+		if t[1] == "" {
+			continue
+		}
+		l, p := get(t[0], t[1], now)
+		if p.maxAttempts <= 0 {
+			continue
+		}
+		if l == nil {
+			if !makeRoom(now) {
+				log.Warnf("[%s] too many failed logins are recorded, the failure of %s %s is not", protocol, t[0], t[1])
+				continue
+			}
+			l = &Lock{Key: key(t[0], t[1]), Type: t[0], Value: t[1]}
+			locks[l.Key] = l
+		}
+		l.Failures++
+		l.LastFailure = now
+		l.LockedUntil = now.Add(p.delay(l.Failures))
+		if l.Failures == p.maxAttempts {
+			log.Warnf("[%s] %s %s is locked until %s", protocol, t[0], t[1], l.LockedUntil.Format(time.DateTime))
+		}
+	}
+}
+
+// Succeed resets the failures of the username,
+// the failures of the ip are kept so that a known account can't be used to reset them
+func Succeed(username string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(locks, key(TypeUser, username))
+}
+
+// List returns the records which still count
+func List() []Lock {
+	now := time.Now()
+	mu.Lock()
+	defer mu.Unlock()
+	res := make([]Lock, 0, len(locks))
+	for _, l := range locks {
+		if l, _ := get(l.Type, l.Value, now); l != nil {
+			res = append(res, *l)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastFailure.After(res[j].LastFailure)
+	})
+	return res
+}
+
+// Clear removes the record of the key, all records are removed if the key is empty
+func Clear(k string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if k == "" {
+		locks = make(map[string]*Lock)
+		return
+	}
+	delete(locks, k)
+}
@@ -0,0 +1,46 @@
+package loginlimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	p := policy{maxAttempts: 5, backoff: time.Second, lockout: time.Minute}
+	for failures, want := range []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, time.Minute, time.Minute} {
+		if got := p.delay(failures); got != want {
+			t.Errorf("delay(%d) = %s, want %s", failures, got, want)
+		}
+	}
+	p.backoff = 30 * time.Second
+	if got := p.delay(4); got != time.Minute {
+		t.Errorf("the backoff should be capped by the lockout, got %s", got)
+	}
+}
+
+func TestMakeRoom(t *testing.T) {
+	defer Clear("")
+	now := time.Now()
+	for i := 0; i < maxLocks; i++ {
+		k := key(TypeIP, fmt.Sprint(i))
+		locks[k] = &Lock{Key: k, Type: TypeIP, LastFailure: now.Add(time.Duration(i) * time.Millisecond)}
+	}
+	// the oldest is locked
+	locks[key(TypeIP, "0")].LockedUntil = now.Add(time.Minute)
+	if !makeRoom(now) {
+		t.Fatal("no room is made")
+	}
+	if len(locks) != maxLocks-maxLocks/10 {
+		t.Errorf("got %d records", len(locks))
+	}
+	if _, ok := locks[key(TypeIP, "0")]; !ok {
+		t.Error("a locked record is dropped")
+	}
+	if _, ok := locks[key(TypeIP, "1")]; ok {
+		t.Error("the oldest unlocked record is kept")
+	}
+	if _, ok := locks[key(TypeIP, fmt.Sprint(maxLocks-1))]; !ok {
+		t.Error("the newest record is dropped")
+	}
+}
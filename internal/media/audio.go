@@ -0,0 +1,67 @@
+package media
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/dhowden/tag"
+	"github.com/pkg/errors"
+)
+
+// readTags reads the ID3, FLAC, MP4 or OGG tags
+func readTags(rs io.ReadSeeker) (*model.MediaInfo, *tag.Picture, error) {
+	m, err := tag.ReadFrom(rs)
+	if err != nil {
+		return nil, nil, err
+	}
+	track, _ := m.Track()
+	info := &model.MediaInfo{
+		Title:  m.Title(),
+		Artist: m.Artist(),
+		Album:  m.Album(),
+		Genre:  m.Genre(),
+		Year:   m.Year(),
+		Track:  track,
+	}
+	if info.Artist == "" {
+		info.Artist = m.AlbumArtist()
+	}
+	pic := m.Picture()
+	info.HasCover = pic != nil && len(pic.Data) > 0
+	return info, pic, nil
+}
+
+// Cover returns the cover art embedded in the audio
+func Cover(ctx context.Context, path string, obj model.Obj) (*tag.Picture, error) {
+	if obj.IsDir() || utils.GetFileType(obj.GetName()) != conf.AUDIO {
+		return nil, errors.WithStack(errs.NotSupport)
+	}
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return nil, err
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	rs, err := stream.NewReadAtSeeker(ss, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	_, pic, err := readTags(rs)
+	if err != nil {
+		return nil, err
+	}
+	if pic == nil || len(pic.Data) == 0 {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+	return pic, nil
+}
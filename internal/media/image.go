@@ -0,0 +1,220 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// maxExifSize is how much of the head of a tiff is read, the exif of a jpeg is in a segment up to 64KB
+const maxExifSize = 256 << 10
+
+const (
+	tagMake             = 0x010f
+	tagModel            = 0x0110
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+)
+
+func readImage(rs io.ReadSeeker) (*model.MediaInfo, error) {
+	info := &model.MediaInfo{}
+	// the size is known by the decoders of the standard formats only
+	if cfg, _, err := image.DecodeConfig(rs); err == nil {
+		info.Width, info.Height = cfg.Width, cfg.Height
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := readExif(rs)
+	if err != nil {
+		return nil, err
+	}
+	parseExif(data, info)
+	return info, nil
+}
+
+// readExif returns the tiff structure of the exif of a jpeg or a tiff, or nil if there is none
+func readExif(rs io.ReadSeeker) ([]byte, error) {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(rs, head); err != nil {
+		return nil, err
+	}
+	switch {
+	case string(head[:2]) == "II" || string(head[:2]) == "MM":
+		buf := make([]byte, maxExifSize)
+		copy(buf, head)
+		n, err := io.ReadFull(rs, buf[4:])
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, err
+		}
+		return buf[:4+n], nil
+	case head[0] != 0xff || head[1] != 0xd8:
+		return nil, nil
+	}
+	marker := head[2:4]
+	// the exif is in one of the first segments
+	for i := 0; i < 16 && marker[0] == 0xff; i++ {
+		// start of scan or end of image
+		if marker[1] == 0xda || marker[1] == 0xd9 {
+			break
+		}
+		var l [2]byte
+		if _, err := io.ReadFull(rs, l[:]); err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint16(l[:])) - 2
+		if size < 0 {
+			break
+		}
+		if marker[1] == 0xe1 {
+			seg := make([]byte, size)
+			if _, err := io.ReadFull(rs, seg); err != nil {
+				return nil, err
+			}
+			if data, ok := bytes.CutPrefix(seg, []byte("Exif\x00\x00")); ok {
+				return data, nil
+			}
+		} else if _, err := rs.Seek(size, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(rs, marker); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	// the value itself if it fits in, otherwise the offset of the value
+	value []byte
+}
+
+// parseExif sets the camera, the date and the location in the exif to info
+func parseExif(data []byte, info *model.MediaInfo) {
+	if len(data) < 8 {
+		return
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return
+	}
+	ifd0 := t.ifd(t.order.Uint32(data[4:8]))
+	info.Make = t.str(ifd0[tagMake])
+	info.Model = t.str(ifd0[tagModel])
+	taken := t.str(ifd0[tagDateTime])
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if s := t.str(t.ifd(t.long(e))[tagDateTimeOriginal]); s != "" {
+			taken = s
+		}
+	}
+	// the time of the camera, whose time zone is unknown
+	if tm, err := time.Parse("2006:01:02 15:04:05", taken); err == nil {
+		info.TakenAt = &tm
+	}
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		gps := t.ifd(t.long(e))
+		info.Latitude = t.coord(gps[tagGPSLatitudeRef], gps[tagGPSLatitude], "S")
+		info.Longitude = t.coord(gps[tagGPSLongitudeRef], gps[tagGPSLongitude], "W")
+	}
+}
+
+func (t *tiff) ifd(off uint32) map[uint16]ifdEntry {
+	entries := make(map[uint16]ifdEntry)
+	if int64(off)+2 > int64(len(t.data)) {
+		return entries
+	}
+	n := int(t.order.Uint16(t.data[off:]))
+	for i := 0; i < n; i++ {
+		p := int(off) + 2 + 12*i
+		if p+12 > len(t.data) {
+			break
+		}
+		entries[t.order.Uint16(t.data[p:])] = ifdEntry{
+			typ:   t.order.Uint16(t.data[p+2:]),
+			count: t.order.Uint32(t.data[p+4:]),
+			value: t.data[p+8 : p+12],
+		}
+	}
+	return entries
+}
+
+// bytes returns the value of the entry whose items are of size
+func (t *tiff) bytes(e ifdEntry, size int) []byte {
+	total := int64(e.count) * int64(size)
+	if total <= 4 {
+		return e.value[:total]
+	}
+	off := int64(t.order.Uint32(e.value))
+	if off+total > int64(len(t.data)) {
+		return nil
+	}
+	return t.data[off : off+total]
+}
+
+func (t *tiff) str(e ifdEntry) string {
+	// ascii
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(t.bytes(e, 1)), "\x00"))
+}
+
+func (t *tiff) long(e ifdEntry) uint32 {
+	switch e.typ {
+	case 3:
+		return uint32(t.order.Uint16(e.value))
+	case 4:
+		return t.order.Uint32(e.value)
+	}
+	return 0
+}
+
+// coord converts the degrees, minutes and seconds of a gps entry, ref is the hemisphere
+func (t *tiff) coord(ref, e ifdEntry, negative string) *float64 {
+	// rational
+	if e.typ != 5 || e.count != 3 {
+		return nil
+	}
+	b := t.bytes(e, 8)
+	if b == nil {
+		return nil
+	}
+	var v float64
+	for i, unit := range []float64{1, 60, 3600} {
+		num, den := t.order.Uint32(b[8*i:]), t.order.Uint32(b[8*i+4:])
+		if den == 0 {
+			return nil
+		}
+		v += float64(num) / float64(den) / unit
+	}
+	if t.str(ref) == negative {
+		v = -v
+	}
+	return &v
+}
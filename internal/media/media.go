@@ -0,0 +1,110 @@
+// Package media reads the metadata of audios, images and videos from their headers
+package media
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	infos = cache.NewMemCache(cache.WithShards[*model.MediaInfo](64))
+	// the files without metadata are not read again and again
+	failed = cache.NewMemCache(cache.WithShards[error](16))
+	group  singleflight.Group
+)
+
+func Enabled() bool {
+	return setting.GetBool(conf.MediaInfoEnabled)
+}
+
+func Supported(obj model.Obj) bool {
+	if obj.IsDir() {
+		return false
+	}
+	switch utils.GetFileType(obj.GetName()) {
+	case conf.AUDIO, conf.IMAGE, conf.VIDEO:
+		return true
+	}
+	return false
+}
+
+func key(path string, obj model.Obj) string {
+	return path + "\n" + strconv.FormatInt(obj.GetSize(), 10) + "\n" + strconv.FormatInt(obj.ModTime().UnixNano(), 10)
+}
+
+// Get returns the metadata of the file, which is cached by the path, the size and the modified time
+func Get(ctx context.Context, path string, obj model.Obj) (*model.MediaInfo, error) {
+	if !Supported(obj) {
+		return nil, errors.WithStack(errs.NotSupport)
+	}
+	k := key(path, obj)
+	if info, ok := infos.Get(k); ok {
+		return info, nil
+	}
+	if err, ok := failed.Get(k); ok {
+		return nil, err
+	}
+	v, err, _ := group.Do(k, func() (interface{}, error) {
+		link, _, err := fs.Link(ctx, path, model.LinkArgs{Header: http.Header{}})
+		if err != nil {
+			return nil, err
+		}
+		info, err := read(ctx, obj, link)
+		if err != nil {
+			if permanent(ctx, err) {
+				failed.Set(k, err, cache.WithEx[error](10*time.Minute))
+			}
+			return nil, err
+		}
+		infos.Set(k, info, cache.WithEx[*model.MediaInfo](24*time.Hour))
+		return info, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*model.MediaInfo), nil
+}
+
+// permanent reports whether the failure is for the file itself,
+// the ones by a canceled request, a timeout or the network are tried again next time
+func permanent(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return !errors.As(err, &netErr)
+}
+
+func read(ctx context.Context, obj model.Obj, link *model.Link) (*model.MediaInfo, error) {
+	if utils.GetFileType(obj.GetName()) == conf.VIDEO {
+		return probeVideo(link)
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	rs, err := stream.NewReadAtSeeker(ss, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	if utils.GetFileType(obj.GetName()) == conf.AUDIO {
+		info, _, err := readTags(rs)
+		return info, err
+	}
+	return readImage(rs)
+}
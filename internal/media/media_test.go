@@ -0,0 +1,102 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// buildExif builds a little endian tiff with the camera, the date and the gps of a photo
+func buildExif() []byte {
+	order := binary.LittleEndian
+	buf := new(bytes.Buffer)
+	buf.WriteString("II")
+	_ = binary.Write(buf, order, uint16(42))
+	_ = binary.Write(buf, order, uint32(8))
+	// the values not fitting in the entries follow the ifds
+	const (
+		ifd0   = 8
+		gpsIFD = ifd0 + 2 + 12*3 + 4
+		values = gpsIFD + 2 + 12*2 + 4
+	)
+	var data bytes.Buffer
+	entry := func(w *bytes.Buffer, tag, typ uint16, count uint32, value []byte) {
+		_ = binary.Write(w, order, tag)
+		_ = binary.Write(w, order, typ)
+		_ = binary.Write(w, order, count)
+		if len(value) <= 4 {
+			w.Write(append(value, make([]byte, 4-len(value))...))
+			return
+		}
+		_ = binary.Write(w, order, uint32(values+data.Len()))
+		data.Write(value)
+	}
+	rational := func(vs ...uint32) []byte {
+		b := make([]byte, 4*len(vs))
+		for i, v := range vs {
+			order.PutUint32(b[4*i:], v)
+		}
+		return b
+	}
+	_ = binary.Write(buf, order, uint16(3))
+	entry(buf, tagMake, 2, 6, []byte("Canon\x00"))
+	entry(buf, tagDateTime, 2, 20, []byte("2024:05:06 07:08:09\x00"))
+	entry(buf, tagGPSIFD, 4, 1, order.AppendUint32(nil, gpsIFD))
+	_ = binary.Write(buf, order, uint32(0))
+	_ = binary.Write(buf, order, uint16(2))
+	entry(buf, tagGPSLongitudeRef, 2, 2, []byte("W\x00"))
+	entry(buf, tagGPSLongitude, 5, 3, rational(122, 1, 30, 1, 0, 1))
+	_ = binary.Write(buf, order, uint32(0))
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func TestExif(t *testing.T) {
+	tiff := buildExif()
+	// a jpeg with an app0 segment before the exif
+	var jpeg bytes.Buffer
+	jpeg.Write([]byte{0xff, 0xd8, 0xff, 0xe0, 0, 4, 0, 0, 0xff, 0xe1})
+	_ = binary.Write(&jpeg, binary.BigEndian, uint16(len(tiff)+8))
+	jpeg.WriteString("Exif\x00\x00")
+	jpeg.Write(tiff)
+	jpeg.Write([]byte{0xff, 0xda})
+
+	data, err := readExif(bytes.NewReader(jpeg.Bytes()))
+	if err != nil || !bytes.Equal(data, tiff) {
+		t.Fatalf("failed read exif: %v", err)
+	}
+	var info model.MediaInfo
+	parseExif(data, &info)
+	if info.Make != "Canon" || info.TakenAt == nil || info.TakenAt.Format("2006-01-02 15:04:05") != "2024-05-06 07:08:09" {
+		t.Errorf("unexpected exif: %+v", info)
+	}
+	if info.Latitude != nil || info.Longitude == nil || math.Abs(*info.Longitude+122.5) > 1e-9 {
+		t.Errorf("unexpected location: %v %v", info.Latitude, info.Longitude)
+	}
+	if info.Keywords() != "Canon" {
+		t.Errorf("unexpected keywords: %q", info.Keywords())
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if !permanent(ctx, errors.New("no metadata")) {
+		t.Error("a file without metadata is read again")
+	}
+	if permanent(ctx, errors.WithMessage(context.DeadlineExceeded, "failed to probe the video")) {
+		t.Error("a timeout is cached")
+	}
+	if permanent(ctx, &net.OpError{Op: "read", Err: errors.New("connection reset")}) {
+		t.Error("a network failure is cached")
+	}
+	cancel()
+	if permanent(ctx, errors.New("no metadata")) {
+		t.Error("the failure of a canceled request is cached")
+	}
+}
@@ -0,0 +1,59 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+const probeTimeout = time.Minute
+
+// probeVideo lets ffprobe read the headers of the video itself
+func probeVideo(link *model.Link) (*model.MediaInfo, error) {
+	input, args, err := stream.FFmpegInput(link)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	out, err := ffmpeg.ProbeWithTimeout(input, probeTimeout, args)
+	if err != nil {
+		// ffprobe is killed at the timeout
+		if time.Since(start) >= probeTimeout {
+			err = context.DeadlineExceeded
+		}
+		return nil, errors.WithMessage(err, "failed to probe the video")
+	}
+	return parseProbe(out)
+}
+
+func parseProbe(out string) (*model.MediaInfo, error) {
+	var probe struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal([]byte(out), &probe); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the probe")
+	}
+	info := &model.MediaInfo{}
+	info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	for _, s := range probe.Streams {
+		if s.CodecType == "video" {
+			info.Codec, info.Width, info.Height = s.CodecName, s.Width, s.Height
+			break
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,17 @@
+package model
+
+// AccessRule is the rule of the downloads of a storage or a meta path
+type AccessRule struct {
+	// the hosts allowed to hotlink, one pattern like *.example.com per line, the requests without referer are allowed
+	RefererAllow string `json:"referer_allow"`
+	// one regular expression of the user agents denied per line
+	UADeny string `json:"ua_deny"`
+	// the ipv4 prefix length the signs are bound to, the ipv6 clients are bound to their /64, 0 to disable
+	BindIP int `json:"bind_ip"`
+	// the max concurrent connections of a link, 0 means no limit
+	MaxConns int `json:"max_conns"`
+}
+
+func (r *AccessRule) IsEmpty() bool {
+	return r.RefererAllow == "" && r.UADeny == "" && r.BindIP <= 0 && r.MaxConns <= 0
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+const (
+	// APITokenPrefix tells personal api tokens apart from login tokens
+	APITokenPrefix = "alist-pat-"
+	// APITokenAccessKeyPrefix tells the s3 access keys of api tokens apart from the one in settings
+	APITokenAccessKeyPrefix = "ALPT"
+)
+
+// APIToken is a long-lived token minted by a user, only its hash is stored.
+// The token acts as its owner restricted to Path and Permission.
+type APIToken struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"index"`
+	Name      string `json:"name"`
+	TokenHash string `json:"-" gorm:"unique"`
+	// AccessKey is the access key id when the token is used by s3 clients
+	AccessKey  string    `json:"access_key" gorm:"unique"`
+	Path       string    `json:"path"`
+	Permission int32     `json:"permission"`
+	ExpiresAt  time.Time `json:"expires_at"` // zero means never
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	LastUsedIP string    `json:"last_used_ip"`
+}
+
+func HashAPIToken(token string) string {
+	return utils.HashData(utils.SHA256, []byte(token))
+}
+
+func (t *APIToken) IsExpired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt)
+}
+
+// Scope returns a copy of the owner restricted by the token,
+// the base path is narrowed to Path so every protocol honors it.
+// The copy must never be saved.
+func (t *APIToken) Scope(owner *User) (*User, error) {
+	basePath, err := owner.JoinPath(t.Path)
+	if err != nil {
+		return nil, err
+	}
+	user := *owner
+	user.BasePath = basePath
+	if owner.IsAdmin() {
+		// a token never grants access to the admin apis
+		user.Role = GENERAL
+		user.Permission = t.Permission
+	} else {
+		user.Permission = owner.Permission & t.Permission
+	}
+	return &user, nil
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// BackupVersion is increased when the layout of Backup is changed incompatibly
+const BackupVersion = 1
+
+// Backup is the whole configuration exported by `alist export`,
+// the records refer to each other by their names instead of their ids
+type Backup struct {
+	Version       int            `json:"version"`
+	AlistVersion  string         `json:"alist_version"`
+	Created       time.Time      `json:"created"`
+	Storages      []Storage      `json:"storages"`
+	LimitProfiles []LimitProfile `json:"limit_profiles"`
+	Users         []BackupUser   `json:"users"`
+	Metas         []Meta         `json:"metas"`
+	Settings      []SettingItem  `json:"settings"`
+	SSHKeys       []BackupSSHKey `json:"ssh_keys"`
+}
+
+// BackupUser carries the credentials hidden from the api
+type BackupUser struct {
+	User
+	PwdHash      string `json:"pwd_hash"`
+	PwdTS        int64  `json:"pwd_ts"`
+	Salt         string `json:"salt"`
+	OtpSecret    string `json:"otp_secret"`
+	Authn        string `json:"authn"`
+	LimitProfile string `json:"limit_profile"`
+}
+
+type BackupSSHKey struct {
+	SSHPublicKey
+	Username string `json:"username"`
+	KeyStr   string `json:"key_str"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Duplicate is a file found by the duplicate finder,
+// the files with the same hash and size are copies of each other
+type Duplicate struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Hash     string    `json:"hash" gorm:"index"` // <hash type>:<hex>
+	Size     int64     `json:"size"`
+	Path     string    `json:"path"`
+	Modified time.Time `json:"modified"`
+}
+
+type DuplicateGroup struct {
+	Hash  string      `json:"hash"`
+	Size  int64       `json:"size"`
+	Count int         `json:"count"`
+	Files []Duplicate `json:"files" gorm:"-"`
+}
+
+type DedupProgress struct {
+	ObjCount     uint64     `json:"obj_count"`
+	Candidates   uint64     `json:"candidates"` // the files sharing their size with others
+	GroupCount   uint64     `json:"group_count"`
+	IsDone       bool       `json:"is_done"`
+	LastDoneTime *time.Time `json:"last_done_time"`
+	Error        string     `json:"error"`
+}
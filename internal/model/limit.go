@@ -0,0 +1,12 @@
+package model
+
+// LimitProfile is the transfer limits of the users attached to it, every user has limits of its own
+type LimitProfile struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"unique" binding:"required"`
+	// KB/s, 0 means no limit
+	DownloadSpeed int `json:"download_speed"`
+	UploadSpeed   int `json:"upload_speed"`
+	// the max concurrent downloads and uploads, 0 means no limit
+	MaxTransfers int `json:"max_transfers"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaInfo is the metadata read from the headers of an audio, an image or a video
+type MediaInfo struct {
+	// the tags of audio
+	Title    string `json:"title,omitempty"`
+	Artist   string `json:"artist,omitempty"`
+	Album    string `json:"album,omitempty"`
+	Genre    string `json:"genre,omitempty"`
+	Year     int    `json:"year,omitempty"`
+	Track    int    `json:"track,omitempty"`
+	HasCover bool   `json:"has_cover,omitempty"`
+	// the exif of images
+	Make      string     `json:"make,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	TakenAt   *time.Time `json:"taken_at,omitempty"`
+	Latitude  *float64   `json:"latitude,omitempty"`
+	Longitude *float64   `json:"longitude,omitempty"`
+	// the stream of videos, the size of images too
+	Duration float64 `json:"duration,omitempty"`
+	Codec    string  `json:"codec,omitempty"`
+	Width    int     `json:"width,omitempty"`
+	Height   int     `json:"height,omitempty"`
+}
+
+// Keywords returns the text of the info to be searched by
+func (m *MediaInfo) Keywords() string {
+	var words []string
+	for _, w := range []string{m.Title, m.Artist, m.Album, m.Genre, m.Make, m.Model, m.Codec} {
+		if w = strings.TrimSpace(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	if m.Year > 0 {
+		words = append(words, strconv.Itoa(m.Year))
+	}
+	return strings.Join(words, " ")
+}
@@ -13,4 +13,25 @@ type Meta struct {
 	RSub      bool   `json:"r_sub"`
 	Header    string `json:"header"`
 	HeaderSub bool   `json:"header_sub"`
+	// upload policy, the lists are separated by commas
+	AllowExt  string `json:"allow_ext"`
+	DenyExt   string `json:"deny_ext"`
+	AllowMime string `json:"allow_mime"` // sniffed from the first bytes, image/* is supported
+	DenyMime  string `json:"deny_mime"`
+	MaxSize   int64  `json:"max_size"` // bytes, 0 means no limit
+	VirusScan bool   `json:"virus_scan"`
+	USub      bool   `json:"u_sub"`
+	// keep the old versions of the files overwritten or removed
+	Versioning   bool `json:"versioning"`
+	VersionCount int  `json:"version_count"` // 0 means no limit
+	VersionDays  int  `json:"version_days"`  // 0 means no limit
+	VSub         bool `json:"v_sub"`
+	// the access rule of the downloads, which takes the place of the rule of the storage
+	AccessRule
+	ASub bool `json:"a_sub"`
+}
+
+func (m *Meta) HasUploadPolicy() bool {
+	return m.AllowExt != "" || m.DenyExt != "" || m.AllowMime != "" || m.DenyMime != "" ||
+		m.MaxSize > 0 || m.VirusScan
 }
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// UserUsage is the storage usage accounted to a user,
+// it is kept apart from User so that updating a user won't overwrite it
+type UserUsage struct {
+	UserID         uint      `json:"user_id" gorm:"primaryKey"`
+	Used           int64     `json:"used"`
+	RecalculatedAt time.Time `json:"recalculated_at"`
+}
@@ -17,14 +17,24 @@ type SearchReq struct {
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	// empty for the names, or SearchModeContent for the content of the files
+	Mode string `json:"mode"`
 	PageReq
 }
 
+const SearchModeContent = "content"
+
 type SearchNode struct {
 	Parent string `json:"parent" gorm:"index"`
 	Name   string `json:"name"`
 	IsDir  bool   `json:"is_dir"`
 	Size   int64  `json:"size"`
+	// the keywords of the media info
+	Media string `json:"media,omitempty"`
+	// the text of the file, which is indexed by the full-text searchers only
+	Content string `json:"content,omitempty" gorm:"-"`
+	// the snippets of the content matched, the keywords are in <mark> tags
+	Highlights []string `json:"highlights,omitempty" gorm:"-"`
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,6 +44,9 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	if p.Mode != "" && p.Mode != SearchModeContent {
+		return fmt.Errorf("unknown search mode: %s", p.Mode)
+	}
 	return nil
 }
 
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// login methods of sessions
+const (
+	LoginPassword = "password"
+	LoginLDAP     = "ldap"
+	LoginSSO      = "sso"
+	LoginWebAuthn = "webauthn"
+)
+
+// Session is created for every login token, keyed by the id of the token.
+// A token is only accepted while its session exists, so deleting the session
+// revokes the token across restarts, and on the other replicas once they stop caching it.
+type Session struct {
+	ID         string    `json:"id" gorm:"primaryKey;size:64"`
+	UserID     uint      `json:"user_id" gorm:"index"`
+	Method     string    `json:"method"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
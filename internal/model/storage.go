@@ -19,6 +19,7 @@ type Storage struct {
 	EnableSign      bool      `json:"enable_sign"`
 	Sort
 	Proxy
+	AccessRule
 }
 
 type Sort struct {
@@ -57,3 +58,16 @@ func (p Proxy) WebdavProxy() bool {
 func (p Proxy) WebdavNative() bool {
 	return !p.Webdav302() && !p.WebdavProxy()
 }
+
+type DiskUsage struct {
+	TotalSpace int64 `json:"total_space"`
+	FreeSpace  int64 `json:"free_space"`
+}
+
+func (d DiskUsage) UsedSpace() int64 {
+	return d.TotalSpace - d.FreeSpace
+}
+
+type StorageDetails struct {
+	DiskUsage
+}
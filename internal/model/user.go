@@ -50,6 +50,9 @@ type User struct {
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
+	Quota      int64  `json:"quota"` // max bytes the user may store, 0 means unlimited
+	// the limit profile of the transfers, 0 means no profile
+	LimitProfileID uint `json:"limit_profile_id"`
 }
 
 func (u *User) IsGuest() bool {
@@ -137,6 +140,10 @@ func (u *User) CanDecompress() bool {
 	return (u.Permission>>13)&1 == 1
 }
 
+func (u *User) HasQuota() bool {
+	return u.Quota > 0
+}
+
 func (u *User) JoinPath(reqPath string) (string, error) {
 	return utils.JoinBasePath(u.BasePath, reqPath)
 }
@@ -0,0 +1,99 @@
+package op
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateAPIToken stores the token and returns the raw token,
+// it can't be recovered later since only the hash is stored
+func CreateAPIToken(t *model.APIToken) (string, error) {
+	raw := model.APITokenPrefix + random.String(40)
+	t.TokenHash = model.HashAPIToken(raw)
+	t.AccessKey = model.APITokenAccessKeyPrefix + strings.ToUpper(random.String(16))
+	t.Path = utils.FixAndCleanPath(t.Path)
+	t.CreatedAt = time.Now()
+	if err := db.CreateAPIToken(t); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// APITokenSecretKey is the secret key for s3 clients, s3 signatures need the
+// secret in plain, so it is derived from the stored hash and the jwt secret
+func APITokenSecretKey(t *model.APIToken) string {
+	mac := hmac.New(sha256.New, []byte(conf.Conf.JwtSecret))
+	mac.Write([]byte(t.TokenHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateAPIToken returns the owner of the raw token restricted by the token
+func ValidateAPIToken(raw, ip string) (*model.User, error) {
+	t, err := db.GetAPITokenByHash(model.HashAPIToken(raw))
+	if err != nil {
+		return nil, errors.WithStack(errs.InvalidAPIToken)
+	}
+	return UseAPIToken(t, ip)
+}
+
+func GetAPITokenByAccessKey(accessKey string) (*model.APIToken, error) {
+	t, err := db.GetAPITokenByAccessKey(accessKey)
+	if err != nil {
+		return nil, errors.WithStack(errs.InvalidAPIToken)
+	}
+	return t, nil
+}
+
+// UseAPIToken records the usage of an authenticated token
+// and returns its owner restricted by the token
+func UseAPIToken(t *model.APIToken, ip string) (*model.User, error) {
+	if t.IsExpired() {
+		return nil, errors.WithStack(errs.ExpiredAPIToken)
+	}
+	owner, err := GetUserById(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if owner.Disabled {
+		return nil, errors.New("the owner of the api token is disabled")
+	}
+	// avoid writing the db on every request
+	now := time.Now()
+	if now.Sub(t.LastUsedAt) > time.Minute || t.LastUsedIP != ip {
+		if err := db.UpdateAPITokenUsed(t.ID, now, ip); err != nil {
+			log.Warnf("failed update last used of api token %d: %+v", t.ID, err)
+		}
+	}
+	return t.Scope(owner)
+}
+
+func GetAPITokensByUserId(userId uint, pageIndex, pageSize int) ([]model.APIToken, int64, error) {
+	return db.GetAPITokensByUserId(userId, pageIndex, pageSize)
+}
+
+func GetAPITokenByIdAndUserId(id uint, userId uint) (*model.APIToken, error) {
+	t, err := db.GetAPITokenById(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.UserID != userId {
+		return nil, errors.New("api token doesn't belong to the user")
+	}
+	return t, nil
+}
+
+func DeleteAPITokenById(id uint) error {
+	return db.DeleteAPITokenById(id)
+}
@@ -0,0 +1,295 @@
+package op
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// exportSetting tells whether the setting is a part of the configuration,
+// the single ones like the token and the progresses belong to the instance
+func exportSetting(item model.SettingItem) bool {
+	return item.Group != model.SINGLE && item.Flag != model.READONLY && item.Flag != model.DEPRECATED
+}
+
+// Export collects the configuration into a backup, the statuses of the storages are left out
+func Export() (*model.Backup, error) {
+	b := &model.Backup{
+		Version:      model.BackupVersion,
+		AlistVersion: conf.Version,
+		Created:      time.Now(),
+	}
+	var err error
+	if b.Storages, _, err = db.GetStorages(1, -1); err != nil {
+		return nil, err
+	}
+	for i := range b.Storages {
+		b.Storages[i].Status = ""
+	}
+	if b.LimitProfiles, err = db.GetLimitProfiles(); err != nil {
+		return nil, err
+	}
+	profiles := make(map[uint]string)
+	for _, p := range b.LimitProfiles {
+		profiles[p.ID] = p.Name
+	}
+	users, _, err := db.GetUsers(1, -1)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[uint]string)
+	for _, u := range users {
+		usernames[u.ID] = u.Username
+		b.Users = append(b.Users, model.BackupUser{
+			User:         u,
+			PwdHash:      u.PwdHash,
+			PwdTS:        u.PwdTS,
+			Salt:         u.Salt,
+			OtpSecret:    u.OtpSecret,
+			Authn:        u.Authn,
+			LimitProfile: profiles[u.LimitProfileID],
+		})
+	}
+	if b.Metas, _, err = db.GetMetas(1, -1); err != nil {
+		return nil, err
+	}
+	items, err := db.GetSettingItems()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if exportSetting(item) {
+			b.Settings = append(b.Settings, item)
+		}
+	}
+	keys, _, err := db.GetSSHPublicKeys(1, -1)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		username, ok := usernames[k.UserId]
+		if !ok {
+			continue
+		}
+		b.SSHKeys = append(b.SSHKeys, model.BackupSSHKey{
+			SSHPublicKey: k,
+			Username:     username,
+			KeyStr:       k.KeyStr,
+		})
+	}
+	return b, nil
+}
+
+// found tells whether the record is found, the errors other than not found are returned
+func found(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// matchUser finds the user the backup user is imported to,
+// there is only one admin and one guest, whatever their names are
+func matchUser(u *model.User) (*model.User, bool, error) {
+	var old *model.User
+	var err error
+	if u.IsAdmin() || u.IsGuest() {
+		old, err = db.GetUserByRole(u.Role)
+	} else {
+		old, err = db.GetUserByName(u.Username)
+	}
+	ok, err := found(err)
+	return old, ok, err
+}
+
+// validateBackup checks the whole backup before anything is written,
+// so that a broken one doesn't leave the database half imported
+func validateBackup(b *model.Backup) error {
+	if b.Version < 1 || b.Version > model.BackupVersion {
+		return errors.Errorf("unsupported backup version: %d", b.Version)
+	}
+	profiles := make(map[string]bool)
+	for _, p := range b.LimitProfiles {
+		profiles[p.Name] = true
+	}
+	for _, s := range b.Storages {
+		if _, err := GetDriver(s.Driver); err != nil {
+			return errors.WithMessagef(err, "failed import storage [%s]", s.MountPath)
+		}
+	}
+	usernames := make(map[string]bool)
+	for _, bu := range b.Users {
+		u := bu.User
+		if bu.LimitProfile != "" && !profiles[bu.LimitProfile] {
+			return errors.Errorf("limit profile [%s] of user [%s] is not in the backup", bu.LimitProfile, u.Username)
+		}
+		old, ok, err := matchUser(&u)
+		if err != nil {
+			return err
+		}
+		if ok && old.Role != u.Role {
+			return errors.Errorf("user [%s] exists with another role", u.Username)
+		}
+		usernames[u.Username] = true
+	}
+	for _, bk := range b.SSHKeys {
+		if !usernames[bk.Username] {
+			return errors.Errorf("user [%s] of ssh key [%s] is not in the backup", bk.Username, bk.Title)
+		}
+	}
+	return nil
+}
+
+// Import writes the backup into the database, the records are matched by their names,
+// the existing ones are kept unless overwrite, while the settings known are always set.
+// The storages are loaded on the next start
+func Import(b *model.Backup, overwrite bool) error {
+	if err := validateBackup(b); err != nil {
+		return err
+	}
+	profiles := make(map[string]uint)
+	for i := range b.LimitProfiles {
+		p := b.LimitProfiles[i]
+		old, err := db.GetLimitProfileByName(p.Name)
+		ok, err := found(err)
+		if err != nil {
+			return err
+		}
+		if ok && !overwrite {
+			profiles[p.Name] = old.ID
+			continue
+		}
+		p.ID = 0
+		if ok {
+			p.ID = old.ID
+			err = UpdateLimitProfile(&p)
+		} else {
+			err = CreateLimitProfile(&p)
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed import limit profile [%s]", p.Name)
+		}
+		profiles[p.Name] = p.ID
+	}
+	for i := range b.Storages {
+		s := b.Storages[i]
+		s.MountPath = utils.FixAndCleanPath(s.MountPath)
+		old, err := db.GetStorageByMountPath(s.MountPath)
+		ok, err := found(err)
+		if err != nil {
+			return err
+		}
+		if ok && !overwrite {
+			continue
+		}
+		s.ID, s.Status = 0, ""
+		if ok {
+			s.ID = old.ID
+			err = db.UpdateStorage(&s)
+		} else {
+			err = db.CreateStorage(&s)
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed import storage [%s]", s.MountPath)
+		}
+	}
+	// the ids of the users the backup users are imported to, by the names in the backup
+	users := make(map[string]uint)
+	for _, bu := range b.Users {
+		u := bu.User
+		u.PwdHash, u.PwdTS, u.Salt = bu.PwdHash, bu.PwdTS, bu.Salt
+		u.OtpSecret, u.Authn, u.Password = bu.OtpSecret, bu.Authn, ""
+		u.LimitProfileID = profiles[bu.LimitProfile]
+		old, ok, err := matchUser(&u)
+		if err != nil {
+			return err
+		}
+		if ok && !overwrite {
+			users[bu.Username] = old.ID
+			continue
+		}
+		u.ID = 0
+		if ok {
+			u.ID = old.ID
+			err = UpdateUser(&u)
+		} else {
+			err = CreateUser(&u)
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed import user [%s]", u.Username)
+		}
+		users[bu.Username] = u.ID
+	}
+	for i := range b.Metas {
+		m := b.Metas[i]
+		m.Path = utils.FixAndCleanPath(m.Path)
+		old, err := db.GetMetaByPath(m.Path)
+		ok, err := found(err)
+		if err != nil {
+			return err
+		}
+		if ok && !overwrite {
+			continue
+		}
+		m.ID = 0
+		if ok {
+			m.ID = old.ID
+			err = UpdateMeta(&m)
+		} else {
+			err = CreateMeta(&m)
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed import meta [%s]", m.Path)
+		}
+	}
+	var items []model.SettingItem
+	for _, item := range b.Settings {
+		old, err := db.GetSettingItemByKey(item.Key)
+		ok, err := found(err)
+		if err != nil {
+			return err
+		}
+		// the settings unknown to this version are dropped
+		if !ok || !exportSetting(*old) {
+			continue
+		}
+		old.Value = item.Value
+		items = append(items, *old)
+	}
+	if len(items) > 0 {
+		if err := SaveSettingItems(items); err != nil {
+			return errors.WithMessage(err, "failed import settings")
+		}
+	}
+	for _, bk := range b.SSHKeys {
+		k := bk.SSHPublicKey
+		k.UserId, k.KeyStr = users[bk.Username], bk.KeyStr
+		old, err := db.GetSSHPublicKeyByUserTitle(k.UserId, k.Title)
+		ok, err := found(err)
+		if err != nil {
+			return err
+		}
+		if ok && !overwrite {
+			continue
+		}
+		k.ID = 0
+		if ok {
+			k.ID = old.ID
+			err = db.UpdateSSHPublicKey(&k)
+		} else {
+			err = db.CreateSSHPublicKey(&k)
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed import ssh key [%s] of user [%s]", k.Title, bk.Username)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package op_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestBackup(t *testing.T) {
+	profile := &model.LimitProfile{Name: "backup", DownloadSpeed: 1024}
+	if err := op.CreateLimitProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Username: "backup", BasePath: "/", LimitProfileID: profile.ID}
+	user.SetPassword("secret")
+	if err := op.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateSSHPublicKey(&model.SSHPublicKey{UserId: user.ID, Title: "laptop", KeyStr: "ssh-ed25519 AAAA"}); err != nil {
+		t.Fatal(err)
+	}
+	meta := &model.Meta{Path: "/backup", Password: "p"}
+	if err := op.CreateMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := op.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b model.Backup
+	if err = json.Unmarshal(data, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = op.DeleteUserById(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	meta.Password = "q"
+	if err = op.UpdateMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+	if err = op.Import(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := db.GetUserByName("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = restored.ValidateRawPassword("secret"); err != nil {
+		t.Errorf("password of the restored user: %v", err)
+	}
+	if restored.LimitProfileID != profile.ID {
+		t.Errorf("limit profile of the restored user: got %d, want %d", restored.LimitProfileID, profile.ID)
+	}
+	if _, err = db.GetSSHPublicKeyByUserTitle(restored.ID, "laptop"); err != nil {
+		t.Errorf("ssh key of the restored user: %v", err)
+	}
+	if m, _ := db.GetMetaByPath("/backup"); m.Password != "q" {
+		t.Errorf("meta is overwritten without overwrite: %s", m.Password)
+	}
+
+	if err = op.Import(&b, true); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := db.GetMetaByPath("/backup"); m.Password != "p" {
+		t.Errorf("meta is not overwritten: %s", m.Password)
+	}
+
+	b.Version = model.BackupVersion + 1
+	if err = op.Import(&b, true); err == nil {
+		t.Error("backup of a newer version is imported")
+	}
+
+	// a broken backup is refused before anything is written
+	b.Version = model.BackupVersion
+	b.LimitProfiles = append(b.LimitProfiles, model.LimitProfile{Name: "partial"})
+	b.Storages = append(b.Storages, model.Storage{Driver: "NoSuchDriver", MountPath: "/partial"})
+	if err = op.Import(&b, true); err == nil {
+		t.Error("backup with an unknown driver is imported")
+	}
+	if _, err = db.GetLimitProfileByName("partial"); err == nil {
+		t.Error("limit profile of a refused backup is imported")
+	}
+}
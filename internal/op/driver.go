@@ -20,9 +20,18 @@ func RegisterDriver(driver DriverConstructor) {
 	tempDriver := driver()
 	tempConfig := tempDriver.Config()
 	registerDriverItems(tempConfig, tempDriver.GetAddition())
+	registerDriverCapabilities(tempConfig, tempDriver)
 	driverMap[tempConfig.Name] = driver
 }
 
+// RegisterDriverInfo registers a driver whose info can't be reflected from its Addition,
+// such as an out-of-process plugin, the common items are still generated from config
+func RegisterDriverInfo(constructor DriverConstructor, info driver.Info) {
+	info.Common = getMainItems(info.Config)
+	driverInfoMap[info.Config.Name] = info
+	driverMap[info.Config.Name] = constructor
+}
+
 func GetDriver(name string) (DriverConstructor, error) {
 	n, ok := driverMap[name]
 	if !ok {
@@ -58,6 +67,12 @@ func registerDriverItems(config driver.Config, addition driver.Additional) {
 	}
 }
 
+func registerDriverCapabilities(config driver.Config, d driver.Driver) {
+	info := driverInfoMap[config.Name]
+	info.Capabilities = driver.GetCapabilities(d)
+	driverInfoMap[config.Name] = info
+}
+
 func getMainItems(config driver.Config) []driver.Item {
 	items := []driver.Item{{
 		Name:     "mount_path",
@@ -116,6 +131,23 @@ func getMainItems(config driver.Config) []driver.Item {
 	items = append(items, driver.Item{
 		Name: "down_proxy_url",
 		Type: conf.TypeText,
+		Help: "one proxy per line, optionally followed by weight=2, cidr=10.0.0.0/8,192.168.0.0/16 to prefer it for the clients in the ranges, and health=/path probed by the health checks",
+	}, driver.Item{
+		Name: "referer_allow",
+		Type: conf.TypeText,
+		Help: "the hosts allowed to hotlink the downloads, one pattern like *.example.com per line",
+	}, driver.Item{
+		Name: "ua_deny",
+		Type: conf.TypeText,
+		Help: "one regular expression of the user agents denied per line",
+	}, driver.Item{
+		Name: "bind_ip",
+		Type: conf.TypeNumber,
+		Help: "bind the signs of the links to the ipv4 prefix of this length, the ipv6 clients to their /64, 0 to disable",
+	}, driver.Item{
+		Name: "max_conns",
+		Type: conf.TypeNumber,
+		Help: "the max concurrent connections of a link, 0 means no limit",
 	})
 	if config.LocalSort {
 		items = append(items, []driver.Item{{
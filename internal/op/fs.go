@@ -451,11 +451,16 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if err != nil {
 		return errors.WithMessage(err, "failed to get dst dir")
 	}
+	if !srcObj.IsDir() {
+		if err := CheckQuota(ctx, srcObj.GetSize()); err != nil {
+			return err
+		}
+	}
 
 	switch s := storage.(type) {
 	case driver.CopyResult:
 		var newObj model.Obj
-		newObj, err = s.Copy(ctx, srcObj, dstDir)
+		newObj, err = s.Copy(withAccounted(ctx), srcObj, dstDir)
 		if err == nil {
 			if newObj != nil {
 				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
@@ -464,13 +469,16 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			}
 		}
 	case driver.Copy:
-		err = s.Copy(ctx, srcObj, dstDir)
+		err = s.Copy(withAccounted(ctx), srcObj, dstDir)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
 	default:
 		return errs.NotImplement
 	}
+	if err == nil && !srcObj.IsDir() {
+		addUsage(ctx, srcObj.GetSize())
+	}
 	return errors.WithStack(err)
 }
 
@@ -482,6 +490,18 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 		return errors.New("delete root folder is not allowed, please goto the manage page to delete the storage instead")
 	}
 	path = utils.FixAndCleanPath(path)
+	if meta := versioningMeta(storage, path); meta != nil {
+		obj, err := Get(ctx, storage, path)
+		if err == nil && !obj.IsDir() && obj.GetSize() > 0 {
+			dirPath, name := stdpath.Split(path)
+			return archiveVersion(ctx, storage, dirPath, name, name, meta)
+		}
+	}
+	return remove(ctx, storage, path)
+}
+
+// remove deletes the object without keeping a version of it
+func remove(ctx context.Context, storage driver.Driver, path string) error {
 	rawObj, err := Get(ctx, storage, path)
 	if err != nil {
 		// if object not found, it's ok
@@ -495,8 +515,11 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 
 	switch s := storage.(type) {
 	case driver.Remove:
-		err = s.Remove(ctx, model.UnwrapObj(rawObj))
+		// the files in a folder are summed up before they are gone
+		size := usageOf(ctx, storage, path, rawObj)
+		err = s.Remove(withAccounted(ctx), model.UnwrapObj(rawObj))
 		if err == nil {
+			addUsage(ctx, -size)
 			delCacheObj(storage, dirPath, rawObj)
 			// clear folder cache recursively
 			if rawObj.IsDir() {
@@ -509,6 +532,26 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	return errors.WithStack(err)
 }
 
+func SetModTime(ctx context.Context, storage driver.Driver, path string, modTime time.Time) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	s, ok := storage.(driver.SetModTime)
+	if !ok {
+		return errs.NotImplement
+	}
+	path = utils.FixAndCleanPath(path)
+	rawObj, err := Get(ctx, storage, path)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get object")
+	}
+	err = s.SetModTime(ctx, model.UnwrapObj(rawObj), modTime)
+	if err == nil {
+		ClearCache(storage, stdpath.Dir(path))
+	}
+	return errors.WithStack(err)
+}
+
 func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file model.FileStreamer, up driver.UpdateProgress, lazyCache ...bool) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
@@ -518,6 +561,9 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			log.Errorf("failed to close file streamer, %v", err)
 		}
 	}()
+	if err := CheckUploadPolicy(ctx, storage, dstDirPath, file); err != nil {
+		return err
+	}
 	// UrlTree PUT
 	if storage.GetStorage().Driver == "UrlTree" {
 		var link string
@@ -530,13 +576,37 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	tempName := file.GetName() + ".alist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
+	var oldSize int64
+	if err == nil {
+		oldSize = fi.GetSize()
+	}
+	// the old obj is renamed aside during the upload, then removed or kept as a version
+	var versioning *model.Meta
+	renameAside := storage.Config().NoOverwriteUpload
+	if err == nil && fi.GetSize() > 0 {
+		if versioning = versioningMeta(storage, dstPath); versioning != nil {
+			renameAside = true
+		}
+	}
+	// a version kept still takes its space, so the new file takes its full size
+	quotaSize := file.GetSize() - oldSize
+	if versioning != nil {
+		quotaSize = file.GetSize()
+	}
+	if err := CheckQuota(ctx, quotaSize); err != nil {
+		return err
+	}
+	counter, cerr := countStream(ctx, file)
+	if cerr != nil {
+		return cerr
+	}
 	if err == nil {
 		if fi.GetSize() == 0 {
 			err = Remove(ctx, storage, dstPath)
 			if err != nil {
 				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
 			}
-		} else if storage.Config().NoOverwriteUpload {
+		} else if renameAside {
 			// try to rename old obj
 			err = Rename(ctx, storage, dstPath, tempName)
 			if err != nil {
@@ -563,7 +633,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	switch s := storage.(type) {
 	case driver.PutResult:
 		var newObj model.Obj
-		newObj, err = s.Put(ctx, parentDir, file, up)
+		newObj, err = s.Put(withAccounted(ctx), parentDir, file, up)
 		if err == nil {
 			if newObj != nil {
 				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
@@ -572,7 +642,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			}
 		}
 	case driver.Put:
-		err = s.Put(ctx, parentDir, file, up)
+		err = s.Put(withAccounted(ctx), parentDir, file, up)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
@@ -580,7 +650,20 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		return errs.NotImplement
 	}
 	log.Debugf("put file [%s] done", file.GetName())
-	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
+	if err == nil {
+		size := file.GetSize()
+		if counter != nil {
+			size = counter.n
+		}
+		if renameAside {
+			// the old obj will be removed below, which takes its size back,
+			// a version of it still takes the space, so the full size is added
+			addUsage(ctx, size)
+		} else {
+			addUsage(ctx, size-oldSize)
+		}
+	}
+	if renameAside && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
 			err := Rename(ctx, storage, tempPath, file.GetName())
@@ -588,8 +671,13 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 				log.Errorf("failed recover old obj: %+v", err)
 			}
 		} else {
-			// upload success, remove old obj
-			err := Remove(ctx, storage, tempPath)
+			// upload success, remove old obj or keep it as a version
+			var err error
+			if versioning != nil {
+				err = archiveVersion(ctx, storage, dstDirPath, tempName, file.GetName(), versioning)
+			} else {
+				err = Remove(ctx, storage, tempPath)
+			}
 			if err != nil {
 				return err
 			} else {
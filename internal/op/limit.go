@@ -0,0 +1,50 @@
+package op
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+var limitProfileCache = cache.NewMemCache(cache.WithShards[*model.LimitProfile](2))
+
+// GetLimitProfileById is read by every transfer, so it's cached
+func GetLimitProfileById(id uint) (*model.LimitProfile, error) {
+	key := strconv.FormatUint(uint64(id), 10)
+	if p, ok := limitProfileCache.Get(key); ok {
+		return p, nil
+	}
+	p, err := db.GetLimitProfileById(id)
+	if err != nil {
+		return nil, err
+	}
+	limitProfileCache.Set(key, p, cache.WithEx[*model.LimitProfile](time.Hour))
+	return p, nil
+}
+
+func GetLimitProfiles() ([]model.LimitProfile, error) {
+	return db.GetLimitProfiles()
+}
+
+func CreateLimitProfile(p *model.LimitProfile) error {
+	return db.CreateLimitProfile(p)
+}
+
+func UpdateLimitProfile(p *model.LimitProfile) error {
+	limitProfileCache.Del(strconv.FormatUint(uint64(p.ID), 10))
+	return db.UpdateLimitProfile(p)
+}
+
+func DeleteLimitProfileById(id uint) error {
+	limitProfileCache.Del(strconv.FormatUint(uint64(id), 10))
+	if err := db.DeleteLimitProfileById(id); err != nil {
+		return err
+	}
+	// the users attached to it are changed
+	userCache.Clear()
+	adminUser, guestUser = nil, nil
+	return nil
+}
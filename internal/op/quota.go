@@ -0,0 +1,125 @@
+package op
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func GetUserUsage(userID uint) (*model.UserUsage, error) {
+	return db.GetUserUsage(userID)
+}
+
+func SetUserUsage(userID uint, used int64) error {
+	return db.SetUserUsage(userID, used)
+}
+
+// CheckQuota returns errs.QuotaExceeded if the user in ctx
+// can not store size more bytes
+func CheckQuota(ctx context.Context, size int64) error {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok || !user.HasQuota() || size <= 0 || accounted(ctx) {
+		return nil
+	}
+	usage, err := db.GetUserUsage(user.ID)
+	if err != nil {
+		return err
+	}
+	if usage.Used+size > user.Quota {
+		return errors.WithStack(errs.QuotaExceeded)
+	}
+	return nil
+}
+
+// addUsage accounts delta bytes to the user in ctx,
+// failures are only logged since the write itself has succeeded
+func addUsage(ctx context.Context, delta int64) {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok || delta == 0 || accounted(ctx) {
+		return
+	}
+	if err := db.AddUserUsage(user.ID, delta); err != nil {
+		log.Errorf("failed update usage of user [%s]: %+v", user.Username, err)
+	}
+}
+
+// usageOf returns the bytes the obj accounts for, the size of a folder is usually unknown,
+// so the files in it are summed up. Folders are only walked if the usage is to be accounted
+func usageOf(ctx context.Context, storage driver.Driver, path string, obj model.Obj) int64 {
+	if !obj.IsDir() {
+		return obj.GetSize()
+	}
+	if _, ok := ctx.Value("user").(*model.User); !ok || accounted(ctx) {
+		return 0
+	}
+	objs, err := List(ctx, storage, path, model.ListArgs{})
+	if err != nil {
+		log.Warnf("failed list %s to account its usage: %+v", path, err)
+		return 0
+	}
+	var size int64
+	for _, o := range objs {
+		size += usageOf(ctx, storage, stdpath.Join(path, o.GetName()), o)
+	}
+	return size
+}
+
+type accountedKey struct{}
+
+// withAccounted marks the usage of the operation as accounted, the wrapper drivers
+// pass the ctx to the operations on the storages they wrap, which must not count it again
+func withAccounted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, accountedKey{}, true)
+}
+
+func accounted(ctx context.Context) bool {
+	return ctx.Value(accountedKey{}) != nil
+}
+
+// countReader counts the bytes of an upload whose size is unknown in advance,
+// the reading fails once they exceed the remaining quota if limit >= 0
+type countReader struct {
+	io.Reader
+	limit int64
+	n     int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	if r.limit >= 0 && r.n > r.limit {
+		return n, errors.WithStack(errs.QuotaExceeded)
+	}
+	return n, err
+}
+
+// countStream wraps the reader of a stream of unknown size with a countReader,
+// nil is returned if the size is known or the usage needn't be accounted
+func countStream(ctx context.Context, file model.FileStreamer) (*countReader, error) {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok || file.GetSize() >= 0 || accounted(ctx) {
+		return nil, nil
+	}
+	fs, ok := file.(*stream.FileStream)
+	if !ok {
+		return nil, nil
+	}
+	r := &countReader{Reader: fs.Reader, limit: -1}
+	if user.HasQuota() {
+		usage, err := db.GetUserUsage(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		r.limit = max(user.Quota-usage.Used, 0)
+	}
+	fs.Reader = r
+	return r, nil
+}
@@ -0,0 +1,53 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestRemoveFolderUsage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a", "b"), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{"a/x": 10, "a/b/y": 20, "z": 5} {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/usage",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"recycle_bin_path":"delete permanently"}`, dir),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(context.Background(), id)
+	})
+	storage, err := op.GetStorageByMountPath("/usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 700, Username: "usage"}
+	if err = op.SetUserUsage(user.ID, 35); err != nil {
+		t.Fatal(err)
+	}
+	if err = op.Remove(context.WithValue(context.Background(), "user", user), storage, "/a"); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := op.GetUserUsage(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Used != 5 {
+		t.Errorf("expected the files in the folder released, the usage is %d", usage.Used)
+	}
+}
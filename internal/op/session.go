@@ -0,0 +1,106 @@
+package op
+
+import (
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// sessionCacheExpiration bounds how long a session revoked by another replica is still accepted here
+const sessionCacheExpiration = time.Minute
+
+// the session of a token is checked on every request, so it's not read from the db every time
+var sessionCache = cache.NewMemCache(cache.WithShards[*model.Session](16))
+var sessionG singleflight.Group[*model.Session]
+
+// CreateSession stores the session of a new login token,
+// the expired sessions of the user are purged at the same time
+func CreateSession(s *model.Session) error {
+	if err := db.DeleteExpiredSessions(s.UserID); err != nil {
+		log.Warnf("failed delete expired sessions of user %d: %+v", s.UserID, err)
+	}
+	now := time.Now()
+	s.CreatedAt = now
+	s.LastSeenAt = now
+	return db.CreateSession(s)
+}
+
+// GetSession returns the session if it is still valid
+func GetSession(id string) (*model.Session, error) {
+	s, ok := sessionCache.Get(id)
+	if !ok {
+		var err error
+		s, err, _ = sessionG.Do(id, func() (*model.Session, error) {
+			s, err := db.GetSessionById(id)
+			if err != nil {
+				return nil, err
+			}
+			sessionCache.Set(id, s, cache.WithEx[*model.Session](sessionCacheExpiration))
+			return s, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.IsExpired() {
+		return nil, errors.New("session is expired")
+	}
+	return s, nil
+}
+
+// TouchSession records the activity of the session
+func TouchSession(s *model.Session, ip string) {
+	// avoid writing the db on every request
+	now := time.Now()
+	if now.Sub(s.LastSeenAt) <= time.Minute && s.IP == ip {
+		return
+	}
+	if err := db.UpdateSessionSeen(s.ID, now, ip); err != nil {
+		log.Warnf("failed update last seen of session %s: %+v", s.ID, err)
+		return
+	}
+	// the cached copy is replaced, it's shared by the requests, and keeps its expiration
+	// so that a revocation by another replica is still seen in time
+	if ttl, ok := sessionCache.Ttl(s.ID); ok && ttl > 0 {
+		seen := *s
+		seen.LastSeenAt, seen.IP = now, ip
+		sessionCache.Set(s.ID, &seen, cache.WithEx[*model.Session](ttl))
+	}
+}
+
+func GetSessionsByUserId(userId uint) ([]model.Session, error) {
+	return db.GetSessionsByUserId(userId)
+}
+
+func GetSessionByIdAndUserId(id string, userId uint) (*model.Session, error) {
+	s, err := db.GetSessionById(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.UserID != userId {
+		return nil, errors.New("session not found")
+	}
+	return s, nil
+}
+
+func DeleteSessionById(id string) error {
+	sessionCache.Del(id)
+	return db.DeleteSessionById(id)
+}
+
+// DeleteSessionsByUserId logs out the user everywhere
+func DeleteSessionsByUserId(userId uint) error {
+	sessions, err := db.GetSessionsByUserId(userId)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		sessionCache.Del(s.ID)
+	}
+	return db.DeleteSessionsByUserId(userId)
+}
@@ -0,0 +1,27 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestSessionCache(t *testing.T) {
+	s := &model.Session{ID: "cached", UserID: 400, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := op.CreateSession(s); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := op.GetSession(s.ID); err != nil {
+			t.Fatalf("failed get session: %+v", err)
+		}
+	}
+	if err := op.DeleteSessionsByUserId(s.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := op.GetSession(s.ID); err == nil {
+		t.Error("a revoked session is still cached")
+	}
+}
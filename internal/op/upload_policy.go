@@ -0,0 +1,153 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/clamd"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// sniffLen is the number of bytes used by http.DetectContentType
+const sniffLen = 512
+
+// CheckUploadPolicy checks the file against the upload policy of the nearest meta
+func CheckUploadPolicy(ctx context.Context, storage driver.Driver, dstDirPath string, file model.FileStreamer) error {
+	dir := stdpath.Join(storage.GetStorage().MountPath, dstDirPath)
+	meta, err := GetNearestMeta(dir)
+	if err != nil {
+		if errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !meta.HasUploadPolicy() || !(utils.PathEqual(meta.Path, dir) || meta.USub && utils.IsSubPath(meta.Path, dir)) {
+		return nil
+	}
+	name := file.GetName()
+	if meta.MaxSize > 0 && file.GetSize() > meta.MaxSize {
+		return errors.WithStack(fmt.Errorf("%w: %s is larger than %d bytes", errs.UploadRejected, name, meta.MaxSize))
+	}
+	// the size of a chunked upload is only known once it's read
+	if fs, ok := file.(*stream.FileStream); ok && meta.MaxSize > 0 && file.GetSize() < 0 {
+		fs.Reader = &maxSizeReader{Reader: fs.Reader, name: name, max: meta.MaxSize}
+	}
+	ext := utils.Ext(name)
+	if meta.AllowExt != "" && !matchList(meta.AllowExt, ext, matchExt) {
+		return errors.WithStack(fmt.Errorf("%w: extension of %s is not allowed", errs.UploadRejected, name))
+	}
+	if matchList(meta.DenyExt, ext, matchExt) {
+		return errors.WithStack(fmt.Errorf("%w: extension of %s is denied", errs.UploadRejected, name))
+	}
+	if meta.AllowMime != "" || meta.DenyMime != "" {
+		mimetype, err := sniffMimetype(file)
+		if err != nil {
+			return errors.WithMessage(err, "failed to sniff the mimetype")
+		}
+		if meta.AllowMime != "" && !matchList(meta.AllowMime, mimetype, matchMime) {
+			return errors.WithStack(fmt.Errorf("%w: %s of %s is not allowed", errs.UploadRejected, mimetype, name))
+		}
+		if matchList(meta.DenyMime, mimetype, matchMime) {
+			return errors.WithStack(fmt.Errorf("%w: %s of %s is denied", errs.UploadRejected, mimetype, name))
+		}
+	}
+	if meta.VirusScan {
+		return scanVirus(ctx, file)
+	}
+	return nil
+}
+
+func matchList(list, value string, match func(pattern, value string) bool) bool {
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern != "" && match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchExt(pattern, ext string) bool {
+	return strings.TrimPrefix(pattern, ".") == ext
+}
+
+func matchMime(pattern, mimetype string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mimetype, prefix+"/")
+	}
+	return pattern == mimetype
+}
+
+// maxSizeReader fails the reading once more than max bytes are read
+type maxSizeReader struct {
+	io.Reader
+	name string
+	max  int64
+	n    int64
+}
+
+func (r *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	if r.n > r.max {
+		return n, errors.WithStack(fmt.Errorf("%w: %s is larger than %d bytes", errs.UploadRejected, r.name, r.max))
+	}
+	return n, err
+}
+
+// sniffMimetype detects the mimetype of the first bytes, the stream can still be read from the start
+func sniffMimetype(file model.FileStreamer) (string, error) {
+	// a stream of unknown size can't be peeked
+	if file.GetSize() < 0 {
+		if _, err := file.CacheFullInTempFile(); err != nil {
+			return "", err
+		}
+	}
+	r, err := file.RangeRead(http_range.Range{Length: min(sniffLen, file.GetSize())})
+	if err != nil {
+		return "", err
+	}
+	head, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	mimetype, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "", err
+	}
+	return mimetype, nil
+}
+
+func scanVirus(ctx context.Context, file model.FileStreamer) error {
+	item, err := GetSettingItemByKey(conf.ClamdAddress)
+	if err != nil || item.Value == "" {
+		return errors.WithStack(fmt.Errorf("%w: virus scan is required but clamd is not configured", errs.UploadRejected))
+	}
+	// clamd needs the whole content before the upload starts
+	f, err := file.CacheFullInTempFile()
+	if err != nil {
+		return err
+	}
+	virus, err := clamd.Scan(ctx, item.Value, io.NewSectionReader(f, 0, file.GetSize()))
+	if err != nil {
+		return errors.WithMessage(err, "failed to scan virus")
+	}
+	if virus != "" {
+		log.Warnf("rejected upload [%s]: %s found", file.GetName(), virus)
+		return errors.WithStack(fmt.Errorf("%w: %s found in %s", errs.UploadRejected, virus, file.GetName()))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package op_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+)
+
+func TestCheckUploadPolicy(t *testing.T) {
+	err := op.CreateMeta(&model.Meta{
+		Path:      "/policy",
+		AllowExt:  "png,.txt",
+		DenyMime:  "application/x-msdownload",
+		MaxSize:   1024,
+		USub:      true,
+		AllowMime: "image/*, text/plain",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := &local.Local{}
+	storage.MountPath = "/policy"
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	var cases = []struct {
+		dir, name string
+		data      []byte
+		ok        bool
+	}{
+		{"/sub", "a.png", png, true},
+		{"/", "a.txt", []byte("hello"), true},
+		{"/", "a.jpg", png, false},
+		// the content doesn't match the allowed mimetypes
+		{"/", "a.png", []byte("MZ\x90\x00"), false},
+		{"/", "a.txt", bytes.Repeat([]byte("a"), 2048), false},
+	}
+	for _, c := range cases {
+		file := &stream.FileStream{
+			Obj:    &model.Object{Name: c.name, Size: int64(len(c.data))},
+			Reader: bytes.NewReader(c.data),
+		}
+		err := op.CheckUploadPolicy(context.Background(), storage, c.dir, file)
+		if c.ok != (err == nil) || err != nil && !errors.Is(err, errs.UploadRejected) {
+			t.Errorf("%s/%s: unexpected result %v", c.dir, c.name, err)
+		}
+	}
+	// the size of a chunked upload is unknown, it's checked while being read
+	conf.Conf.TempDir = t.TempDir()
+	file := &stream.FileStream{
+		Obj:    &model.Object{Name: "b.txt", Size: -1},
+		Reader: bytes.NewReader(bytes.Repeat([]byte("a"), 2048)),
+	}
+	err = op.CheckUploadPolicy(context.Background(), storage, "/", file)
+	if err == nil {
+		_, err = io.ReadAll(file)
+	}
+	if !errors.Is(err, errs.UploadRejected) {
+		t.Errorf("b.txt of unknown size: unexpected result %v", err)
+	}
+}
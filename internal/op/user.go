@@ -82,6 +82,15 @@ func DeleteUserById(id uint) error {
 		return errs.DeleteAdminOrGuest
 	}
 	userCache.Del(old.Username)
+	if err := db.DeleteUserUsage(id); err != nil {
+		return err
+	}
+	if err := db.DeleteAPITokensByUserId(id); err != nil {
+		return err
+	}
+	if err := DeleteSessionsByUserId(id); err != nil {
+		return err
+	}
 	return db.DeleteUserById(id)
 }
 
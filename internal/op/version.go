@@ -0,0 +1,171 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"sort"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// VersionsDirName is the hidden folder keeping the old versions of the files in a folder,
+// the versions of a file are stored as .versions/<name>/<timestamp>
+const VersionsDirName = ".versions"
+
+// versionIDFormat is sortable, so the latest version has the largest id
+const versionIDFormat = "20060102-150405.000000000"
+
+// isVersionPath reports whether path is in a versions folder
+func isVersionPath(path string) bool {
+	for dir := path; dir != "/" && dir != "."; dir = stdpath.Dir(dir) {
+		if stdpath.Base(dir) == VersionsDirName {
+			return true
+		}
+	}
+	return false
+}
+
+// versioningMeta returns the meta enabling versioning for the file of the actual path
+func versioningMeta(storage driver.Driver, path string) *model.Meta {
+	if isVersionPath(path) {
+		return nil
+	}
+	dir := stdpath.Join(storage.GetStorage().MountPath, stdpath.Dir(path))
+	meta, err := GetNearestMeta(dir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			log.Warnf("failed get meta of %s: %+v", dir, err)
+		}
+		return nil
+	}
+	if !meta.Versioning || !(utils.PathEqual(meta.Path, dir) || meta.VSub && utils.IsSubPath(meta.Path, dir)) {
+		return nil
+	}
+	return meta
+}
+
+func versionsDir(path string) string {
+	dir, name := stdpath.Split(path)
+	return stdpath.Join(dir, VersionsDirName, name)
+}
+
+// archiveVersion moves the file srcName in dirPath into the versions of the file name,
+// then applies the retention of meta
+func archiveVersion(ctx context.Context, storage driver.Driver, dirPath, srcName, name string, meta *model.Meta) error {
+	vDir := versionsDir(stdpath.Join(dirPath, name))
+	if err := MakeDir(ctx, storage, vDir); err != nil {
+		return errors.WithMessage(err, "failed to make versions dir")
+	}
+	srcPath := stdpath.Join(dirPath, srcName)
+	err := Move(ctx, storage, srcPath, vDir)
+	if errs.IsNotImplement(err) {
+		// keep a copy instead, the original is removed without being archived again
+		if err = Copy(ctx, storage, srcPath, vDir); err == nil {
+			err = remove(ctx, storage, srcPath)
+		}
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed to archive version")
+	}
+	id := time.Now().UTC().Format(versionIDFormat)
+	if err = Rename(ctx, storage, stdpath.Join(vDir, srcName), id); err != nil {
+		return errors.WithMessage(err, "failed to name version")
+	}
+	pruneVersions(ctx, storage, vDir, meta)
+	return nil
+}
+
+// pruneVersions removes the versions beyond the count or the age kept by meta
+func pruneVersions(ctx context.Context, storage driver.Driver, vDir string, meta *model.Meta) {
+	if meta.VersionCount <= 0 && meta.VersionDays <= 0 {
+		return
+	}
+	versions, err := listVersions(ctx, storage, vDir)
+	if err != nil {
+		log.Warnf("failed list versions of %s: %+v", vDir, err)
+		return
+	}
+	expire := time.Now().AddDate(0, 0, -meta.VersionDays)
+	for i, v := range versions {
+		if meta.VersionCount > 0 && i >= meta.VersionCount || meta.VersionDays > 0 && v.ModTime().Before(expire) {
+			if err = remove(ctx, storage, stdpath.Join(vDir, v.GetName())); err != nil {
+				log.Warnf("failed remove version %s of %s: %+v", v.GetName(), vDir, err)
+			}
+		}
+	}
+}
+
+// listVersions lists the versions in vDir, the latest first,
+// the modified time of a version is the time it was replaced
+func listVersions(ctx context.Context, storage driver.Driver, vDir string) ([]model.Obj, error) {
+	objs, err := List(ctx, storage, vDir, model.ListArgs{})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	versions := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		t, err := time.Parse(versionIDFormat, obj.GetName())
+		if err != nil || obj.IsDir() {
+			continue
+		}
+		versions = append(versions, &model.Object{
+			ID:       obj.GetID(),
+			Path:     obj.GetPath(),
+			Name:     obj.GetName(),
+			Size:     obj.GetSize(),
+			Modified: t,
+			HashInfo: obj.GetHash(),
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].GetName() > versions[j].GetName()
+	})
+	return versions, nil
+}
+
+// ListVersions lists the old versions of the file of the actual path, the latest first
+func ListVersions(ctx context.Context, storage driver.Driver, path string) ([]model.Obj, error) {
+	path = utils.FixAndCleanPath(path)
+	return listVersions(ctx, storage, versionsDir(path))
+}
+
+// versionPath returns the actual path of a version of the file
+func versionPath(path, id string) string {
+	return stdpath.Join(versionsDir(utils.FixAndCleanPath(path)), id)
+}
+
+// RestoreVersion makes a version the current content of the file,
+// the current content becomes a version if versioning is still enabled
+func RestoreVersion(ctx context.Context, storage driver.Driver, path, id string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	path = utils.FixAndCleanPath(path)
+	if _, err := time.Parse(versionIDFormat, id); err != nil {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	vPath := versionPath(path, id)
+	if _, err := Get(ctx, storage, vPath); err != nil {
+		return errors.WithMessage(err, "failed to get version")
+	}
+	dirPath, name := stdpath.Split(path)
+	if _, err := Get(ctx, storage, path); err == nil {
+		// Remove archives it if versioning is enabled
+		if err = Remove(ctx, storage, path); err != nil {
+			return errors.WithMessage(err, "failed to replace current version")
+		}
+	}
+	if err := Move(ctx, storage, vPath, dirPath); err != nil {
+		return errors.WithMessage(err, "failed to move version")
+	}
+	return Rename(ctx, storage, stdpath.Join(dirPath, id), name)
+}
@@ -0,0 +1,70 @@
+package op_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+)
+
+func TestVersioning(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/versioned",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"mkdir_perm":"777","recycle_bin_path":"delete permanently"}`, dir),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	if err = op.CreateMeta(&model.Meta{Path: "/versioned", Versioning: true, VersionCount: 2}); err != nil {
+		t.Fatal(err)
+	}
+	storage, err := op.GetStorageByMountPath("/versioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(content string) {
+		err := op.Put(ctx, storage, "/", &stream.FileStream{
+			Obj:    &model.Object{Name: "a.txt", Size: int64(len(content)), Modified: time.Now()},
+			Reader: bytes.NewReader([]byte(content)),
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, content := range []string{"v1", "v2", "v3", "v4"} {
+		put(content)
+	}
+	versions, err := op.ListVersions(ctx, storage, "/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// v1 is beyond the count kept
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	if err = op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(dir + "/a.txt"); !os.IsNotExist(err) {
+		t.Fatalf("expected a.txt removed, got %v", err)
+	}
+	versions, _ = op.ListVersions(ctx, storage, "/a.txt")
+	if err = op.RestoreVersion(ctx, storage, "/a.txt", versions[0].GetName()); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(dir + "/a.txt"); string(b) != "v4" {
+		t.Errorf("expected v4 restored, got %q", b)
+	}
+}
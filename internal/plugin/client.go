@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/plugin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type pipe struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (p pipe) Close() error {
+	err1 := p.WriteCloser.Close()
+	err2 := p.ReadCloser.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// client is a running plugin process
+type client struct {
+	name string
+	cmd  *exec.Cmd
+	rpc  *rpc.Client
+}
+
+func start(path string) (*client, error) {
+	cmd := exec.Command(path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(path)
+	cmd.Stderr = log.WithField("plugin", name).WriterLevel(log.WarnLevel)
+	if err = cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "failed start plugin %s", name)
+	}
+	return &client{
+		name: name,
+		cmd:  cmd,
+		rpc:  rpc.NewClientWithCodec(jsonrpc.NewClientCodec(pipe{ReadCloser: stdout, WriteCloser: stdin})),
+	}, nil
+}
+
+// errNotRunning is returned by the calls after the plugin is dropped or failed to start
+var errNotRunning = errors.New("plugin is not running")
+
+// call calls the method of the plugin, it returns as soon as ctx is done,
+// the reply of the abandoned call is dropped by rpc.Client
+func (c *client) call(ctx context.Context, method string, args, reply any) error {
+	if c == nil {
+		return errors.WithStack(errNotRunning)
+	}
+	call := c.rpc.Go(plugin.ServiceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-call.Done:
+		return convertErr(call.Error)
+	}
+}
+
+// close closes the pipes and waits a while for the plugin to exit before killing it
+func (c *client) close() {
+	_ = c.rpc.Close()
+	if c.cmd == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = c.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		log.Warnf("plugin %s doesn't exit in time, kill it", c.name)
+		_ = c.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// convertErr turns the error strings of the plugin back to the errors of errs package
+func convertErr(err error) error {
+	var serverErr rpc.ServerError
+	if !errors.As(err, &serverErr) {
+		return err
+	}
+	if strings.Contains(string(serverErr), plugin.ErrNotImplement.Error()) {
+		return errors.WithStack(errs.NotImplement)
+	}
+	return errors.New(string(serverErr))
+}
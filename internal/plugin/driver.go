@@ -0,0 +1,215 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/plugin"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Plugin proxies a plugin process as a driver, every storage runs its own process
+type Plugin struct {
+	model.Storage
+	addition map[string]any
+
+	path   string
+	start  func(path string) (*client, error)
+	info   plugin.InfoReply
+	client *client
+	root   plugin.Obj
+}
+
+func (d *Plugin) Config() driver.Config {
+	c := d.info.Config
+	return driver.Config{
+		Name:        c.Name,
+		LocalSort:   c.LocalSort,
+		OnlyProxy:   c.OnlyProxy,
+		NoCache:     c.NoCache,
+		NoUpload:    c.NoUpload || !d.info.Capabilities.Put,
+		DefaultRoot: c.DefaultRoot,
+		Alert:       c.Alert,
+	}
+}
+
+func (d *Plugin) GetAddition() driver.Additional {
+	return &d.addition
+}
+
+func (d *Plugin) Init(ctx context.Context) error {
+	if d.client != nil {
+		d.client.close()
+		d.client = nil
+	}
+	c, err := d.start(d.path)
+	if err != nil {
+		return err
+	}
+	d.client = c
+	addition, err := utils.Json.MarshalToString(d.addition)
+	if err != nil {
+		return err
+	}
+	var reply plugin.InitReply
+	if err = c.call(ctx, "Init", plugin.InitArgs{Addition: addition}, &reply); err != nil {
+		return err
+	}
+	d.root = reply.Root
+	if reply.Addition != "" && reply.Addition != addition {
+		if err = utils.Json.UnmarshalFromString(reply.Addition, &d.addition); err != nil {
+			return errors.Wrap(err, "failed unmarshal addition replied by plugin")
+		}
+		op.MustSaveDriverStorage(d)
+	}
+	return nil
+}
+
+func (d *Plugin) Drop(ctx context.Context) error {
+	if d.client == nil {
+		return nil
+	}
+	err := d.client.call(ctx, "Drop", plugin.Empty{}, &plugin.Empty{})
+	d.client.close()
+	d.client = nil
+	return err
+}
+
+func (d *Plugin) GetRoot(ctx context.Context) (model.Obj, error) {
+	return toObj(d.root), nil
+}
+
+func (d *Plugin) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	var reply plugin.ListReply
+	if err := d.client.call(ctx, "List", plugin.ListArgs{Dir: fromObj(dir)}, &reply); err != nil {
+		return nil, err
+	}
+	return utils.SliceConvert(reply.Objs, func(src plugin.Obj) (model.Obj, error) {
+		return toObj(src), nil
+	})
+}
+
+func (d *Plugin) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	var reply plugin.LinkReply
+	err := d.client.call(ctx, "Link", plugin.LinkArgs{
+		File:   fromObj(file),
+		IP:     args.IP,
+		Header: args.Header,
+	}, &reply)
+	if err != nil {
+		return nil, err
+	}
+	link := &model.Link{
+		URL:    reply.URL,
+		Header: reply.Header,
+	}
+	if reply.Expiration > 0 {
+		exp := time.Duration(reply.Expiration) * time.Second
+		link.Expiration = &exp
+	}
+	if reply.Stream {
+		link.URL = ""
+		f := fromObj(file)
+		link.RangeReadCloser = &model.RangeReadCloser{
+			RangeReader: func(ctx context.Context, r http_range.Range) (io.ReadCloser, error) {
+				length := r.Length
+				if length < 0 {
+					length = file.GetSize() - r.Start
+				}
+				return io.NopCloser(&reader{ctx: ctx, c: d.client, file: f, offset: r.Start, remain: length}), nil
+			},
+		}
+	}
+	return link, nil
+}
+
+func (d *Plugin) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) (model.Obj, error) {
+	var reply plugin.ObjReply
+	err := d.client.call(ctx, "MakeDir", plugin.MakeDirArgs{ParentDir: fromObj(parentDir), Name: dirName}, &reply)
+	return toObjPtr(reply.Obj), err
+}
+
+func (d *Plugin) Move(ctx context.Context, srcObj, dstDir model.Obj) (model.Obj, error) {
+	var reply plugin.ObjReply
+	err := d.client.call(ctx, "Move", plugin.MoveArgs{Src: fromObj(srcObj), DstDir: fromObj(dstDir)}, &reply)
+	return toObjPtr(reply.Obj), err
+}
+
+func (d *Plugin) Rename(ctx context.Context, srcObj model.Obj, newName string) (model.Obj, error) {
+	var reply plugin.ObjReply
+	err := d.client.call(ctx, "Rename", plugin.RenameArgs{Src: fromObj(srcObj), NewName: newName}, &reply)
+	return toObjPtr(reply.Obj), err
+}
+
+func (d *Plugin) Copy(ctx context.Context, srcObj, dstDir model.Obj) (model.Obj, error) {
+	var reply plugin.ObjReply
+	err := d.client.call(ctx, "Copy", plugin.MoveArgs{Src: fromObj(srcObj), DstDir: fromObj(dstDir)}, &reply)
+	return toObjPtr(reply.Obj), err
+}
+
+func (d *Plugin) Remove(ctx context.Context, obj model.Obj) error {
+	return d.client.call(ctx, "Remove", plugin.RemoveArgs{Obj: fromObj(obj)}, &plugin.Empty{})
+}
+
+func (d *Plugin) Put(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (model.Obj, error) {
+	var started plugin.PutStartReply
+	err := d.client.call(ctx, "PutStart", plugin.PutStartArgs{
+		DstDir:   fromObj(dstDir),
+		Name:     file.GetName(),
+		Size:     file.GetSize(),
+		Modified: file.ModTime(),
+		Mimetype: file.GetMimetype(),
+	}, &started)
+	if err != nil {
+		return nil, err
+	}
+	end := plugin.PutEndArgs{UploadID: started.UploadID}
+	abort := func() {
+		// ctx may have been canceled, the abort must still reach the plugin
+		_ = d.client.call(context.Background(), "PutAbort", end, &plugin.Empty{})
+	}
+	reader := driver.NewLimitedUploadStream(ctx, file)
+	buf := make([]byte, plugin.ChunkSize)
+	var done int64
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 {
+			if err := d.client.call(ctx, "PutChunk", plugin.PutChunkArgs{UploadID: started.UploadID, Data: buf[:n]}, &plugin.Empty{}); err != nil {
+				abort()
+				return nil, err
+			}
+			done += int64(n)
+			if file.GetSize() > 0 {
+				up(float64(done) / float64(file.GetSize()) * 100)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			abort()
+			return nil, err
+		}
+	}
+	var reply plugin.ObjReply
+	if err = d.client.call(ctx, "PutFinish", end, &reply); err != nil {
+		abort()
+		return nil, err
+	}
+	return toObjPtr(reply.Obj), nil
+}
+
+var _ driver.Driver = (*Plugin)(nil)
+var _ driver.GetRooter = (*Plugin)(nil)
+var _ driver.MkdirResult = (*Plugin)(nil)
+var _ driver.MoveResult = (*Plugin)(nil)
+var _ driver.RenameResult = (*Plugin)(nil)
+var _ driver.CopyResult = (*Plugin)(nil)
+var _ driver.Remove = (*Plugin)(nil)
+var _ driver.PutResult = (*Plugin)(nil)
@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/plugin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Load registers every executable in dir as a driver
+func Load(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed read plugin dir %s: %+v", dir, err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := register(path); err != nil {
+			log.Errorf("failed load plugin %s: %+v", entry.Name(), err)
+		}
+	}
+}
+
+func register(path string) error {
+	info, err := probe(path)
+	if err != nil {
+		return err
+	}
+	if info.ProtocolVersion != plugin.ProtocolVersion {
+		return fmt.Errorf("protocol version %d is not supported, expect %d", info.ProtocolVersion, plugin.ProtocolVersion)
+	}
+	if info.Config.Name == "" {
+		return errors.New("plugin replies an empty driver name")
+	}
+	if _, err := op.GetDriver(info.Config.Name); err == nil {
+		return fmt.Errorf("driver named %s already exists", info.Config.Name)
+	}
+	constructor := func() driver.Driver {
+		return &Plugin{path: path, start: start, info: *info, addition: map[string]any{}}
+	}
+	c := info.Capabilities
+	op.RegisterDriverInfo(constructor, driver.Info{
+		Additional: toItems(info.Additional),
+		Config:     constructor().Config(),
+		Capabilities: driver.Capabilities{
+			GetRooter:    true,
+			MkdirResult:  c.MakeDir,
+			MoveResult:   c.Move,
+			RenameResult: c.Rename,
+			CopyResult:   c.Copy,
+			Remove:       c.Remove,
+			PutResult:    c.Put,
+		},
+	})
+	log.Infof("loaded plugin driver [%s] from %s", info.Config.Name, path)
+	return nil
+}
+
+// probe starts the plugin only to ask for its info
+func probe(path string) (*plugin.InfoReply, error) {
+	c, err := start(path)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var info plugin.InfoReply
+	if err = c.call(ctx, "Info", plugin.Empty{}, &info); err != nil {
+		return nil, errors.WithMessage(err, "failed get info")
+	}
+	return &info, nil
+}
+
+func toItems(items []plugin.Item) []driver.Item {
+	res := make([]driver.Item, 0, len(items))
+	for _, item := range items {
+		if item.Type == "" {
+			item.Type = "string"
+		}
+		res = append(res, driver.Item(item))
+	}
+	return res
+}
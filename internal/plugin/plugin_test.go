@@ -0,0 +1,210 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	stdpath "path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/driver/drivertest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/plugin"
+)
+
+// memPlugin is an in-memory plugin identifying objs by path
+type memPlugin struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	dirs  map[string]bool
+}
+
+func (m *memPlugin) Info() plugin.InfoReply {
+	return plugin.InfoReply{
+		Config:     plugin.Config{Name: "MemPlugin", NoCache: true},
+		Additional: []plugin.Item{{Name: "token", Required: true}},
+		Capabilities: plugin.Capabilities{
+			MakeDir: true, Move: true, Rename: true, Copy: true, Remove: true, Put: true,
+		},
+	}
+}
+
+func (m *memPlugin) Init(addition string) (*plugin.InitReply, error) {
+	if !strings.Contains(addition, "secret") {
+		return nil, errors.New("token is required")
+	}
+	m.files = map[string][]byte{}
+	m.dirs = map[string]bool{"/": true}
+	return &plugin.InitReply{Root: plugin.Obj{ID: "/", Path: "/", IsFolder: true}}, nil
+}
+
+func (m *memPlugin) Drop() error {
+	return nil
+}
+
+func (m *memPlugin) obj(path string) plugin.Obj {
+	data, ok := m.files[path]
+	return plugin.Obj{ID: path, Path: path, Name: stdpath.Base(path), Size: int64(len(data)), IsFolder: !ok, Modified: time.Now()}
+}
+
+func (m *memPlugin) List(dir plugin.Obj) ([]plugin.Obj, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []plugin.Obj
+	for p := range m.files {
+		if stdpath.Dir(p) == dir.Path {
+			res = append(res, m.obj(p))
+		}
+	}
+	for p := range m.dirs {
+		if p != "/" && stdpath.Dir(p) == dir.Path {
+			res = append(res, m.obj(p))
+		}
+	}
+	return res, nil
+}
+
+func (m *memPlugin) Link(args plugin.LinkArgs) (*plugin.LinkReply, error) {
+	return &plugin.LinkReply{Stream: true}, nil
+}
+
+func (m *memPlugin) Read(file plugin.Obj, offset, length int64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := m.files[file.Path]
+	if offset >= int64(len(data)) {
+		return nil, io.EOF
+	}
+	end := min(offset+length, int64(len(data)))
+	return append([]byte(nil), data[offset:end]...), nil
+}
+
+func (m *memPlugin) MakeDir(parentDir plugin.Obj, name string) (*plugin.Obj, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := stdpath.Join(parentDir.Path, name)
+	m.dirs[p] = true
+	o := m.obj(p)
+	return &o, nil
+}
+
+// rebase moves src and everything under it to dst
+func (m *memPlugin) rebase(src, dst string, keep bool) {
+	for p, data := range m.files {
+		if p == src || strings.HasPrefix(p, src+"/") {
+			m.files[dst+strings.TrimPrefix(p, src)] = data
+			if !keep {
+				delete(m.files, p)
+			}
+		}
+	}
+	for p := range m.dirs {
+		if p == src || strings.HasPrefix(p, src+"/") {
+			m.dirs[dst+strings.TrimPrefix(p, src)] = true
+			if !keep {
+				delete(m.dirs, p)
+			}
+		}
+	}
+}
+
+func (m *memPlugin) Move(src, dstDir plugin.Obj) (*plugin.Obj, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dst := stdpath.Join(dstDir.Path, src.Name)
+	m.rebase(src.Path, dst, false)
+	o := m.obj(dst)
+	return &o, nil
+}
+
+func (m *memPlugin) Rename(src plugin.Obj, newName string) (*plugin.Obj, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dst := stdpath.Join(stdpath.Dir(src.Path), newName)
+	m.rebase(src.Path, dst, false)
+	o := m.obj(dst)
+	return &o, nil
+}
+
+func (m *memPlugin) Copy(src, dstDir plugin.Obj) (*plugin.Obj, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dst := stdpath.Join(dstDir.Path, src.Name)
+	m.rebase(src.Path, dst, true)
+	o := m.obj(dst)
+	return &o, nil
+}
+
+func (m *memPlugin) Remove(obj plugin.Obj) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for p := range m.files {
+		if p == obj.Path || strings.HasPrefix(p, obj.Path+"/") {
+			delete(m.files, p)
+		}
+	}
+	for p := range m.dirs {
+		if p == obj.Path || strings.HasPrefix(p, obj.Path+"/") {
+			delete(m.dirs, p)
+		}
+	}
+	return nil
+}
+
+func (m *memPlugin) Put(ctx context.Context, args plugin.PutStartArgs, r io.Reader) (*plugin.Obj, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := stdpath.Join(args.DstDir.Path, args.Name)
+	m.files[p] = data
+	o := m.obj(p)
+	return &o, nil
+}
+
+// startMem runs the plugin in process over a pipe instead of a child process
+func startMem(path string) (*client, error) {
+	hostConn, pluginConn := net.Pipe()
+	go func() {
+		_ = plugin.ServeConn(&memPlugin{}, pluginConn)
+	}()
+	return &client{name: path, rpc: rpc.NewClientWithCodec(jsonrpc.NewClientCodec(hostConn))}, nil
+}
+
+func TestPluginConformance(t *testing.T) {
+	info := (&memPlugin{}).Info()
+	drivertest.Run(t, drivertest.Options{
+		New: func(t *testing.T) driver.Driver {
+			d := &Plugin{path: "mem", start: startMem, info: info, addition: map[string]any{"token": "secret"}}
+			if err := d.Init(context.Background()); err != nil {
+				t.Fatalf("failed init plugin: %+v", err)
+			}
+			return d
+		},
+	})
+}
+
+func TestDropped(t *testing.T) {
+	d := &Plugin{path: "mem", start: startMem, info: (&memPlugin{}).Info(), addition: map[string]any{"token": "secret"}}
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Drop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.List(context.Background(), toObj(d.root), model.ListArgs{}); !errors.Is(err, errNotRunning) {
+		t.Errorf("list after drop: got %v, want %v", err, errNotRunning)
+	}
+}
@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"context"
+	"io"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/plugin"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func toObj(o plugin.Obj) model.Obj {
+	return &model.Object{
+		ID:       o.ID,
+		Path:     o.Path,
+		Name:     o.Name,
+		Size:     o.Size,
+		Modified: o.Modified,
+		Ctime:    o.Ctime,
+		IsFolder: o.IsFolder,
+		HashInfo: utils.FromString(o.Hash),
+	}
+}
+
+func toObjPtr(o *plugin.Obj) model.Obj {
+	if o == nil {
+		return nil
+	}
+	return toObj(*o)
+}
+
+func fromObj(o model.Obj) plugin.Obj {
+	o = model.UnwrapObj(o)
+	return plugin.Obj{
+		ID:       o.GetID(),
+		Path:     o.GetPath(),
+		Name:     o.GetName(),
+		Size:     o.GetSize(),
+		Modified: o.ModTime(),
+		Ctime:    o.CreateTime(),
+		IsFolder: o.IsDir(),
+		Hash:     o.GetHash().String(),
+	}
+}
+
+// reader streams a file from the plugin chunk by chunk
+type reader struct {
+	ctx    context.Context
+	c      *client
+	file   plugin.Obj
+	offset int64
+	remain int64
+	buf    []byte
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.remain <= 0 {
+			return 0, io.EOF
+		}
+		length := min(r.remain, plugin.ChunkSize)
+		var reply plugin.ReadReply
+		err := r.c.call(r.ctx, "Read", plugin.ReadArgs{File: r.file, Offset: r.offset, Length: length}, &reply)
+		if err != nil {
+			return 0, err
+		}
+		if len(reply.Data) == 0 {
+			if reply.EOF {
+				return 0, io.EOF
+			}
+			return 0, io.ErrNoProgress
+		}
+		r.buf = reply.Data
+		r.offset += int64(len(reply.Data))
+		r.remain -= int64(len(reply.Data))
+		if reply.EOF {
+			r.remain = 0
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,134 @@
+package proxypool
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/net"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+const probeTimeout = 5 * time.Second
+
+type Status struct {
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+var (
+	client   *http.Client
+	statusMu sync.RWMutex
+	statuses = make(map[string]*Status)
+)
+
+// Healthy reports whether the proxy is up, the proxies not checked yet are
+func Healthy(url string) bool {
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+	s, ok := statuses[url]
+	return !ok || s.Healthy
+}
+
+// Statuses returns the results of the last health checks
+func Statuses() []Status {
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+	res := make([]Status, 0, len(statuses))
+	for _, s := range statuses {
+		res = append(res, *s)
+	}
+	return res
+}
+
+// Init starts the health checks, which are run every health check interval seconds
+func Init() {
+	client = net.NewHttpClient()
+	client.Timeout = probeTimeout
+	// a redirecting proxy is up
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	go func() {
+		for {
+			interval := setting.GetInt(conf.DownProxyHealthCheckInterval, 60)
+			if interval > 0 {
+				CheckAll()
+			} else {
+				interval = 60
+			}
+			time.Sleep(time.Duration(interval) * time.Second)
+		}
+	}()
+}
+
+// CheckAll probes the proxies of all the storages
+func CheckAll() {
+	probes := make(map[string]string)
+	for _, storage := range op.GetAllStorages() {
+		urls := storage.GetStorage().DownProxyUrl
+		if urls == "" {
+			continue
+		}
+		for _, p := range getPool(urls) {
+			probes[p.URL] = p.URL + p.Health
+		}
+	}
+	var wg sync.WaitGroup
+	results := make(map[string]*Status, len(probes))
+	var mu sync.Mutex
+	for url, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := check(url, probe)
+			mu.Lock()
+			results[url] = s
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	statusMu.Lock()
+	for url, s := range results {
+		if old, ok := statuses[url]; ok && old.Healthy != s.Healthy {
+			if s.Healthy {
+				log.Infof("down proxy %s is up", url)
+			} else {
+				log.Warnf("down proxy %s is down: %s", url, s.Error)
+			}
+		}
+	}
+	// the proxies removed from the storages are forgotten
+	statuses = results
+	statusMu.Unlock()
+}
+
+// check takes the proxy as down if it can't be connected or it responds with a server error
+func check(url, probe string) *Status {
+	s := &Status{URL: url, CheckedAt: time.Now()}
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, probe, nil)
+	if err == nil {
+		var res *http.Response
+		if res, err = client.Do(req); err == nil {
+			_ = res.Body.Close()
+			if res.StatusCode >= 500 {
+				s.Error = res.Status
+				return s
+			}
+		}
+	}
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Healthy = true
+	return s
+}
@@ -0,0 +1,161 @@
+// Package proxypool picks a download proxy of a storage from the pool given by its down proxy urls
+package proxypool
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Proxy is a line of the down proxy urls, like
+//
+//	https://cdn.example.com weight=2 cidr=10.0.0.0/8,192.168.0.0/16 health=/ping
+//
+// weight is relative to the others, the clients in cidr prefer the proxy,
+// and health is the path probed by the health checks
+type Proxy struct {
+	URL    string       `json:"url"`
+	Weight float64      `json:"weight"`
+	CIDRs  []*net.IPNet `json:"-"`
+	Health string       `json:"health"`
+}
+
+func parseProxy(line string) (*Proxy, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, errors.New("empty proxy")
+	}
+	p := &Proxy{URL: strings.TrimSuffix(fields[0], "/"), Weight: 1}
+	for _, f := range fields[1:] {
+		k, v, _ := strings.Cut(f, "=")
+		switch k {
+		case "weight":
+			w, err := strconv.ParseFloat(v, 64)
+			if err != nil || w <= 0 {
+				return nil, errors.Errorf("invalid weight: %s", v)
+			}
+			p.Weight = w
+		case "cidr":
+			for _, s := range strings.Split(v, ",") {
+				_, ipNet, err := net.ParseCIDR(s)
+				if err != nil {
+					return nil, errors.Errorf("invalid cidr: %s", s)
+				}
+				p.CIDRs = append(p.CIDRs, ipNet)
+			}
+		case "health":
+			p.Health = v
+		default:
+			return nil, errors.Errorf("unknown option: %s", f)
+		}
+	}
+	return p, nil
+}
+
+// Parse parses the down proxy urls, one proxy per line
+func Parse(urls string) ([]*Proxy, error) {
+	var proxies []*Proxy
+	for _, line := range strings.Split(urls, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		p, err := parseProxy(line)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to parse down proxy %s", line)
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, nil
+}
+
+var (
+	poolsMu sync.Mutex
+	// the pools by their down proxy urls, which are parsed once
+	pools = make(map[string][]*Proxy)
+)
+
+func getPool(urls string) []*Proxy {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	if pool, ok := pools[urls]; ok {
+		return pool
+	}
+	pool, err := Parse(urls)
+	if err != nil {
+		// the lines without options are usable still
+		log.Warnf("%+v", err)
+		pool = nil
+		for _, line := range strings.Split(urls, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			if p, err := parseProxy(line); err == nil {
+				pool = append(pool, p)
+			}
+		}
+	}
+	pools[urls] = pool
+	return pool
+}
+
+func (p *Proxy) contains(ip net.IP) bool {
+	for _, n := range p.CIDRs {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pick returns the proxy url serving the file for the client, or "" if every proxy is down.
+// The proxies are ranked by rendezvous hashing of the path, so a file goes to the same proxy
+// as long as it's up, and to the next one of its own when it's down
+func Pick(urls, path, clientIP string) string {
+	pool := getPool(urls)
+	ip := net.ParseIP(clientIP)
+	var near, general, healthy []*Proxy
+	for _, p := range pool {
+		if !Healthy(p.URL) {
+			continue
+		}
+		healthy = append(healthy, p)
+		if len(p.CIDRs) == 0 {
+			general = append(general, p)
+		} else if ip != nil && p.contains(ip) {
+			near = append(near, p)
+		}
+	}
+	candidates := near
+	if len(candidates) == 0 {
+		candidates = general
+	}
+	if len(candidates) == 0 {
+		candidates = healthy
+	}
+	var best *Proxy
+	bestScore := math.Inf(-1)
+	for _, p := range candidates {
+		if s := score(p, path); s > bestScore {
+			best, bestScore = p, s
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.URL
+}
+
+// score is the weighted rendezvous hash of the path on the proxy
+func score(p *Proxy, path string) float64 {
+	h := sha256.Sum256([]byte(p.URL + "\n" + path))
+	// a uniform number in (0, 1)
+	u := (float64(binary.BigEndian.Uint64(h[:8])>>11) + 0.5) / (1 << 53)
+	return -p.Weight / math.Log(u)
+}
@@ -0,0 +1,52 @@
+package proxypool
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPick(t *testing.T) {
+	urls := "https://a.example.com/\nhttps://b.example.com weight=3\nhttps://lan.example.com cidr=10.0.0.0/8"
+	if _, err := Parse("https://a.example.com weight=0"); err == nil {
+		t.Error("zero weight should fail")
+	}
+	// the broken lines are dropped, the blank ones are skipped
+	if pool := getPool("https://a.example.com\n\nhttps://b.example.com weight=x\n"); len(pool) != 1 {
+		t.Errorf("unexpected pool of the broken urls: %v", pool)
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		path := fmt.Sprintf("/file%d", i)
+		u := Pick(urls, path, "1.2.3.4")
+		if u != Pick(urls, path, "1.2.3.4") {
+			t.Fatalf("%s is not picked deterministically", path)
+		}
+		counts[u]++
+	}
+	if counts["https://lan.example.com"] != 0 || counts["https://b.example.com"] < 2*counts["https://a.example.com"] {
+		t.Errorf("unexpected distribution: %v", counts)
+	}
+	if u := Pick(urls, "/file", "10.1.2.3"); u != "https://lan.example.com" {
+		t.Errorf("the clients in the range should get the lan proxy, got %s", u)
+	}
+
+	// the files of a proxy down go to the others, the others keep theirs
+	before := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		path := fmt.Sprintf("/file%d", i)
+		before[path] = Pick(urls, path, "1.2.3.4")
+	}
+	statuses = map[string]*Status{"https://b.example.com": {URL: "https://b.example.com"}}
+	defer func() { statuses = map[string]*Status{} }()
+	for path, u := range before {
+		got := Pick(urls, path, "1.2.3.4")
+		if got == "https://b.example.com" || u == "https://a.example.com" && got != u {
+			t.Errorf("%s: %s before, %s after b is down", path, u, got)
+		}
+	}
+	statuses["https://a.example.com"] = &Status{URL: "https://a.example.com"}
+	statuses["https://lan.example.com"] = &Status{URL: "https://lan.example.com"}
+	if u := Pick(urls, "/file", "1.2.3.4"); u != "" {
+		t.Errorf("no proxy should be picked when all are down, got %s", u)
+	}
+}
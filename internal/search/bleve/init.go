@@ -8,7 +8,8 @@ import (
 )
 
 var config = searcher.Config{
-	Name: "bleve",
+	Name:    "bleve",
+	Content: true,
 }
 
 func Init(indexPath *string) (bleve.Index, error) {
@@ -25,6 +26,10 @@ func Init(indexPath *string) (bleve.Index, error) {
 		// TODO: appoint analyzer
 		nameFieldMapping := bleve.NewKeywordFieldMapping()
 		searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
+		// the snippets are highlighted with the term vectors
+		contentFieldMapping := bleve.NewTextFieldMapping()
+		contentFieldMapping.IncludeTermVectors = true
+		searchNodeMapping.AddFieldMappingsAt("content", contentFieldMapping)
 		indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
 		fileIndex, err = bleve.New(*indexPath, indexMapping)
 		if err != nil {
@@ -13,6 +13,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/blevesearch/bleve/v2"
 	search2 "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -27,9 +28,17 @@ func (b *Bleve) Config() searcher.Config {
 
 func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	var queries []query2.Query
-	query := bleve.NewMatchQuery(req.Keywords)
-	query.SetField("name")
-	queries = append(queries, query)
+	if req.Mode == model.SearchModeContent {
+		query := bleve.NewMatchQuery(req.Keywords)
+		query.SetField("content")
+		queries = append(queries, query)
+	} else {
+		query := bleve.NewMatchQuery(req.Keywords)
+		query.SetField("name")
+		mediaQuery := bleve.NewMatchQuery(req.Keywords)
+		mediaQuery.SetField("media")
+		queries = append(queries, bleve.NewDisjunctionQuery(query, mediaQuery))
+	}
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
@@ -37,21 +46,30 @@ func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.Search
 	}
 	reqQuery := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(reqQuery)
-	search.SortBy([]string{"name"})
+	if req.Mode == model.SearchModeContent {
+		// the most relevant first
+		search.Highlight = bleve.NewHighlightWithStyle(html.Name)
+		search.Highlight.AddField("content")
+	} else {
+		search.SortBy([]string{"name"})
+	}
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
-	search.Fields = []string{"*"}
+	search.Fields = []string{"parent", "name", "is_dir", "size", "media"}
 	searchResults, err := b.BIndex.Search(search)
 	if err != nil {
 		log.Errorf("search error: %+v", err)
 		return nil, 0, err
 	}
 	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
+		media, _ := src.Fields["media"].(string)
 		return model.SearchNode{
-			Parent: src.Fields["parent"].(string),
-			Name:   src.Fields["name"].(string),
-			IsDir:  src.Fields["is_dir"].(bool),
-			Size:   int64(src.Fields["size"].(float64)),
+			Parent:     src.Fields["parent"].(string),
+			Name:       src.Fields["name"].(string),
+			IsDir:      src.Fields["is_dir"].(bool),
+			Size:       int64(src.Fields["size"].(float64)),
+			Media:      media,
+			Highlights: src.Fragments["content"],
 		}, nil
 	})
 	return res, int64(searchResults.Total), nil
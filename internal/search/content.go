@@ -0,0 +1,288 @@
+package search
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	stdpath "path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// maxContentLength is the most text of a file indexed
+const maxContentLength = 1 << 20
+
+// maxEntrySize is the most bytes inflated from an entry of a document,
+// the rest of a larger one is ignored
+const maxEntrySize = 64 << 20
+
+var documentTypes = []string{"docx", "xlsx", "pptx", "pdf"}
+
+// contentSupported reports whether the content of the file is indexed
+func contentSupported(obj model.Obj) bool {
+	if obj.IsDir() || obj.GetSize() == 0 || obj.GetSize() > int64(setting.GetInt(conf.IndexContentMaxSize, 10))<<20 {
+		return false
+	}
+	ext := utils.Ext(obj.GetName())
+	return utils.SliceContains(conf.SlicesMap[conf.TextTypes], ext) || utils.SliceContains(documentTypes, ext)
+}
+
+// extractContent reads the file and extracts its plain text
+func extractContent(ctx context.Context, path string, obj model.Obj) (string, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return "", err
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return "", err
+	}
+	defer ss.Close()
+	r, err := ss.RangeRead(http_range.Range{Start: 0, Length: obj.GetSize()})
+	if err != nil {
+		return "", err
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	data, err := io.ReadAll(io.LimitReader(r, obj.GetSize()))
+	if err != nil {
+		return "", err
+	}
+	text, err := extractText(utils.Ext(obj.GetName()), data)
+	if err != nil {
+		return "", err
+	}
+	// the text extracted of a document may be cut in a character too
+	if len(text) >= maxContentLength {
+		text = strings.ToValidUTF8(text[:maxContentLength], "")
+	}
+	return text, nil
+}
+
+func extractText(ext string, data []byte) (string, error) {
+	switch ext {
+	case "docx":
+		return officeText(data, "word/document.xml")
+	case "xlsx":
+		return officeText(data, "xl/sharedStrings.xml", "xl/worksheets/*.xml")
+	case "pptx":
+		return officeText(data, "ppt/slides/*.xml")
+	case "pdf":
+		return pdfText(data), nil
+	}
+	if !utf8.Valid(data) {
+		return "", errors.New("not utf-8 text")
+	}
+	return string(data), nil
+}
+
+// officeText extracts the text of the xml files matching patterns in an office open xml document
+func officeText(data []byte, patterns ...string) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, pattern := range patterns {
+		for _, f := range zr.File {
+			if b.Len() >= maxContentLength {
+				return b.String(), nil
+			}
+			if ok, _ := stdpath.Match(pattern, f.Name); !ok {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return "", err
+			}
+			lr := &io.LimitedReader{R: rc, N: maxEntrySize}
+			err = xmlText(&b, lr)
+			_ = rc.Close()
+			// a truncated entry gives what has been read
+			if err != nil && lr.N > 0 {
+				return "", errors.WithMessagef(err, "failed to read %s", f.Name)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// xmlText writes the text in the <t> elements, which are w:t, a:t and t of the documents, the slides
+// and the sheets, every paragraph or shared string ends with a new line, it stops once maxContentLength is written
+func xmlText(b *strings.Builder, r io.Reader) error {
+	d := xml.NewDecoder(r)
+	inText := false
+	for b.Len() < maxContentLength {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p", "si", "row":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t[:min(len(t), maxContentLength-b.Len())])
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	pdfStreamRegexp = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+	pdfTextRegexp   = regexp.MustCompile(`(?s)BT(.*?)ET`)
+)
+
+// pdfText extracts the text shown by the content streams of a pdf, only the text of the simple fonts
+// can be decoded, the composite fonts need their cmaps
+func pdfText(data []byte) string {
+	var b strings.Builder
+	for _, m := range pdfStreamRegexp.FindAllSubmatchIndex(data, -1) {
+		if b.Len() >= maxContentLength {
+			break
+		}
+		dict := data[m[2]:m[3]]
+		start := m[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		// the images and the fonts have no text
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/Length1")) {
+			continue
+		}
+		content := data[start : start+end]
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) {
+				continue
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			// the corrupted or the truncated streams give what has been inflated
+			content, _ = io.ReadAll(io.LimitReader(zr, maxContentLength))
+			_ = zr.Close()
+		}
+		for _, t := range pdfTextRegexp.FindAllSubmatch(content, -1) {
+			textObject(&b, t[1])
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// textObject writes the strings shown in a text object, the operators moving to the next line write a new line
+func textObject(b *strings.Builder, ops []byte) {
+	for i := 0; i < len(ops); i++ {
+		switch c := ops[i]; c {
+		case '(':
+			depth := 1
+			for i++; i < len(ops) && depth > 0; i++ {
+				switch c := ops[i]; c {
+				case '\\':
+					if i++; i < len(ops) {
+						switch ops[i] {
+						case 'n':
+							b.WriteByte('\n')
+						case 't':
+							b.WriteByte('\t')
+						case 'r', 'b', 'f':
+						case '0', '1', '2', '3', '4', '5', '6', '7':
+							// an octal character code of up to 3 digits
+							j := i
+							for j < len(ops) && j < i+3 && ops[j] >= '0' && ops[j] <= '7' {
+								j++
+							}
+							if n, err := strconv.ParseUint(string(ops[i:j]), 8, 8); err == nil {
+								b.WriteByte(byte(n))
+							}
+							i = j - 1
+						default:
+							b.WriteByte(ops[i])
+						}
+					}
+				case '(':
+					depth++
+					b.WriteByte(c)
+				case ')':
+					if depth--; depth > 0 {
+						b.WriteByte(c)
+					}
+				default:
+					b.WriteByte(c)
+				}
+			}
+			i--
+		case '<':
+			end := bytes.IndexByte(ops[i:], '>')
+			if end < 0 {
+				return
+			}
+			s, err := hex.DecodeString(string(bytes.Join(bytes.Fields(ops[i+1:i+end]), nil)))
+			if err == nil && isPrintable(s) {
+				b.Write(s)
+			}
+			i += end
+		case '\'', '"':
+			b.WriteByte('\n')
+		case 'T':
+			if i+1 < len(ops) && (ops[i+1] == '*' || ops[i+1] == 'd' || ops[i+1] == 'D') {
+				b.WriteByte('\n')
+				i++
+			}
+		case '-':
+			// a large negative adjustment in a TJ array is a space between words
+			j := i + 1
+			for j < len(ops) && (ops[j] >= '0' && ops[j] <= '9' || ops[j] == '.') {
+				j++
+			}
+			if n, err := strconv.ParseFloat(string(ops[i:j]), 64); err == nil && n < -200 {
+				b.WriteByte(' ')
+			}
+			i = j - 1
+		}
+	}
+}
+
+func isPrintable(s []byte) bool {
+	for _, c := range s {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
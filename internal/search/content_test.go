@@ -0,0 +1,50 @@
+package search
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExtractText(t *testing.T) {
+	var docx bytes.Buffer
+	zw := zip.NewWriter(&docx)
+	w, _ := zw.Create("word/document.xml")
+	_, _ = w.Write([]byte(`<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:tab/><w:t>revenue &amp; costs</w:t></w:r></w:p></w:body></w:document>`))
+	_ = zw.Close()
+	text, err := extractText("docx", docx.Bytes())
+	if err != nil || text != "Quarterly report\n\trevenue & costs\n" {
+		t.Errorf("unexpected docx text %q: %v", text, err)
+	}
+
+	var content bytes.Buffer
+	zlw := zlib.NewWriter(&content)
+	_, _ = zlw.Write([]byte("BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Wor) 20 (ld) -300 (again)] TJ ET"))
+	_ = zlw.Close()
+	pdf := fmt.Sprintf("%%PDF-1.4\n4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n%%%%EOF",
+		content.Len(), content.String())
+	text, _ = extractText("pdf", []byte(pdf))
+	if strings.TrimSpace(text) != "Hello (PDF)\nWorld again" {
+		t.Errorf("unexpected pdf text %q", text)
+	}
+
+	// a zip bomb is only inflated up to the max content
+	docx.Reset()
+	zw = zip.NewWriter(&docx)
+	w, _ = zw.Create("word/document.xml")
+	_, _ = w.Write([]byte(`<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>`))
+	_, _ = w.Write(bytes.Repeat([]byte("a"), maxEntrySize+1))
+	_ = zw.Close()
+	text, err = extractText("docx", docx.Bytes())
+	if err != nil || len(text) > maxContentLength {
+		t.Errorf("unexpected docx text of %d bytes: %v", len(text), err)
+	}
+
+	if _, err = extractText("txt", []byte{0xff, 0xfe}); err == nil {
+		t.Error("invalid utf-8 should fail")
+	}
+}
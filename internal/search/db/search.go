@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/search/searcher"
 )
@@ -15,6 +16,9 @@ func (D DB) Config() searcher.Config {
 }
 
 func (D DB) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	if req.Mode == model.SearchModeContent {
+		return nil, 0, errs.NotSupport
+	}
 	return db.SearchNode(req, true)
 }
 
@@ -13,6 +13,7 @@ import (
 var config = searcher.Config{
 	Name:       "meilisearch",
 	AutoUpdate: true,
+	Content:    true,
 }
 
 func init() {
@@ -24,7 +25,7 @@ func init() {
 			}),
 			IndexUid:             conf.Conf.Meilisearch.IndexPrefix + "alist",
 			FilterableAttributes: []string{"parent", "is_dir", "name"},
-			SearchableAttributes: []string{"name"},
+			SearchableAttributes: []string{"name", "media", "content"},
 		}
 
 		_, err := m.Client.GetIndex(m.IndexUid)
@@ -8,6 +8,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/google/uuid"
 	"github.com/meilisearch/meilisearch-go"
+	"html"
 	"path"
 	"strings"
 	"time"
@@ -29,12 +30,36 @@ func (m *Meilisearch) Config() searcher.Config {
 	return config
 }
 
+// the highlights are marked by the private use characters, so that only
+// the marks are left as html once the content is escaped
+const (
+	highlightPreTag  = "\ue000"
+	highlightPostTag = "\ue001"
+)
+
+// formatHighlight escapes the content like the html highlighter of bleve
+func formatHighlight(content string) string {
+	return strings.NewReplacer(
+		highlightPreTag, "<mark>",
+		highlightPostTag, "</mark>",
+	).Replace(html.EscapeString(content))
+}
+
 func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	mReq := &meilisearch.SearchRequest{
-		AttributesToSearchOn: m.SearchableAttributes,
+		AttributesToSearchOn: []string{"name", "media"},
+		AttributesToRetrieve: []string{"parent", "name", "is_dir", "size", "media"},
 		Page:                 int64(req.Page),
 		HitsPerPage:          int64(req.PerPage),
 	}
+	if req.Mode == model.SearchModeContent {
+		mReq.AttributesToSearchOn = []string{"content"}
+		mReq.AttributesToCrop = []string{"content"}
+		mReq.CropLength = 30
+		mReq.AttributesToHighlight = []string{"content"}
+		mReq.HighlightPreTag = highlightPreTag
+		mReq.HighlightPostTag = highlightPostTag
+	}
 	if req.Scope != 0 {
 		mReq.Filter = fmt.Sprintf("is_dir = %v", req.Scope == 1)
 	}
@@ -44,11 +69,20 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
 		srcMap := src.(map[string]any)
+		media, _ := srcMap["media"].(string)
+		var highlights []string
+		if formatted, ok := srcMap["_formatted"].(map[string]any); ok {
+			if content, ok := formatted["content"].(string); ok && content != "" {
+				highlights = []string{formatHighlight(content)}
+			}
+		}
 		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
+			Parent:     srcMap["parent"].(string),
+			Name:       srcMap["name"].(string),
+			IsDir:      srcMap["is_dir"].(bool),
+			Size:       int64(srcMap["size"].(float64)),
+			Media:      media,
+			Highlights: highlights,
 		}, nil
 	})
 	if err != nil {
@@ -99,6 +133,7 @@ func (m *Meilisearch) getDocumentsByParent(ctx context.Context, parent string) (
 		return nil, err
 	}
 	return utils.SliceConvert(result.Results, func(src map[string]any) (*searchDocument, error) {
+		media, _ := src["media"].(string)
 		return &searchDocument{
 			ID: src["id"].(string),
 			SearchNode: model.SearchNode{
@@ -106,6 +141,7 @@ func (m *Meilisearch) getDocumentsByParent(ctx context.Context, parent string) (
 				Name:   src["name"].(string),
 				IsDir:  src["is_dir"].(bool),
 				Size:   int64(src["size"].(float64)),
+				Media:  media,
 			},
 		}, nil
 	})
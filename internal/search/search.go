@@ -3,12 +3,15 @@ package search
 import (
 	"context"
 	"fmt"
+	"path"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/media"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/search/searcher"
+	"github.com/alist-org/alist/v3/internal/setting"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -48,6 +51,9 @@ func Init(mode string) error {
 }
 
 func Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	if instance == nil {
+		return nil, 0, errs.SearchNotAvailable
+	}
 	return instance.Search(ctx, req)
 }
 
@@ -55,12 +61,7 @@ func Index(ctx context.Context, parent string, obj model.Obj) error {
 	if instance == nil {
 		return errs.SearchNotAvailable
 	}
-	return instance.Index(ctx, model.SearchNode{
-		Parent: parent,
-		Name:   obj.GetName(),
-		IsDir:  obj.IsDir(),
-		Size:   obj.GetSize(),
-	})
+	return instance.Index(ctx, searchNode(ctx, parent, obj))
 }
 
 type ObjWithParent struct {
@@ -77,16 +78,37 @@ func BatchIndex(ctx context.Context, objs []ObjWithParent) error {
 	}
 	var searchNodes []model.SearchNode
 	for i := range objs {
-		searchNodes = append(searchNodes, model.SearchNode{
-			Parent: objs[i].Parent,
-			Name:   objs[i].GetName(),
-			IsDir:  objs[i].IsDir(),
-			Size:   objs[i].GetSize(),
-		})
+		searchNodes = append(searchNodes, searchNode(ctx, objs[i].Parent, objs[i].Obj))
 	}
 	return instance.BatchIndex(ctx, searchNodes)
 }
 
+func searchNode(ctx context.Context, parent string, obj model.Obj) model.SearchNode {
+	node := model.SearchNode{
+		Parent: parent,
+		Name:   obj.GetName(),
+		IsDir:  obj.IsDir(),
+		Size:   obj.GetSize(),
+	}
+	if setting.GetBool(conf.IndexMediaInfo) && media.Supported(obj) {
+		info, err := media.Get(ctx, path.Join(parent, obj.GetName()), obj)
+		if err != nil {
+			log.Debugf("failed get media info of %s/%s: %+v", parent, obj.GetName(), err)
+		} else {
+			node.Media = info.Keywords()
+		}
+	}
+	if instance.Config().Content && setting.GetBool(conf.IndexContent) && contentSupported(obj) {
+		content, err := extractContent(ctx, path.Join(parent, obj.GetName()), obj)
+		if err != nil {
+			log.Debugf("failed extract content of %s/%s: %+v", parent, obj.GetName(), err)
+		} else {
+			node.Content = content
+		}
+	}
+	return node
+}
+
 func init() {
 	op.RegisterSettingItemHook(conf.SearchIndex, func(item *model.SettingItem) error {
 		log.Debugf("searcher init, mode: %s", item.Value)
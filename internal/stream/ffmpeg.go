@@ -0,0 +1,47 @@
+package stream
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// FFmpegReads reports whether ffmpeg can read the link itself, which is a local file or an http url
+func FFmpegReads(link *model.Link) bool {
+	if link.MFile != nil {
+		_, ok := link.MFile.(*os.File)
+		return ok
+	}
+	return strings.HasPrefix(link.URL, "http")
+}
+
+// FFmpegInput returns the input of ffmpeg for the link, ffmpeg reads and seeks the media itself,
+// so the link must be a local file or an http url. The file of the link is closed
+func FFmpegInput(link *model.Link) (string, ffmpeg.KwArgs, error) {
+	if !FFmpegReads(link) {
+		if link.MFile != nil {
+			_ = link.MFile.Close()
+		}
+		return "", nil, errors.WithMessage(errs.NotSupport, "the media can't be read by ffmpeg")
+	}
+	if link.MFile != nil {
+		defer link.MFile.Close()
+		return link.MFile.(*os.File).Name(), ffmpeg.KwArgs{}, nil
+	}
+	args := ffmpeg.KwArgs{}
+	var headers strings.Builder
+	for k, vs := range link.Header {
+		for _, v := range vs {
+			headers.WriteString(fmt.Sprintf("%s: %s\r\n", k, v))
+		}
+	}
+	if headers.Len() > 0 {
+		args["headers"] = headers.String()
+	}
+	return link.URL, args, nil
+}
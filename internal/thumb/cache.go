@@ -0,0 +1,73 @@
+package thumb
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+// cleanInterval is how often the size of the cache is checked
+const cleanInterval = 10 * time.Minute
+
+func cleanLoop() {
+	for range time.Tick(cleanInterval) {
+		clean(int64(setting.GetInt(conf.ThumbnailCacheSize, 1024)) * 1024 * 1024)
+	}
+}
+
+// touch marks the cached file used, the files are evicted by their modified time
+func touch(p string) {
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+}
+
+// clean removes the least recently used files until the cache is within capacity, 0 for no limit
+func clean(capacity int64) {
+	if capacity <= 0 {
+		return
+	}
+	type file struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var (
+		files []file
+		used  int64
+	)
+	_ = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		// the unfinished writes are left to their writers
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), "tmp-") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, file{path: p, size: info.Size(), used: info.ModTime()})
+		used += info.Size()
+		return nil
+	})
+	if used <= capacity {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].used.Before(files[j].used)
+	})
+	for _, f := range files {
+		if used <= capacity {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("failed remove cached thumbnail %s: %+v", f.path, err)
+			continue
+		}
+		used -= f.size
+	}
+}
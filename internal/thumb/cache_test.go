@@ -0,0 +1,38 @@
+package thumb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClean(t *testing.T) {
+	dir = t.TempDir()
+	now := time.Now()
+	write := func(name string, size int, used time.Time) string {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, size), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, used, used); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	oldest := write("aa/old.png", 100, now.Add(-3*time.Hour))
+	old := write("renditions/bb/old.jpeg", 100, now.Add(-2*time.Hour))
+	recent := write("cc/recent.png", 100, now.Add(-time.Hour))
+	tmp := write("cc/tmp-1", 100, now.Add(-4*time.Hour))
+	// the old one is used again
+	touch(old)
+	clean(250)
+	for p, want := range map[string]bool{oldest: false, old: true, recent: true, tmp: true} {
+		if _, err := os.Stat(p); (err == nil) != want {
+			t.Errorf("%s kept: %v, want %v", p, err == nil, want)
+		}
+	}
+}
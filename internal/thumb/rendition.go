@@ -0,0 +1,231 @@
+package thumb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"image"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// RenditionParams are the query parameters asking for a rendition of an image
+var RenditionParams = []string{"w", "h", "fit", "q", "fmt"}
+
+// RenditionQuery returns the rendition parameters of the query in a canonical form, which the signs cover
+func RenditionQuery(query url.Values) string {
+	v := url.Values{}
+	for _, k := range RenditionParams {
+		if query.Has(k) {
+			v.Set(k, query.Get(k))
+		}
+	}
+	return v.Encode()
+}
+
+type Rendition struct {
+	Width   int
+	Height  int
+	Fit     string // contain, cover or fill
+	Quality int
+	Format  string // jpeg, png or webp
+}
+
+// ParseRendition parses the rendition parameters of the query, nil is returned if there are none
+func ParseRendition(query url.Values, name string) (*Rendition, error) {
+	if RenditionQuery(query) == "" {
+		return nil, nil
+	}
+	r := &Rendition{Fit: "contain", Quality: 85, Format: "jpeg"}
+	if ext := utils.Ext(name); ext == "png" || ext == "gif" {
+		r.Format = "png"
+	}
+	var err error
+	if w := query.Get("w"); w != "" {
+		if r.Width, err = strconv.Atoi(w); err != nil || r.Width < 0 {
+			return nil, errors.Errorf("invalid width: %s", w)
+		}
+	}
+	if h := query.Get("h"); h != "" {
+		if r.Height, err = strconv.Atoi(h); err != nil || r.Height < 0 {
+			return nil, errors.Errorf("invalid height: %s", h)
+		}
+	}
+	maxDim := setting.GetInt(conf.RenditionMaxDimension, 4096)
+	if r.Width > maxDim || r.Height > maxDim {
+		return nil, errors.Errorf("the dimensions can't be larger than %d", maxDim)
+	}
+	// every rendition asked is cached, the values are limited so that the links can't be varied endlessly
+	sizes := allowed(conf.RenditionSizes)
+	if !sizes(r.Width) || !sizes(r.Height) {
+		return nil, errors.Errorf("the dimensions must be one of %s", setting.GetStr(conf.RenditionSizes))
+	}
+	if q := query.Get("q"); q != "" {
+		if r.Quality, err = strconv.Atoi(q); err != nil || r.Quality < 1 || r.Quality > 100 {
+			return nil, errors.Errorf("invalid quality: %s", q)
+		}
+		if !allowed(conf.RenditionQualities)(r.Quality) {
+			return nil, errors.Errorf("the quality must be one of %s", setting.GetStr(conf.RenditionQualities))
+		}
+	}
+	if fit := query.Get("fit"); fit != "" {
+		if fit != "contain" && fit != "cover" && fit != "fill" {
+			return nil, errors.Errorf("invalid fit: %s", fit)
+		}
+		r.Fit = fit
+	}
+	if format := strings.ToLower(query.Get("fmt")); format != "" {
+		if format == "jpg" {
+			format = "jpeg"
+		}
+		if format != "jpeg" && format != "png" && format != "webp" {
+			return nil, errors.Errorf("invalid format: %s", format)
+		}
+		r.Format = format
+	}
+	return r, nil
+}
+
+// allowed returns whether a value is in the list of the setting, 0 which means not asked
+// and any value if the list is empty are allowed
+func allowed(key string) func(int) bool {
+	list := strings.TrimSpace(setting.GetStr(key))
+	return func(v int) bool {
+		if v == 0 || list == "" {
+			return true
+		}
+		for _, s := range strings.Split(list, ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && n == v {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (r *Rendition) ContentType() string {
+	return "image/" + r.Format
+}
+
+func (r *Rendition) key(path string, obj model.Obj) string {
+	h := sha1.Sum([]byte(key(path, obj) + "\n" + strconv.Itoa(r.Width) + "x" + strconv.Itoa(r.Height) +
+		"\n" + r.Fit + "\n" + strconv.Itoa(r.Quality) + "\n" + r.Format))
+	return hex.EncodeToString(h[:])
+}
+
+// GetRendition returns the local file of the rendition of the image at the mount path, which is made if not cached
+func GetRendition(ctx context.Context, path string, obj model.Obj, r *Rendition) (string, error) {
+	if dir == "" {
+		return "", errors.New("the image cache is not initialized")
+	}
+	if utils.GetFileType(obj.GetName()) != conf.IMAGE || obj.GetSize() > MaxImageSize {
+		return "", errors.WithMessage(errs.NotSupport, "only the images can be resized")
+	}
+	k := r.key(path, obj)
+	p := filepath.Join(dir, "renditions", k[:2], k+"."+r.Format)
+	if utils.Exists(p) {
+		touch(p)
+		return p, nil
+	}
+	_, err, _ := group.Do(k, func() (interface{}, error) {
+		if utils.Exists(p) {
+			return nil, nil
+		}
+		s := sem.Load()
+		if err := s.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		defer s.Release(1)
+		data, err := r.render(ctx, path, obj)
+		if err != nil {
+			return nil, err
+		}
+		return nil, save(p, data)
+	})
+	if err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+func (r *Rendition) render(ctx context.Context, path string, obj model.Obj) ([]byte, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	img, err := readImage(ctx, obj, link)
+	if err != nil {
+		return nil, err
+	}
+	img = r.resize(img)
+	var buf bytes.Buffer
+	switch r.Format {
+	case "jpeg":
+		err = imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(r.Quality))
+	case "png":
+		err = imaging.Encode(&buf, img, imaging.PNG)
+	case "webp":
+		err = encodeWebP(&buf, img, r.Quality)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Rendition) resize(img image.Image) image.Image {
+	w, h := r.Width, r.Height
+	if w == 0 && h == 0 {
+		return img
+	}
+	// never enlarge
+	size := img.Bounds().Size()
+	if w > size.X || h > size.Y {
+		if w*size.Y > h*size.X {
+			w, h = min(w, size.X), 0
+		} else {
+			w, h = 0, min(h, size.Y)
+		}
+	}
+	if w == 0 || h == 0 {
+		return imaging.Resize(img, w, h, imaging.Lanczos)
+	}
+	switch r.Fit {
+	case "cover":
+		return imaging.Fill(img, w, h, imaging.Center, imaging.Lanczos)
+	case "fill":
+		return imaging.Resize(img, w, h, imaging.Lanczos)
+	default:
+		return imaging.Fit(img, w, h, imaging.Lanczos)
+	}
+}
+
+// encodeWebP lets ffmpeg encode, there is no webp encoder in go
+func encodeWebP(buf *bytes.Buffer, img image.Image, quality int) error {
+	var png bytes.Buffer
+	if err := imaging.Encode(&png, img, imaging.PNG); err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	err := ffmpeg.Input("pipe:", ffmpeg.KwArgs{"f": "png_pipe"}).
+		Output("pipe:", ffmpeg.KwArgs{"f": "webp", "vcodec": "libwebp", "quality": quality}).
+		GlobalArgs("-loglevel", "error").Silent(true).
+		WithInput(&png).WithOutput(buf, &stderr).Run()
+	if err != nil {
+		return errors.WithMessagef(err, "failed encode webp: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package thumb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// Width of the thumbnails, the same as the Local driver
+	Width = 144
+	// MaxImageSize is the largest image read to make a thumbnail
+	MaxImageSize = 64 << 20
+	// MaxImagePixels is the most pixels of an image decoded, a small file may be a huge image
+	MaxImagePixels = 64 << 20
+	// the position of the video frame taken, the first frame is used for the shorter videos
+	videoThumbPos = "10"
+)
+
+var (
+	dir   string
+	sem   atomic.Pointer[semaphore.Weighted]
+	group singleflight.Group
+	// the files failed recently are not tried again for a while
+	failed = cache.NewMemCache(cache.WithShards[error](16))
+)
+
+// Init makes the thumbnails cached in d and generated n at a time at most,
+// the cache is kept within the size of the setting by evicting the least recently used
+func Init(d string, n int) error {
+	if err := os.MkdirAll(d, 0o777); err != nil {
+		return errors.WithStack(err)
+	}
+	dir = d
+	SetConcurrency(n)
+	go cleanLoop()
+	return nil
+}
+
+func SetConcurrency(n int) {
+	sem.Store(semaphore.NewWeighted(int64(max(n, 1))))
+}
+
+func Enabled() bool {
+	return dir != "" && setting.GetBool(conf.ThumbnailEnabled)
+}
+
+// Supported reports whether a thumbnail can be made for the file
+func Supported(obj model.Obj) bool {
+	if obj.IsDir() || obj.GetSize() <= 0 {
+		return false
+	}
+	switch utils.GetFileType(obj.GetName()) {
+	case conf.IMAGE:
+		return obj.GetSize() <= MaxImageSize
+	case conf.VIDEO:
+		return true
+	}
+	return false
+}
+
+func key(path string, obj model.Obj) string {
+	h := sha1.Sum([]byte(path + "\n" + strconv.FormatInt(obj.GetSize(), 10) + "\n" + strconv.FormatInt(obj.ModTime().UnixNano(), 10)))
+	return hex.EncodeToString(h[:])
+}
+
+// Get returns the local file of the thumbnail of the file at the mount path, which is generated if not cached
+func Get(ctx context.Context, path string, obj model.Obj) (string, error) {
+	k := key(path, obj)
+	p := filepath.Join(dir, k[:2], k+".png")
+	if utils.Exists(p) {
+		touch(p)
+		return p, nil
+	}
+	if err, ok := failed.Get(k); ok {
+		return "", err
+	}
+	_, err, _ := group.Do(k, func() (interface{}, error) {
+		if utils.Exists(p) {
+			return nil, nil
+		}
+		s := sem.Load()
+		if err := s.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		defer s.Release(1)
+		data, err := generate(ctx, path, obj)
+		if err != nil {
+			// the requests canceled may well succeed next time
+			if ctx.Err() == nil {
+				failed.Set(k, err, cache.WithEx[error](10*time.Minute))
+			}
+			return nil, err
+		}
+		return nil, save(p, data)
+	})
+	if err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+func save(p string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o777); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), "tmp-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = f.Write(data)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return errors.WithStack(err)
+}
+
+func generate(ctx context.Context, path string, obj model.Obj) ([]byte, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return nil, err
+	}
+	var img image.Image
+	if utils.GetFileType(obj.GetName()) == conf.VIDEO {
+		img, err = videoFrame(ctx, obj, link)
+	} else {
+		img, err = readImage(ctx, obj, link)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = imaging.Encode(&buf, imaging.Resize(img, Width, 0, imaging.Lanczos), imaging.PNG); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readImage(ctx context.Context, obj model.Obj, link *model.Link) (image.Image, error) {
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	r, err := ss.RangeRead(http_range.Range{Start: 0, Length: -1})
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxImageSize))
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return nil, errors.WithMessagef(errs.NotSupport, "the image of %dx%d is too large", cfg.Width, cfg.Height)
+	}
+	return imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+}
+
+// videoFrame takes a frame of the video, ffmpeg seeks in the video itself if it can read the link,
+// otherwise the video is piped to it from the start
+func videoFrame(ctx context.Context, obj model.Obj, link *model.Link) (image.Image, error) {
+	var input string
+	var args ffmpeg.KwArgs
+	var ss *stream.SeekableStream
+	var err error
+	if stream.FFmpegReads(link) {
+		if input, args, err = stream.FFmpegInput(link); err != nil {
+			return nil, err
+		}
+	} else {
+		if ss, err = stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link); err != nil {
+			return nil, err
+		}
+		defer ss.Close()
+		input, args = "pipe:", ffmpeg.KwArgs{}
+	}
+	args["noaccurate_seek"] = ""
+	for _, pos := range []string{videoThumbPos, "0"} {
+		if utils.IsCanceled(ctx) {
+			return nil, ctx.Err()
+		}
+		args["ss"] = pos
+		buf := bytes.NewBuffer(nil)
+		cmd := ffmpeg.Input(input, args).
+			Output("pipe:", ffmpeg.KwArgs{"vframes": 1, "format": "image2", "vcodec": "mjpeg"}).
+			GlobalArgs("-loglevel", "error").Silent(true).
+			WithOutput(buf, io.Discard)
+		if ss != nil {
+			err = pipeRun(cmd, ss)
+		} else {
+			err = cmd.Run()
+		}
+		if err == nil && buf.Len() > 0 {
+			return imaging.Decode(buf)
+		}
+	}
+	if err == nil {
+		err = errors.New("no frame extracted")
+	}
+	return nil, err
+}
+
+// pipeRun runs ffmpeg with the video read from the start as its input
+func pipeRun(cmd *ffmpeg.Stream, ss *stream.SeekableStream) error {
+	r, err := ss.RangeRead(http_range.Range{Start: 0, Length: -1})
+	if err != nil {
+		return err
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	return cmd.WithInput(r).Run()
+}
@@ -0,0 +1,138 @@
+package thumb_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/thumb"
+	"github.com/disintegration/imaging"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	conf.SlicesMap[conf.ImageTypes] = []string{"jpg"}
+	db.Init(dB)
+}
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	img := imaging.New(600, 400, color.NRGBA{R: 255, A: 255})
+	if err := imaging.Save(img, filepath.Join(dir, "a.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	// a small png claiming to be a huge image
+	var png bytes.Buffer
+	if err := imaging.Encode(&png, imaging.New(1, 1, color.NRGBA{A: 255}), imaging.PNG); err != nil {
+		t.Fatal(err)
+	}
+	huge := png.Bytes()
+	binary.BigEndian.PutUint32(huge[16:], 100000)
+	binary.BigEndian.PutUint32(huge[20:], 100000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	if err := os.WriteFile(filepath.Join(dir, "huge.jpg"), huge, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, dir),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	if err = thumb.Init(t.TempDir(), 2); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := fs.Get(ctx, "/local/a.jpg", &fs.GetArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !thumb.Supported(obj) {
+		t.Fatal("expected images supported")
+	}
+	p, err := thumb.Get(ctx, "/local/a.jpg", obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != thumb.Width || cfg.Height != thumb.Width*2/3 {
+		t.Errorf("unexpected size %dx%d", cfg.Width, cfg.Height)
+	}
+
+	r, err := thumb.ParseRendition(url.Values{"w": {"300"}, "h": {"300"}, "fit": {"cover"}, "fmt": {"png"}}, "a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = thumb.GetRendition(ctx, "/local/a.jpg", obj, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendition, err := imaging.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := rendition.Bounds().Size(); size.X != 300 || size.Y != 300 {
+		t.Errorf("unexpected rendition size %v", size)
+	}
+	if _, err = thumb.ParseRendition(url.Values{"w": {"100000"}}, "a.jpg"); err == nil {
+		t.Error("expected the dimensions capped")
+	}
+	err = op.SaveSettingItems([]model.SettingItem{
+		{Key: conf.RenditionSizes, Value: "128, 256"},
+		{Key: conf.RenditionQualities, Value: "85"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []url.Values{{"w": {"300"}}, {"w": {"256"}, "h": {"100"}}, {"w": {"128"}, "q": {"60"}}} {
+		if _, err = thumb.ParseRendition(q, "a.jpg"); err == nil {
+			t.Errorf("expected %v refused", q)
+		}
+	}
+	if _, err = thumb.ParseRendition(url.Values{"w": {"256"}, "q": {"85"}}, "a.jpg"); err != nil {
+		t.Errorf("expected the listed values allowed: %v", err)
+	}
+
+	obj, err = fs.Get(ctx, "/local/huge.jpg", &fs.GetArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = thumb.Get(ctx, "/local/huge.jpg", obj); !errors.Is(err, errs.NotSupport) {
+		t.Errorf("expected the huge image refused, got %v", err)
+	}
+}
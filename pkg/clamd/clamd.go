@@ -0,0 +1,87 @@
+// Package clamd scans streams with the INSTREAM command of ClamAV's clamd.
+package clamd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const chunkSize = 64 * 1024
+
+// Dial connects to clamd, address is tcp://host:port, unix:///path/to/clamd.ctl or host:port
+func Dial(ctx context.Context, address string) (net.Conn, error) {
+	network := "tcp"
+	if addr, ok := strings.CutPrefix(address, "unix://"); ok {
+		network, address = "unix", addr
+	} else {
+		address = strings.TrimPrefix(address, "tcp://")
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, network, address)
+}
+
+// Scan sends the reader to clamd, it returns the name of the virus or an empty string if it's clean
+func Scan(ctx context.Context, address string, r io.Reader) (string, error) {
+	conn, err := Dial(ctx, address)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Minute))
+	}
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, rerr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err = conn.Write(buf[:4+n]); err != nil {
+				// clamd closes the connection when the size limit is exceeded, the reply tells why
+				break
+			}
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			return "", rerr
+		}
+		if err = ctx.Err(); err != nil {
+			return "", err
+		}
+	}
+	if err == nil {
+		if _, err = conn.Write([]byte{0, 0, 0, 0}); err != nil {
+			return "", err
+		}
+	}
+	reply, rerr := io.ReadAll(conn)
+	if rerr != nil && len(reply) == 0 {
+		return "", rerr
+	}
+	return parseReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+// parseReply parses replies like "stream: OK" and "stream: Eicar-Signature FOUND"
+func parseReply(reply string) (string, error) {
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("unexpected clamd reply: %s", reply)
+	}
+}
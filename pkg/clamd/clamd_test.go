@@ -0,0 +1,73 @@
+package clamd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serve is a stand-in of clamd, it reports a virus if the stream contains "EICAR"
+func serve(t *testing.T, l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			cmd := make([]byte, len("zINSTREAM\x00"))
+			if _, err := io.ReadFull(conn, cmd); err != nil || string(cmd) != "zINSTREAM\x00" {
+				t.Errorf("unexpected command %q", cmd)
+				return
+			}
+			var data bytes.Buffer
+			for {
+				var size uint32
+				if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+					t.Error(err)
+					return
+				}
+				if size == 0 {
+					break
+				}
+				if _, err := io.CopyN(&data, conn, int64(size)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if strings.Contains(data.String(), "EICAR") {
+				_, _ = conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+			} else {
+				_, _ = conn.Write([]byte("stream: OK\x00"))
+			}
+		}()
+	}
+}
+
+func TestScan(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "clamd.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+	go serve(t, l)
+	defer os.Remove(sock)
+
+	clean := bytes.Repeat([]byte("a"), 3*chunkSize+10)
+	virus, err := Scan(context.Background(), "unix://"+sock, bytes.NewReader(clean))
+	if err != nil || virus != "" {
+		t.Fatalf("expect clean, got %q, %v", virus, err)
+	}
+	infected := append(clean, []byte("EICAR")...)
+	virus, err = Scan(context.Background(), "unix://"+sock, bytes.NewReader(infected))
+	if err != nil || virus != "Eicar-Test-Signature" {
+		t.Fatalf("expect the virus found, got %q, %v", virus, err)
+	}
+}
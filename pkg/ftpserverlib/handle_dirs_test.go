@@ -0,0 +1,45 @@
+package ftpserver
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+type mlsxFileInfo struct {
+	os.FileInfo
+	create time.Time
+	unique string
+}
+
+func (f mlsxFileInfo) Name() string          { return "a.txt" }
+func (f mlsxFileInfo) Size() int64           { return 5 }
+func (f mlsxFileInfo) IsDir() bool           { return false }
+func (f mlsxFileInfo) ModTime() time.Time    { return time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC) }
+func (f mlsxFileInfo) CreateTime() time.Time { return f.create }
+func (f mlsxFileInfo) UniqueID() string      { return f.unique }
+
+func TestWriteMLSxEntry(t *testing.T) {
+	cases := []struct {
+		info os.FileInfo
+		want string
+	}{
+		{
+			mlsxFileInfo{create: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), unique: "abc"},
+			"Type=file;Size=5;Modify=20240506070809;Create=20240102030405;Unique=abc; a.txt\r\n",
+		},
+		// the zero create time and the empty unique id are left out
+		{mlsxFileInfo{}, "Type=file;Size=5;Modify=20240506070809; a.txt\r\n"},
+	}
+	c := &clientHandler{}
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		if err := c.writeMLSxEntry(&buf, tc.info); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("got %q, want %q", buf.String(), tc.want)
+		}
+	}
+}
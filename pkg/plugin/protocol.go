@@ -0,0 +1,170 @@
+// Package plugin defines the protocol between alist and out-of-process
+// driver plugins.
+//
+// A plugin is an executable placed in the plugin dir. alist starts one process
+// per storage and speaks JSON-RPC 1.0 (net/rpc/jsonrpc) with it over the
+// plugin's stdin and stdout, so stdout must not be used for anything else;
+// stderr is forwarded to the alist log. The methods mirror driver.Meta,
+// driver.Reader and the write interfaces, file contents are streamed in
+// chunks through Read and PutStart/PutChunk/PutFinish.
+//
+// Plugins written in Go only need to implement Driver and call Serve.
+package plugin
+
+import (
+	"net/http"
+	"time"
+)
+
+// ServiceName is the name of the rpc service, methods are called as "Plugin.List" etc.
+const ServiceName = "Plugin"
+
+// ProtocolVersion is increased on incompatible changes of the protocol
+const ProtocolVersion = 1
+
+// ChunkSize is the max size of data carried by a Read or PutChunk call
+const ChunkSize = 1024 * 1024
+
+// Item describes an addition field that the admin UI renders, same as driver.Item
+type Item struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Default  string `json:"default"`
+	Options  string `json:"options"`
+	Required bool   `json:"required"`
+	Help     string `json:"help"`
+}
+
+// Config is the part of driver.Config that makes sense for a plugin
+type Config struct {
+	Name        string `json:"name"`
+	LocalSort   bool   `json:"local_sort"`
+	OnlyProxy   bool   `json:"only_proxy"`
+	NoCache     bool   `json:"no_cache"`
+	NoUpload    bool   `json:"no_upload"`
+	DefaultRoot string `json:"default_root"`
+	Alert       string `json:"alert"`
+}
+
+// Capabilities tells which of the optional methods the plugin implements
+type Capabilities struct {
+	MakeDir bool `json:"make_dir"`
+	Move    bool `json:"move"`
+	Rename  bool `json:"rename"`
+	Copy    bool `json:"copy"`
+	Remove  bool `json:"remove"`
+	Put     bool `json:"put"`
+}
+
+type Obj struct {
+	ID       string    `json:"id"`
+	Path     string    `json:"path"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Ctime    time.Time `json:"ctime"`
+	IsFolder bool      `json:"is_folder"`
+	// Hash is a json object from hash name to value, such as {"md5":"..."}
+	Hash string `json:"hash"`
+}
+
+type Empty struct{}
+
+type InfoReply struct {
+	ProtocolVersion int          `json:"protocol_version"`
+	Config          Config       `json:"config"`
+	Additional      []Item       `json:"additional"`
+	Capabilities    Capabilities `json:"capabilities"`
+}
+
+type InitArgs struct {
+	// Addition is the json of the storage addition, keyed by Item.Name
+	Addition string `json:"addition"`
+}
+
+type InitReply struct {
+	// Addition is saved back to the storage if not empty, e.g. a refreshed token
+	Addition string `json:"addition"`
+	// Root is the obj of the root folder
+	Root Obj `json:"root"`
+}
+
+type ListArgs struct {
+	Dir Obj `json:"dir"`
+}
+
+type ListReply struct {
+	Objs []Obj `json:"objs"`
+}
+
+type LinkArgs struct {
+	File   Obj         `json:"file"`
+	IP     string      `json:"ip"`
+	Header http.Header `json:"header"`
+}
+
+type LinkReply struct {
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	// Expiration in seconds, 0 means the link is not cached
+	Expiration int64 `json:"expiration"`
+	// Stream means the content must be fetched with Read instead of URL
+	Stream bool `json:"stream"`
+}
+
+type ReadArgs struct {
+	File   Obj   `json:"file"`
+	Offset int64 `json:"offset"`
+	// Length is at most ChunkSize
+	Length int64 `json:"length"`
+}
+
+type ReadReply struct {
+	Data []byte `json:"data"`
+	EOF  bool   `json:"eof"`
+}
+
+type MakeDirArgs struct {
+	ParentDir Obj    `json:"parent_dir"`
+	Name      string `json:"name"`
+}
+
+type MoveArgs struct {
+	Src    Obj `json:"src"`
+	DstDir Obj `json:"dst_dir"`
+}
+
+type RenameArgs struct {
+	Src     Obj    `json:"src"`
+	NewName string `json:"new_name"`
+}
+
+type RemoveArgs struct {
+	Obj Obj `json:"obj"`
+}
+
+type PutStartArgs struct {
+	DstDir   Obj       `json:"dst_dir"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Mimetype string    `json:"mimetype"`
+}
+
+type PutStartReply struct {
+	UploadID string `json:"upload_id"`
+}
+
+type PutChunkArgs struct {
+	UploadID string `json:"upload_id"`
+	Data     []byte `json:"data"`
+}
+
+type PutEndArgs struct {
+	UploadID string `json:"upload_id"`
+}
+
+// ObjReply carries the obj created by a write method, nil if unknown
+type ObjReply struct {
+	Obj *Obj `json:"obj"`
+}
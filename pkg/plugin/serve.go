@@ -0,0 +1,244 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNotImplement is returned for optional methods the plugin doesn't implement
+var ErrNotImplement = errors.New("not implement")
+
+// Driver is implemented by plugins written in Go,
+// the optional write methods are implemented by Writer
+type Driver interface {
+	Info() InfoReply
+	Init(addition string) (*InitReply, error)
+	Drop() error
+	List(dir Obj) ([]Obj, error)
+	Link(args LinkArgs) (*LinkReply, error)
+	// Read is only called if Link replies with Stream
+	Read(file Obj, offset, length int64) ([]byte, error)
+}
+
+type Writer interface {
+	MakeDir(parentDir Obj, name string) (*Obj, error)
+	Move(src, dstDir Obj) (*Obj, error)
+	Rename(src Obj, newName string) (*Obj, error)
+	Copy(src, dstDir Obj) (*Obj, error)
+	Remove(obj Obj) error
+	// Put reads the content from r, ctx is canceled if alist aborts the upload
+	Put(ctx context.Context, args PutStartArgs, r io.Reader) (*Obj, error)
+}
+
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+func (stdio) Close() error {
+	return nil
+}
+
+// Serve serves d over stdin and stdout until alist closes the pipe
+func Serve(d Driver) error {
+	return ServeConn(d, stdio{Reader: os.Stdin, Writer: os.Stdout})
+}
+
+// ServeConn serves d over conn, it's useful for tests
+func ServeConn(d Driver, conn io.ReadWriteCloser) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, &service{d: d}); err != nil {
+		return err
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	return nil
+}
+
+type upload struct {
+	w      *io.PipeWriter
+	cancel context.CancelFunc
+	done   chan struct{}
+	obj    *Obj
+	err    error
+}
+
+type service struct {
+	d       Driver
+	uploads sync.Map
+	nextID  atomic.Int64
+}
+
+func (s *service) writer() (Writer, error) {
+	w, ok := s.d.(Writer)
+	if !ok {
+		return nil, ErrNotImplement
+	}
+	return w, nil
+}
+
+func (s *service) Info(_ Empty, reply *InfoReply) error {
+	*reply = s.d.Info()
+	reply.ProtocolVersion = ProtocolVersion
+	return nil
+}
+
+func (s *service) Init(args InitArgs, reply *InitReply) error {
+	res, err := s.d.Init(args.Addition)
+	if err != nil {
+		return err
+	}
+	*reply = *res
+	return nil
+}
+
+func (s *service) Drop(_ Empty, _ *Empty) error {
+	return s.d.Drop()
+}
+
+func (s *service) List(args ListArgs, reply *ListReply) error {
+	objs, err := s.d.List(args.Dir)
+	reply.Objs = objs
+	return err
+}
+
+func (s *service) Link(args LinkArgs, reply *LinkReply) error {
+	res, err := s.d.Link(args)
+	if err != nil {
+		return err
+	}
+	*reply = *res
+	return nil
+}
+
+func (s *service) Read(args ReadArgs, reply *ReadReply) error {
+	if args.Length > ChunkSize {
+		args.Length = ChunkSize
+	}
+	data, err := s.d.Read(args.File, args.Offset, args.Length)
+	if errors.Is(err, io.EOF) {
+		reply.EOF = true
+		err = nil
+	}
+	reply.Data = data
+	return err
+}
+
+func (s *service) MakeDir(args MakeDirArgs, reply *ObjReply) (err error) {
+	w, err := s.writer()
+	if err != nil {
+		return err
+	}
+	reply.Obj, err = w.MakeDir(args.ParentDir, args.Name)
+	return err
+}
+
+func (s *service) Move(args MoveArgs, reply *ObjReply) (err error) {
+	w, err := s.writer()
+	if err != nil {
+		return err
+	}
+	reply.Obj, err = w.Move(args.Src, args.DstDir)
+	return err
+}
+
+func (s *service) Rename(args RenameArgs, reply *ObjReply) (err error) {
+	w, err := s.writer()
+	if err != nil {
+		return err
+	}
+	reply.Obj, err = w.Rename(args.Src, args.NewName)
+	return err
+}
+
+func (s *service) Copy(args MoveArgs, reply *ObjReply) (err error) {
+	w, err := s.writer()
+	if err != nil {
+		return err
+	}
+	reply.Obj, err = w.Copy(args.Src, args.DstDir)
+	return err
+}
+
+func (s *service) Remove(args RemoveArgs, _ *Empty) error {
+	w, err := s.writer()
+	if err != nil {
+		return err
+	}
+	return w.Remove(args.Obj)
+}
+
+func (s *service) PutStart(args PutStartArgs, reply *PutStartReply) error {
+	w, err := s.writer()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r, pw := io.Pipe()
+	u := &upload{w: pw, cancel: cancel, done: make(chan struct{})}
+	id := strconv.FormatInt(s.nextID.Add(1), 10)
+	s.uploads.Store(id, u)
+	go func() {
+		defer close(u.done)
+		u.obj, u.err = w.Put(ctx, args, r)
+		// unblock PutChunk if Put returns before reading everything
+		_ = r.CloseWithError(u.err)
+	}()
+	reply.UploadID = id
+	return nil
+}
+
+func (s *service) getUpload(id string) (*upload, error) {
+	u, ok := s.uploads.Load(id)
+	if !ok {
+		return nil, fmt.Errorf("upload %s not found", id)
+	}
+	return u.(*upload), nil
+}
+
+func (s *service) PutChunk(args PutChunkArgs, _ *Empty) error {
+	u, err := s.getUpload(args.UploadID)
+	if err != nil {
+		return err
+	}
+	if _, err = u.w.Write(args.Data); err != nil {
+		<-u.done
+		if u.err != nil {
+			return u.err
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) PutFinish(args PutEndArgs, reply *ObjReply) error {
+	u, err := s.getUpload(args.UploadID)
+	if err != nil {
+		return err
+	}
+	defer s.uploads.Delete(args.UploadID)
+	_ = u.w.Close()
+	<-u.done
+	u.cancel()
+	reply.Obj = u.obj
+	return u.err
+}
+
+func (s *service) PutAbort(args PutEndArgs, _ *Empty) error {
+	u, err := s.getUpload(args.UploadID)
+	if err != nil {
+		return err
+	}
+	defer s.uploads.Delete(args.UploadID)
+	u.cancel()
+	_ = u.w.CloseWithError(context.Canceled)
+	<-u.done
+	return nil
+}
@@ -0,0 +1,144 @@
+package common
+
+import (
+	"net"
+	"net/url"
+	stdpath "path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetAccessRule returns the access rule of the downloads of the file, the rule of the nearest meta
+// applies to the files in its path, or in its sub paths with ASub, otherwise the rule of the storage does
+func GetAccessRule(path string) *model.AccessRule {
+	meta, err := op.GetNearestMeta(path)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		log.Warnf("failed get meta of %s: %+v", path, err)
+	}
+	// the meta should apply to the parent of the file
+	if meta != nil && !meta.AccessRule.IsEmpty() && IsApply(meta.Path, stdpath.Dir(path), meta.ASub) {
+		return &meta.AccessRule
+	}
+	if storage := op.GetBalancedStorage(path); storage != nil && !storage.GetStorage().AccessRule.IsEmpty() {
+		return &storage.GetStorage().AccessRule
+	}
+	return nil
+}
+
+// BindSignData binds the data to be signed to the ip prefix of the client if the rule asks
+func BindSignData(data string, rule *model.AccessRule, clientIP string) string {
+	if rule == nil || rule.BindIP <= 0 {
+		return data
+	}
+	return data + "\n" + ipPrefix(clientIP, rule.BindIP)
+}
+
+func ipPrefix(clientIP string, bits int) string {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return clientIP
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(min(bits, 32), 32)).String()
+	}
+	// the ipv6 clients usually get a whole /64, the addresses in it change often
+	return ip.Mask(net.CIDRMask(64, 128)).String()
+}
+
+// CheckAccess checks the referer and the user agent of a download, the reason is returned if it's denied
+func CheckAccess(rule *model.AccessRule, referer, host, userAgent string) error {
+	if rule == nil {
+		return nil
+	}
+	if userAgent != "" {
+		for _, re := range compileUADeny(rule.UADeny) {
+			if re.MatchString(userAgent) {
+				return errors.New("user agent is not allowed")
+			}
+		}
+	}
+	if referer != "" && strings.TrimSpace(rule.RefererAllow) != "" {
+		u, err := url.Parse(referer)
+		if err != nil || !refererAllowed(rule.RefererAllow, u.Hostname(), host) {
+			return errors.New("referer is not allowed")
+		}
+	}
+	return nil
+}
+
+func refererAllowed(allow, refererHost, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	// the pages of the site itself
+	if strings.EqualFold(refererHost, host) {
+		return true
+	}
+	for _, pattern := range strings.Split(allow, "\n") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if ok, _ := stdpath.Match(pattern, strings.ToLower(refererHost)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var uaDenyCache sync.Map
+
+func compileUADeny(uaDeny string) []*regexp.Regexp {
+	if uaDeny == "" {
+		return nil
+	}
+	if v, ok := uaDenyCache.Load(uaDeny); ok {
+		return v.([]*regexp.Regexp)
+	}
+	var res []*regexp.Regexp
+	for _, line := range strings.Split(uaDeny, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + line)
+		if err != nil {
+			log.Warnf("invalid user agent pattern %s: %+v", line, err)
+			continue
+		}
+		res = append(res, re)
+	}
+	uaDenyCache.Store(uaDeny, res)
+	return res
+}
+
+var (
+	connsMu sync.Mutex
+	conns   = make(map[string]int)
+)
+
+// AcquireConn takes a connection of the link, false is returned if it has max connections,
+// the connection taken must be released
+func AcquireConn(link string, max int) bool {
+	connsMu.Lock()
+	defer connsMu.Unlock()
+	if conns[link] >= max {
+		return false
+	}
+	conns[link]++
+	return true
+}
+
+func ReleaseConn(link string) {
+	connsMu.Lock()
+	defer connsMu.Unlock()
+	if conns[link]--; conns[link] <= 0 {
+		delete(conns, link)
+	}
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestCheckAccess(t *testing.T) {
+	rule := &model.AccessRule{
+		RefererAllow: "*.example.com\nfriend.org",
+		UADeny:       "curl\n^Wget/",
+	}
+	testCases := []struct {
+		referer, userAgent string
+		allowed            bool
+	}{
+		{"", "Mozilla/5.0", true},
+		{"https://www.example.com/page", "Mozilla/5.0", true},
+		{"https://friend.org/", "Mozilla/5.0", true},
+		{"https://alist.local:5244/dir", "Mozilla/5.0", true},
+		{"https://evil.com/", "Mozilla/5.0", false},
+		{"https://example.com.evil.com/", "Mozilla/5.0", false},
+		{"", "curl/8.0", false},
+		{"", "wget/1.21", false},
+	}
+	for _, tc := range testCases {
+		err := CheckAccess(rule, tc.referer, "alist.local:5244", tc.userAgent)
+		if (err == nil) != tc.allowed {
+			t.Errorf("%q %q: got %v", tc.referer, tc.userAgent, err)
+		}
+	}
+
+	rule = &model.AccessRule{BindIP: 24}
+	if BindSignData("/a", rule, "192.168.1.7") != BindSignData("/a", rule, "192.168.1.200") ||
+		BindSignData("/a", rule, "192.168.1.7") == BindSignData("/a", rule, "192.168.2.7") {
+		t.Error("the signs should be bound to the /24 of the clients")
+	}
+	if BindSignData("/a", rule, "2001:db8::1") != BindSignData("/a", rule, "2001:db8::2") {
+		t.Error("the signs should be bound to the /64 of the ipv6 clients")
+	}
+	rule = &model.AccessRule{BindIP: 32}
+	if BindSignData("/a", rule, "2001:db8::1") != BindSignData("/a", rule, "2001:db8::2") ||
+		BindSignData("/a", rule, "2001:db8::1") == BindSignData("/a", rule, "2001:db8:0:1::1") {
+		t.Error("the ipv6 clients should be bound to their /64 whatever the ipv4 prefix length")
+	}
+
+	if !AcquireConn("/a", 1) || AcquireConn("/a", 1) {
+		t.Fatal("the link should have one connection only")
+	}
+	ReleaseConn("/a")
+	if !AcquireConn("/a", 1) {
+		t.Error("the connection released should be taken again")
+	}
+	ReleaseConn("/a")
+}
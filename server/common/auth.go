@@ -3,10 +3,12 @@ package common
 import (
 	"time"
 
-	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
@@ -16,35 +18,47 @@ type UserClaims struct {
 	Username string `json:"username"`
 	PwdTS    int64  `json:"pwd_ts"`
 	jwt.RegisteredClaims
+	// Session is filled by ParseToken
+	Session *model.Session `json:"-"`
 }
 
-var validTokenCache = cache.NewMemCache[bool]()
-
-func GenerateToken(user *model.User) (tokenString string, err error) {
+// GenerateToken signs a login token for the user and stores its session,
+// method is how the user logged in
+func GenerateToken(c *gin.Context, user *model.User, method string) (tokenString string, err error) {
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour)
 	claim := UserClaims{
 		Username: user.Username,
 		PwdTS:    user.PwdTS,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		}}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
 	tokenString, err = token.SignedString(SecretKey)
 	if err != nil {
 		return "", err
 	}
-	validTokenCache.Set(tokenString, true)
-	return tokenString, err
+	err = op.CreateSession(&model.Session{
+		ID:        claim.ID,
+		UserID:    user.ID,
+		Method:    method,
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return tokenString, nil
 }
 
-func ParseToken(tokenString string) (*UserClaims, error) {
+func parseClaims(tokenString string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return SecretKey, nil
 	})
-	if IsTokenInvalidated(tokenString) {
-		return nil, errors.New("token is invalidated")
-	}
 	if err != nil {
 		if ve, ok := err.(*jwt.ValidationError); ok {
 			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
@@ -64,15 +78,30 @@ func ParseToken(tokenString string) (*UserClaims, error) {
 	return nil, errors.New("couldn't handle this token")
 }
 
+// ParseToken validates the token and checks that its session is not revoked
+func ParseToken(tokenString string) (*UserClaims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, errors.New("token is invalidated")
+	}
+	session, err := op.GetSession(claims.ID)
+	if err != nil {
+		return nil, errors.New("token is invalidated")
+	}
+	claims.Session = session
+	return claims, nil
+}
+
 func InvalidateToken(tokenString string) error {
 	if tokenString == "" {
 		return nil // don't invalidate empty guest token
 	}
-	validTokenCache.Del(tokenString)
-	return nil
-}
-
-func IsTokenInvalidated(tokenString string) bool {
-	_, ok := validTokenCache.Get(tokenString)
-	return !ok
+	claims, err := parseClaims(tokenString)
+	if err != nil || claims.ID == "" {
+		return nil // already unusable
+	}
+	return op.DeleteSessionById(claims.ID)
 }
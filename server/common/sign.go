@@ -2,6 +2,8 @@ package common
 
 import (
 	stdpath "path"
+	"strconv"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -9,9 +11,51 @@ import (
 	"github.com/alist-org/alist/v3/internal/sign"
 )
 
-func Sign(obj model.Obj, parent string, encrypt bool) string {
-	if obj.IsDir() || (!encrypt && !setting.GetBool(conf.SignAll)) {
+func Sign(obj model.Obj, parent string, encrypt bool, clientIP string, user *model.User) string {
+	if obj.IsDir() {
 		return ""
 	}
-	return sign.Sign(stdpath.Join(parent, obj.GetName()))
+	return SignPath(stdpath.Join(parent, obj.GetName()), encrypt, clientIP, user)
+}
+
+// SignPath signs the path for the client, the path is signed if it's encrypted,
+// all are signed, or the rule binds the signs to the clients
+func SignPath(path string, encrypt bool, clientIP string, user *model.User) string {
+	rule := GetAccessRule(path)
+	if !encrypt && !setting.GetBool(conf.SignAll) && (rule == nil || rule.BindIP <= 0) {
+		return ""
+	}
+	return SignUser(BindSignData(path, rule, clientIP), user, sign.Sign)
+}
+
+// SignDownProxy signs the path for the down proxy of the storage, it's bound to the client
+// the same as the signs given by SignPath, so that the down proxy can't be used to skip the rule
+func SignDownProxy(path string, clientIP string) string {
+	return sign.Sign(BindSignData(path, GetAccessRule(path), clientIP))
+}
+
+// SignUser signs the data bound to the user, the id of the user is put before the sign,
+// so that the transfers by the sign are limited by the profile of the user instead of the guest
+func SignUser(data string, user *model.User, signFunc func(string) string) string {
+	if user == nil || user.IsGuest() {
+		return signFunc(data)
+	}
+	id := strconv.FormatUint(uint64(user.ID), 10)
+	return id + "." + signFunc(BindSignUser(data, id))
+}
+
+// BindSignUser binds the data to be signed to the id of the user if it's given
+func BindSignUser(data, userID string) string {
+	if userID == "" {
+		return data
+	}
+	return data + "\nuser " + userID
+}
+
+// SplitSignUser splits the id of the user bound off the sign, there is no dot in the sign itself
+func SplitSignUser(s string) (userID, rest string) {
+	if id, rest, ok := strings.Cut(s, "."); ok {
+		return id, rest
+	}
+	return "", s
 }
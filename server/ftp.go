@@ -5,11 +5,12 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
-	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/loginlimit"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	ftpserver "github.com/alist-org/alist/v3/pkg/ftpserverlib"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/ftp"
 	"math/rand"
@@ -72,7 +73,7 @@ func NewMainDriver() (*FtpMainDriver, error) {
 			DisableLISTArgs:           false,
 			DisableSite:               false,
 			DisableActiveMode:         conf.Conf.FTP.DisableActiveMode,
-			EnableHASH:                false,
+			EnableHASH:                true,
 			DisableSTAT:               false,
 			DisableSYST:               false,
 			EnableCOMB:                false,
@@ -121,14 +122,21 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 			return nil, err
 		}
 	} else {
+		ip := cc.RemoteAddr().String()
+		if err = loginlimit.Check(user, ip); err != nil {
+			return nil, err
+		}
 		userObj, err = op.GetUserByName(user)
 		if err != nil {
+			loginlimit.Fail("ftp", user, ip)
 			return nil, err
 		}
 		passHash := model.StaticHash(pass)
 		if err = userObj.ValidatePwdStaticHash(passHash); err != nil {
+			loginlimit.Fail("ftp", user, ip)
 			return nil, err
 		}
+		loginlimit.Succeed(user)
 	}
 	if userObj.Disabled || !userObj.CanFTPAccess() {
 		return nil, errors.New("user is not allowed to access via FTP")
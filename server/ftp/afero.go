@@ -3,10 +3,10 @@ package ftp
 import (
 	"context"
 	"errors"
-	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	ftpserver "github.com/alist-org/alist/v3/pkg/ftpserverlib"
 	"github.com/spf13/afero"
 	"os"
 	"time"
@@ -86,9 +86,6 @@ func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserve
 	if (flags & os.O_SYNC) != 0 {
 		return nil, errs.NotSupport
 	}
-	if (flags & os.O_APPEND) != 0 {
-		return nil, errs.NotSupport
-	}
 	user := a.ctx.Value("user").(*model.User)
 	path, err := user.JoinPath(name)
 	if err != nil {
@@ -103,8 +100,11 @@ func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserve
 		return nil, errors.New("file already exists")
 	}
 	if (flags & os.O_WRONLY) != 0 {
+		if (flags & os.O_APPEND) != 0 {
+			return OpenResumeUpload(a.ctx, path, -1)
+		}
 		if offset != 0 {
-			return nil, errs.NotSupport
+			return OpenResumeUpload(a.ctx, path, offset)
 		}
 		trunc := (flags & os.O_TRUNC) != 0
 		if fileSize > 0 {
@@ -116,6 +116,10 @@ func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserve
 	return OpenDownload(a.ctx, path, offset)
 }
 
+func (a *AferoAdapter) ComputeHash(name string, algo ftpserver.HASHAlgo, startOffset, endOffset int64) (string, error) {
+	return ComputeHash(a.ctx, name, algo, startOffset, endOffset)
+}
+
 func (a *AferoAdapter) SetNextFileSize(size int64) {
 	a.nextFileSize = size
 }
@@ -2,26 +2,33 @@ package ftp
 
 import (
 	"context"
-	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/internal/blockcache"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/stream"
+	ftpserver "github.com/alist-org/alist/v3/pkg/ftpserverlib"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/pkg/errors"
 	fs2 "io/fs"
 	"net/http"
 	"os"
+	stdpath "path"
 	"time"
 )
 
 type FileDownloadProxy struct {
 	ftpserver.FileTransfer
-	reader stream.SStreamReadAtSeeker
+	reader   stream.SStreamReadAtSeeker
+	transfer *bandwidth.Transfer
+	// held is set if the transfer belongs to an upload, it's released by the upload
+	held bool
 }
 
-func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownloadProxy, error) {
+func downloadAuth(ctx context.Context, reqPath string) (context.Context, error) {
 	user := ctx.Value("user").(*model.User)
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -33,7 +40,43 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 	if !common.CanAccess(user, meta, reqPath, ctx.Value("meta_pass").(string)) {
 		return nil, errs.PermissionDenied
 	}
+	return ctx, nil
+}
+
+func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownloadProxy, error) {
+	ctx, err := downloadAuth(ctx, reqPath)
+	if err != nil {
+		return nil, err
+	}
+	transfer, err := bandwidth.Acquire(ctx.Value("user").(*model.User), bandwidth.Download)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := openDownload(ctx, reqPath, offset)
+	if err != nil {
+		transfer.Release()
+		return nil, err
+	}
+	ret.transfer = transfer
+	return ret, nil
+}
 
+// OpenDownloadIn reads the file within the transfer of an upload of it, such as a resumed or an in-place one,
+// so that the user doesn't need a second transfer for it. The transfer is not released on close
+func OpenDownloadIn(ctx context.Context, reqPath string, offset int64, transfer *bandwidth.Transfer) (*FileDownloadProxy, error) {
+	ctx, err := downloadAuth(ctx, reqPath)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := openDownload(ctx, reqPath, offset)
+	if err != nil {
+		return nil, err
+	}
+	ret.transfer, ret.held = transfer, true
+	return ret, nil
+}
+
+func openDownload(ctx context.Context, reqPath string, offset int64) (*FileDownloadProxy, error) {
 	// directly use proxy
 	header := *(ctx.Value("proxy_header").(*http.Header))
 	link, obj, err := fs.Link(ctx, reqPath, model.LinkArgs{
@@ -47,7 +90,7 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 		Obj: obj,
 		Ctx: ctx,
 	}
-	ss, err := stream.NewSeekableStream(fileStream, link)
+	ss, err := stream.NewSeekableStream(fileStream, blockcache.Wrap(reqPath, obj, link))
 	if err != nil {
 		return nil, err
 	}
@@ -64,10 +107,25 @@ func (f *FileDownloadProxy) Read(p []byte) (n int, err error) {
 	if err != nil {
 		return
 	}
-	err = stream.ClientDownloadLimit.WaitN(f.reader.GetRawStream().Ctx, n)
+	err = f.transfer.WaitN(f.reader.GetRawStream().Ctx, n)
+	return
+}
+
+// ReadAt is not safe for concurrent use, since the underlying readers are shared
+func (f *FileDownloadProxy) ReadAt(p []byte, off int64) (n int, err error) {
+	n, err = f.reader.ReadAt(p, off)
+	if n > 0 {
+		if e := f.transfer.WaitN(f.reader.GetRawStream().Ctx, n); e != nil {
+			return n, e
+		}
+	}
 	return
 }
 
+func (f *FileDownloadProxy) Size() int64 {
+	return f.reader.GetRawStream().GetSize()
+}
+
 func (f *FileDownloadProxy) Write(p []byte) (n int, err error) {
 	return 0, errs.NotSupport
 }
@@ -77,11 +135,17 @@ func (f *FileDownloadProxy) Seek(offset int64, whence int) (int64, error) {
 }
 
 func (f *FileDownloadProxy) Close() error {
+	if !f.held {
+		f.transfer.Release()
+	}
 	return f.reader.Close()
 }
 
+// OsFileInfoAdapter is what MLSD and MLST list, besides the type, size and modify facts
+// it gives the create and unique facts by ftpserver.FileInfoExtensionMLSx
 type OsFileInfoAdapter struct {
-	obj model.Obj
+	obj  model.Obj
+	path string
 }
 
 func (o *OsFileInfoAdapter) Name() string {
@@ -100,18 +164,38 @@ func (o *OsFileInfoAdapter) Mode() fs2.FileMode {
 	return mode
 }
 
+// ModTime is the create time if the storage gives no modified time,
+// the mirroring clients compare it to skip the unchanged files
 func (o *OsFileInfoAdapter) ModTime() time.Time {
-	return o.obj.ModTime()
+	if t := o.obj.ModTime(); !t.IsZero() {
+		return t
+	}
+	return o.obj.CreateTime()
 }
 
 func (o *OsFileInfoAdapter) IsDir() bool {
 	return o.obj.IsDir()
 }
 
+func (o *OsFileInfoAdapter) CreateTime() time.Time {
+	return o.obj.CreateTime()
+}
+
+// UniqueID is derived from the id the storage gives, or the path if there is none,
+// it's hashed since the fact can't hold spaces or semicolons
+func (o *OsFileInfoAdapter) UniqueID() string {
+	if id := o.obj.GetID(); id != "" {
+		return utils.GetMD5EncodeStr(id)
+	}
+	return utils.GetMD5EncodeStr(o.path)
+}
+
 func (o *OsFileInfoAdapter) Sys() any {
 	return o.obj
 }
 
+var _ ftpserver.FileInfoExtensionMLSx = (*OsFileInfoAdapter)(nil)
+
 func Stat(ctx context.Context, path string) (os.FileInfo, error) {
 	user := ctx.Value("user").(*model.User)
 	reqPath, err := user.JoinPath(path)
@@ -132,7 +216,7 @@ func Stat(ctx context.Context, path string) (os.FileInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &OsFileInfoAdapter{obj: obj}, nil
+	return &OsFileInfoAdapter{obj: obj, path: reqPath}, nil
 }
 
 func List(ctx context.Context, path string) ([]os.FileInfo, error) {
@@ -157,7 +241,7 @@ func List(ctx context.Context, path string) ([]os.FileInfo, error) {
 	}
 	ret := make([]os.FileInfo, len(objs))
 	for i, obj := range objs {
-		ret[i] = &OsFileInfoAdapter{obj: obj}
+		ret[i] = &OsFileInfoAdapter{obj: obj, path: stdpath.Join(reqPath, obj.GetName())}
 	}
 	return ret, nil
 }
@@ -0,0 +1,132 @@
+package ftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// partialExpiration is how long the interrupted uploads are kept for resuming
+const partialExpiration = 24 * time.Hour
+
+func partialDir() string {
+	return filepath.Join(conf.Conf.TempDir, "ftp-partial")
+}
+
+func partialPath(ctx context.Context, path string) string {
+	user := ctx.Value("user").(*model.User)
+	return filepath.Join(partialDir(), utils.GetMD5EncodeStr(fmt.Sprintf("%d:%s", user.ID, path)))
+}
+
+// stagePartial keeps the received part of an interrupted upload in the temp dir
+func stagePartial(ctx context.Context, path string, file *os.File) error {
+	_ = file.Close()
+	if err := os.MkdirAll(partialDir(), 0o700); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	cleanPartials()
+	if err := os.Rename(file.Name(), partialPath(ctx, path)); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+func cleanPartials() {
+	entries, err := os.ReadDir(partialDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < partialExpiration {
+			continue
+		}
+		if err = os.Remove(filepath.Join(partialDir(), entry.Name())); err != nil {
+			log.Warnf("failed to remove expired partial upload %s: %+v", entry.Name(), err)
+		}
+	}
+}
+
+// OpenResumeUpload continues an upload from the offset given by REST,
+// or from the end of the file if the offset is negative, which is how APPE works.
+// The content before the offset is taken from the interrupted upload staged before,
+// or from the file itself if an upload was committed before it completed.
+func OpenResumeUpload(ctx context.Context, path string, offset int64) (*FileUploadProxy, error) {
+	err := UploadAuth(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	transfer, err := bandwidth.Acquire(ctx.Value("user").(*model.User), bandwidth.Upload)
+	if err != nil {
+		return nil, err
+	}
+	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
+	if err != nil {
+		transfer.Release()
+		return nil, err
+	}
+	fail := func(err error) (*FileUploadProxy, error) {
+		transfer.Release()
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return nil, err
+	}
+	staged := partialPath(ctx, path)
+	if info, e := os.Stat(staged); e == nil && info.Size() >= offset {
+		_ = tmpFile.Close()
+		if err = os.Rename(staged, tmpFile.Name()); err != nil {
+			return fail(err)
+		}
+		if tmpFile, err = os.OpenFile(tmpFile.Name(), os.O_RDWR, 0); err != nil {
+			return fail(err)
+		}
+	} else {
+		obj, e := fs.Get(ctx, path, &fs.GetArgs{})
+		size := int64(0)
+		if e == nil {
+			size = obj.GetSize()
+		}
+		if offset < 0 {
+			offset = size
+		}
+		if size < offset {
+			return fail(errors.Errorf("nothing to resume at offset %d", offset))
+		}
+		if offset > 0 {
+			proxy, err := OpenDownloadIn(ctx, path, 0, transfer)
+			if err != nil {
+				return fail(err)
+			}
+			_, err = io.CopyN(tmpFile, proxy, offset)
+			_ = proxy.Close()
+			if err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if offset < 0 {
+		if offset, err = tmpFile.Seek(0, io.SeekEnd); err != nil {
+			return fail(err)
+		}
+	}
+	if err = tmpFile.Truncate(offset); err != nil {
+		return fail(err)
+	}
+	if _, err = tmpFile.Seek(offset, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return &FileUploadProxy{buffer: tmpFile, path: path, ctx: ctx, trunc: true, transfer: transfer}, nil
+}
@@ -3,13 +3,14 @@ package ftp
 import (
 	"bytes"
 	"context"
-	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
+	"github.com/alist-org/alist/v3/internal/bandwidth"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/stream"
+	ftpserver "github.com/alist-org/alist/v3/pkg/ftpserverlib"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/pkg/errors"
 	"io"
@@ -21,13 +22,16 @@ import (
 
 type FileUploadProxy struct {
 	ftpserver.FileTransfer
-	buffer *os.File
-	path   string
-	ctx    context.Context
-	trunc  bool
+	buffer   *os.File
+	path     string
+	ctx      context.Context
+	trunc    bool
+	failed   bool
+	transfer *bandwidth.Transfer
 }
 
-func uploadAuth(ctx context.Context, path string) error {
+// UploadAuth checks whether the user in ctx can write the file of the path
+func UploadAuth(ctx context.Context, path string) error {
 	user := ctx.Value("user").(*model.User)
 	meta, err := op.GetNearestMeta(stdpath.Dir(path))
 	if err != nil {
@@ -43,15 +47,20 @@ func uploadAuth(ctx context.Context, path string) error {
 }
 
 func OpenUpload(ctx context.Context, path string, trunc bool) (*FileUploadProxy, error) {
-	err := uploadAuth(ctx, path)
+	err := UploadAuth(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	transfer, err := bandwidth.Acquire(ctx.Value("user").(*model.User), bandwidth.Upload)
 	if err != nil {
 		return nil, err
 	}
 	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
 	if err != nil {
+		transfer.Release()
 		return nil, err
 	}
-	return &FileUploadProxy{buffer: tmpFile, path: path, ctx: ctx, trunc: trunc}, nil
+	return &FileUploadProxy{buffer: tmpFile, path: path, ctx: ctx, trunc: trunc, transfer: transfer}, nil
 }
 
 func (f *FileUploadProxy) Read(p []byte) (n int, err error) {
@@ -63,7 +72,7 @@ func (f *FileUploadProxy) Write(p []byte) (n int, err error) {
 	if err != nil {
 		return
 	}
-	err = stream.ClientUploadLimit.WaitN(f.ctx, n)
+	err = f.transfer.WaitN(f.ctx, n)
 	return
 }
 
@@ -71,7 +80,17 @@ func (f *FileUploadProxy) Seek(offset int64, whence int) (int64, error) {
 	return f.buffer.Seek(offset, whence)
 }
 
+// TransferError is called when the data connection breaks,
+// the received part is then kept for a later REST resume instead of being uploaded
+func (f *FileUploadProxy) TransferError(err error) {
+	f.failed = true
+}
+
 func (f *FileUploadProxy) Close() error {
+	f.transfer.Release()
+	if f.failed {
+		return stagePartial(f.ctx, f.path, f.buffer)
+	}
 	dir, name := stdpath.Split(f.path)
 	size, err := f.buffer.Seek(0, io.SeekCurrent)
 	if err != nil {
@@ -102,7 +121,7 @@ func (f *FileUploadProxy) Close() error {
 	}
 	s.SetTmpFile(f.buffer)
 	_, err = fs.PutAsTask(f.ctx, dir, s)
-	return err
+	return convertPutErr(err)
 }
 
 type FileUploadWithLengthProxy struct {
@@ -114,17 +133,22 @@ type FileUploadWithLengthProxy struct {
 	pFirst        int
 	pipeWriter    io.WriteCloser
 	errChan       chan error
+	transfer      *bandwidth.Transfer
 }
 
 func OpenUploadWithLength(ctx context.Context, path string, trunc bool, length int64) (*FileUploadWithLengthProxy, error) {
-	err := uploadAuth(ctx, path)
+	err := UploadAuth(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	transfer, err := bandwidth.Acquire(ctx.Value("user").(*model.User), bandwidth.Upload)
 	if err != nil {
 		return nil, err
 	}
 	if trunc {
 		_ = fs.Remove(ctx, path)
 	}
-	return &FileUploadWithLengthProxy{ctx: ctx, path: path, length: length}, nil
+	return &FileUploadWithLengthProxy{ctx: ctx, path: path, length: length, transfer: transfer}, nil
 }
 
 func (f *FileUploadWithLengthProxy) Read(p []byte) (n int, err error) {
@@ -161,7 +185,7 @@ func (f *FileUploadWithLengthProxy) write(p []byte) (n int, err error) {
 		}
 		go func() {
 			e := fs.PutDirectly(f.ctx, dir, s, true)
-			f.errChan <- e
+			f.errChan <- convertPutErr(e)
 			close(f.errChan)
 		}()
 		f.pipeWriter = writer
@@ -183,7 +207,7 @@ func (f *FileUploadWithLengthProxy) Write(p []byte) (n int, err error) {
 	if err != nil {
 		return
 	}
-	err = stream.ClientUploadLimit.WaitN(f.ctx, n)
+	err = f.transfer.WaitN(f.ctx, n)
 	return
 }
 
@@ -192,6 +216,7 @@ func (f *FileUploadWithLengthProxy) Seek(offset int64, whence int) (int64, error
 }
 
 func (f *FileUploadWithLengthProxy) Close() error {
+	defer f.transfer.Release()
 	if f.pipeWriter != nil {
 		err := f.pipeWriter.Close()
 		if err != nil {
@@ -213,6 +238,14 @@ func (f *FileUploadWithLengthProxy) Close() error {
 			WebPutAsTask: false,
 			Reader:       bytes.NewReader(data),
 		}
-		return fs.PutDirectly(f.ctx, dir, s, true)
+		return convertPutErr(fs.PutDirectly(f.ctx, dir, s, true))
 	}
 }
+
+// convertPutErr maps the quota error to the one ftpserverlib replies 552 with
+func convertPutErr(err error) error {
+	if errors.Is(err, errs.QuotaExceeded) {
+		return ftpserver.ErrStorageExceeded
+	}
+	return err
+}
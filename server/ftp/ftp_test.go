@@ -0,0 +1,188 @@
+package ftp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	ftpserver "github.com/alist-org/alist/v3/pkg/ftpserverlib"
+	"github.com/xhofe/tache"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+	fs.UploadTaskManager = tache.NewManager[*fs.UploadTask](tache.WithWorks(1))
+}
+
+// newContext mounts a local storage at /local for a user limited to one transfer at a time
+func newContext(t *testing.T) (context.Context, string) {
+	conf.Conf.TempDir = t.TempDir()
+	dir := t.TempDir()
+	id, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"mkdir_perm":"777","recycle_bin_path":"delete permanently"}`, dir),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(context.Background(), id)
+	})
+	p := &model.LimitProfile{Name: t.Name(), MaxTransfers: 1}
+	if err = op.CreateLimitProfile(p); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "user", &model.User{ID: 500, Role: model.ADMIN, BasePath: "/", Permission: 0xffff, LimitProfileID: p.ID})
+	ctx = context.WithValue(ctx, "meta_pass", "")
+	ctx = context.WithValue(ctx, "client_ip", "127.0.0.1")
+	ctx = context.WithValue(ctx, "proxy_header", &http.Header{})
+	return ctx, dir
+}
+
+// waitContent waits for the upload task to write the file
+func waitContent(t *testing.T, path, want string) {
+	t.Helper()
+	var got []byte
+	for i := 0; i < 100; i++ {
+		got, _ = os.ReadFile(path)
+		if string(got) == want {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("got %q, want %q", got, want)
+}
+
+func TestResumeUpload(t *testing.T) {
+	ctx, dir := newContext(t)
+	write := func(f *FileUploadProxy, s string) {
+		t.Helper()
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// STOR broken by the data connection, then REST 6 + STOR
+	f, err := OpenUpload(ctx, "/local/a.txt", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(f, "hello ")
+	f.TransferError(fmt.Errorf("connection reset"))
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = OpenResumeUpload(ctx, "/local/a.txt", 6); err != nil {
+		t.Fatalf("failed resume: %+v", err)
+	}
+	write(f, "world")
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitContent(t, filepath.Join(dir, "a.txt"), "hello world")
+
+	// APPE reads the stored file within the transfer of the upload
+	if f, err = OpenResumeUpload(ctx, "/local/a.txt", -1); err != nil {
+		t.Fatalf("failed append: %+v", err)
+	}
+	write(f, "!")
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitContent(t, filepath.Join(dir, "a.txt"), "hello world!")
+
+	if _, err = OpenResumeUpload(ctx, "/local/a.txt", 100); err == nil {
+		t.Error("resumed past the end of the file")
+	}
+	// the transfer of the refused resume is released
+	if f, err = OpenUpload(ctx, "/local/b.txt", true); err != nil {
+		t.Fatalf("the transfer is kept: %+v", err)
+	}
+	_ = f.Close()
+}
+
+func TestComputeHash(t *testing.T) {
+	ctx, dir := newContext(t)
+	if err := os.WriteFile(filepath.Join(dir, "h.txt"), []byte("hello world"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		algo       ftpserver.HASHAlgo
+		start, end int64
+		want       string
+	}{
+		// XCRC
+		{ftpserver.HASHAlgoCRC32, 0, 11, "0d4a1185"},
+		{ftpserver.HASHAlgoMD5, 0, 11, "5eb63bbbe01eeed093cb22bb8f5acdc3"},
+		// a range is hashed on demand
+		{ftpserver.HASHAlgoMD5, 6, 11, "7d793037a0760186574b0282f2f435e7"},
+		{ftpserver.HASHAlgoSHA1, 0, 5, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{ftpserver.HASHAlgoSHA256, 0, 11, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"},
+	}
+	for _, c := range cases {
+		got, err := ComputeHash(ctx, "/local/h.txt", c.algo, c.start, c.end)
+		if err != nil {
+			t.Fatalf("failed hash %v: %+v", c.algo, err)
+		}
+		if got != c.want {
+			t.Errorf("hash %v [%d, %d): got %s, want %s", c.algo, c.start, c.end, got, c.want)
+		}
+	}
+	// the transfer of every hash is released
+	f, err := OpenUpload(ctx, "/local/b.txt", true)
+	if err != nil {
+		t.Fatalf("the transfer is kept: %+v", err)
+	}
+	_ = f.Close()
+}
+
+func TestMLSxFacts(t *testing.T) {
+	ctx, dir := newContext(t)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	infos, err := List(ctx, "/local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unique := make(map[string]string)
+	for _, info := range infos {
+		ext, ok := info.(ftpserver.FileInfoExtensionMLSx)
+		if !ok {
+			t.Fatal("no create and unique facts")
+		}
+		unique[info.Name()] = ext.UniqueID()
+	}
+	if len(unique) != 2 || unique["a.txt"] == "" || unique["a.txt"] == unique["b.txt"] {
+		t.Fatalf("the unique facts don't tell the files apart: %v", unique)
+	}
+	// MLST gives the same fact as MLSD
+	info, err := Stat(ctx, "/local/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.(ftpserver.FileInfoExtensionMLSx).UniqueID(); got != unique["a.txt"] {
+		t.Errorf("the unique fact of MLST is %s, of MLSD is %s", got, unique["a.txt"])
+	}
+}
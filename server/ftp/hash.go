@@ -0,0 +1,65 @@
+package ftp
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	ftpserver "github.com/alist-org/alist/v3/pkg/ftpserverlib"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// ComputeHash serves HASH, XCRC, XMD5 and XSHA*, the hash reported by the storage
+// is used if the whole file is requested, otherwise the content is hashed on demand
+func ComputeHash(ctx context.Context, path string, algo ftpserver.HASHAlgo, start, end int64) (string, error) {
+	var ht *utils.HashType
+	var newHash func() hash.Hash
+	switch algo {
+	case ftpserver.HASHAlgoCRC32:
+		newHash = func() hash.Hash { return crc32.NewIEEE() }
+	case ftpserver.HASHAlgoMD5:
+		ht = utils.MD5
+	case ftpserver.HASHAlgoSHA1:
+		ht = utils.SHA1
+	case ftpserver.HASHAlgoSHA256:
+		ht = utils.SHA256
+	case ftpserver.HASHAlgoSHA512:
+		newHash = sha512.New
+	default:
+		return "", errs.NotSupport
+	}
+	info, err := Stat(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if obj, ok := info.Sys().(model.Obj); ok && ht != nil && start == 0 && end == obj.GetSize() {
+		if h := obj.GetHash().GetHash(ht); h != "" {
+			return strings.ToLower(h), nil
+		}
+	}
+	if ht != nil {
+		newHash = func() hash.Hash { return ht.NewFunc() }
+	}
+	user := ctx.Value("user").(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return "", err
+	}
+	proxy, err := OpenDownload(ctx, reqPath, start)
+	if err != nil {
+		return "", err
+	}
+	defer proxy.Close()
+	h := newHash()
+	if _, err = io.CopyN(h, proxy, end-start); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
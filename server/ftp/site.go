@@ -2,7 +2,7 @@ package ftp
 
 import (
 	"fmt"
-	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
+	ftpserver "github.com/alist-org/alist/v3/pkg/ftpserverlib"
 	"strconv"
 )
 
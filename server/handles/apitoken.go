@@ -0,0 +1,143 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenCreateReq struct {
+	Name       string `json:"name" binding:"required"`
+	Path       string `json:"path"`
+	Permission int32  `json:"permission"`
+	ExpiresIn  int    `json:"expires_in"` // hours, 0 means never
+}
+
+type APITokenCreateResp struct {
+	model.APIToken
+	Token     string `json:"token"`
+	SecretKey string `json:"secret_key"`
+}
+
+func CreateMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req APITokenCreateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, "request invalid", 400)
+		return
+	}
+	if req.ExpiresIn < 0 {
+		common.ErrorStrResp(c, "expires_in invalid", 400)
+		return
+	}
+	t := &model.APIToken{
+		UserID:     userObj.ID,
+		Name:       req.Name,
+		Path:       req.Path,
+		Permission: req.Permission,
+	}
+	if req.ExpiresIn > 0 {
+		t.ExpiresAt = time.Now().Add(time.Duration(req.ExpiresIn) * time.Hour)
+	}
+	// validate the path before storing
+	if _, err := t.Scope(userObj); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	raw, err := op.CreateAPIToken(t)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	// the token and the secret key are only shown once
+	common.SuccessResp(c, APITokenCreateResp{
+		APIToken:  *t,
+		Token:     raw,
+		SecretKey: op.APITokenSecretKey(t),
+	})
+}
+
+func ListMyAPITokens(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listAPITokens(c, userObj)
+}
+
+func DeleteMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	t, err := op.GetAPITokenByIdAndUserId(uint(tokenId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get api token", 404)
+		return
+	}
+	if err = op.DeleteAPITokenById(t.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListAPITokens(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	listAPITokens(c, userObj)
+}
+
+func DeleteAPIToken(c *gin.Context) {
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteAPITokenById(uint(tokenId)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func listAPITokens(c *gin.Context, userObj *model.User) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetAPITokensByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tokens,
+		Total:   total,
+	})
+}
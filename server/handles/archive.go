@@ -120,8 +120,9 @@ func FsArchiveMeta(c *gin.Context) {
 		return
 	}
 	s := ""
-	if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) {
-		s = sign.SignArchive(reqPath)
+	rule := common.GetAccessRule(reqPath)
+	if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) || rule != nil && rule.BindIP > 0 {
+		s = common.SignUser(common.BindSignData(reqPath, rule, c.ClientIP()), user, sign.SignArchive)
 	}
 	api := "/ae"
 	if ret.DriverProviding {
@@ -356,7 +357,7 @@ func ArchiveProxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
-		localProxy(c, link, file, storage.GetStorage().ProxyRange)
+		localProxy(c, link, file, storage.GetStorage().ProxyRange, "")
 	} else {
 		common.ErrorStrResp(c, "proxy not allowed", 403)
 		return
@@ -4,9 +4,8 @@ import (
 	"bytes"
 	"encoding/base64"
 	"image/png"
-	"time"
 
-	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/loginlimit"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -14,12 +13,6 @@ import (
 	"github.com/pquerna/otp/totp"
 )
 
-var loginCache = cache.NewMemCache[int]()
-var (
-	defaultDuration = time.Minute * 5
-	defaultTimes    = 5
-)
-
 type LoginReq struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password"`
@@ -50,46 +43,45 @@ func LoginHash(c *gin.Context) {
 func loginHash(c *gin.Context, req *LoginReq) {
 	// check count of login
 	ip := c.ClientIP()
-	count, ok := loginCache.Get(ip)
-	if ok && count >= defaultTimes {
-		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
-		loginCache.Expire(ip, defaultDuration)
+	if err := loginlimit.Check(req.Username, ip); err != nil {
+		common.ErrorResp(c, err, 429)
 		return
 	}
 	// check username
 	user, err := op.GetUserByName(req.Username)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		loginlimit.Fail("http", req.Username, ip)
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		loginlimit.Fail("http", req.Username, ip)
 		return
 	}
 	// check 2FA
 	if user.OtpSecret != "" {
 		if !totp.Validate(req.OtpCode, user.OtpSecret) {
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
-			loginCache.Set(ip, count+1)
+			loginlimit.Fail("http", req.Username, ip)
 			return
 		}
 	}
 	// generate token
-	token, err := common.GenerateToken(user)
+	token, err := common.GenerateToken(c, user, model.LoginPassword)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
-	loginCache.Del(ip)
+	loginlimit.Succeed(req.Username)
 }
 
 type UserResp struct {
 	model.User
-	Otp bool `json:"otp"`
+	Otp  bool  `json:"otp"`
+	Used int64 `json:"used"`
 }
 
 // CurrentUser get current user by token
@@ -103,6 +95,9 @@ func CurrentUser(c *gin.Context) {
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
 	}
+	if usage, err := op.GetUserUsage(user.ID); err == nil {
+		userResp.Used = usage.Used
+	}
 	common.SuccessResp(c, userResp)
 }
 
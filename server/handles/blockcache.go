@@ -0,0 +1,26 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/blockcache"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func BlockCacheStats(c *gin.Context) {
+	cache := blockcache.Instance()
+	if cache == nil {
+		common.ErrorStrResp(c, "block cache is not initialized", 400)
+		return
+	}
+	common.SuccessResp(c, cache.Stats())
+}
+
+func PurgeBlockCache(c *gin.Context) {
+	cache := blockcache.Instance()
+	if cache == nil {
+		common.ErrorStrResp(c, "block cache is not initialized", 400)
+		return
+	}
+	cache.Purge()
+	common.SuccessResp(c)
+}
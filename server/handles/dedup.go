@@ -0,0 +1,110 @@
+package handles
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/dedup"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type DedupScanReq struct {
+	Paths   []string `json:"paths"`
+	MinSize int64    `json:"min_size"`
+}
+
+func DedupScan(c *gin.Context) {
+	var req DedupScanReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if dedup.Running() {
+		common.ErrorStrResp(c, "finding duplicates is running", 400)
+		return
+	}
+	if len(req.Paths) == 0 {
+		req.Paths = []string{"/"}
+	}
+	go func() {
+		err := dedup.Scan(context.Background(), req.Paths, req.MinSize)
+		if err != nil {
+			log.Errorf("find duplicates error: %+v", err)
+		}
+	}()
+	common.SuccessResp(c)
+}
+
+func DedupStop(c *gin.Context) {
+	quit := dedup.Quit.Load()
+	if quit == nil {
+		common.ErrorStrResp(c, "finding duplicates is not running", 400)
+		return
+	}
+	select {
+	case *quit <- struct{}{}:
+	default:
+	}
+	common.SuccessResp(c)
+}
+
+func DedupProgress(c *gin.Context) {
+	progress, err := dedup.Progress()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, progress)
+}
+
+func ListDuplicates(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	groups, total, err := dedup.GetGroups(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: groups,
+		Total:   total,
+	})
+}
+
+type DedupActionReq struct {
+	Hash  string   `json:"hash" binding:"required"`
+	Keep  string   `json:"keep" binding:"required"`
+	Paths []string `json:"paths" binding:"required"`
+}
+
+func DedupDelete(c *gin.Context) {
+	var req DedupActionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := dedup.Delete(c, req.Hash, req.Keep, req.Paths); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DedupLink(c *gin.Context) {
+	var req DedupActionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := dedup.ReplaceWithLink(c, req.Hash, req.Keep, req.Paths, common.GetApiUrl(c.Request)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -8,12 +8,15 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/blockcache"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/proxypool"
 	"github.com/alist-org/alist/v3/internal/setting"
-	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/internal/thumb"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -58,14 +61,19 @@ func Proxy(c *gin.Context) {
 		return
 	}
 	if canProxy(storage, filename) {
+		if r, ok := c.Get("rendition"); ok {
+			proxyRendition(c, rawPath, r.(*thumb.Rendition))
+			return
+		}
 		downProxyUrl := storage.GetStorage().DownProxyUrl
 		if downProxyUrl != "" {
 			_, ok := c.GetQuery("d")
-			if !ok {
+			// it's proxied here if all the down proxies are down
+			if downProxy := proxypool.Pick(downProxyUrl, rawPath, c.ClientIP()); !ok && downProxy != "" {
 				URL := fmt.Sprintf("%s%s?sign=%s",
-					strings.Split(downProxyUrl, "\n")[0],
+					downProxy,
 					utils.EncodePath(rawPath, true),
-					sign.Sign(rawPath))
+					common.SignDownProxy(rawPath, c.ClientIP()))
 				c.Redirect(302, URL)
 				return
 			}
@@ -79,7 +87,7 @@ func Proxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
-		localProxy(c, link, file, storage.GetStorage().ProxyRange)
+		localProxy(c, link, file, storage.GetStorage().ProxyRange, rawPath)
 	} else {
 		common.ErrorStrResp(c, "proxy not allowed", 403)
 		return
@@ -112,7 +120,29 @@ func down(c *gin.Context, link *model.Link) {
 	c.Redirect(302, link.URL)
 }
 
-func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange bool) {
+// proxyRendition serves a resized or converted image, which is cached
+func proxyRendition(c *gin.Context, rawPath string, r *thumb.Rendition) {
+	obj, err := fs.Get(c, rawPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	p, err := thumb.GetRendition(c, rawPath, obj, r)
+	if err != nil {
+		if errs.IsNotSupportError(err) {
+			common.ErrorResp(c, err, 400)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	c.Header("Content-Type", r.ContentType())
+	c.Header("Etag", fmt.Sprintf(`"%s"`, strings.TrimSuffix(stdpath.Base(p), stdpath.Ext(p))))
+	c.File(p)
+}
+
+// localProxy serves the link, its ranges are read through the block cache if cachePath is given
+func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange bool, cachePath string) {
 	var err error
 	if link.URL != "" && setting.GetBool(conf.ForwardDirectLinkParams) {
 		query := c.Request.URL.Query()
@@ -128,6 +158,9 @@ func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange boo
 	if proxyRange {
 		common.ProxyRange(link, file.GetSize())
 	}
+	if cachePath != "" {
+		link = blockcache.Wrap(cachePath, file, link)
+	}
 	Writer := &common.WrittenResponseWriter{ResponseWriter: c.Writer}
 
 	//优先处理md文件
@@ -0,0 +1,96 @@
+package handles
+
+import (
+	"net/url"
+	"os"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/hls"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const hlsContentType = "application/vnd.apple.mpegurl"
+
+// FsHLS serves the HLS streams of a video transcoded on demand, the proxy of the video must be allowed
+func FsHLS(c *gin.Context) {
+	rawPath := c.MustGet("path").(string)
+	file := c.MustGet("hls_file").(string)
+	if !hls.Enabled() {
+		common.ErrorStrResp(c, "hls is not enabled", 404)
+		return
+	}
+	storage, err := fs.GetStorage(rawPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !canProxy(storage, stdpath.Base(rawPath)) {
+		common.ErrorStrResp(c, "proxy not allowed", 403)
+		return
+	}
+	obj, err := fs.Get(c, rawPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if obj.IsDir() || utils.GetFileType(obj.GetName()) != conf.VIDEO {
+		common.ErrorResp(c, errs.NotSupport, 404)
+		return
+	}
+	// the playlists carry the sign to the files they refer to
+	var query string
+	if s := c.Query("sign"); s != "" {
+		query = url.Values{"sign": {s}}.Encode()
+	}
+	if file == hls.MasterPlaylist {
+		src, err := hls.Probe(c, rawPath, obj)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		c.Data(200, hlsContentType, []byte(src.Master(query)))
+		return
+	}
+	if stdpath.Base(file) == hls.IndexPlaylist {
+		src, err := hls.Probe(c, rawPath, obj)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		// the playlist of ffmpeg is served as it grows if the duration is unknown
+		if playlist := src.Playlist(); playlist != "" {
+			c.Header("Cache-Control", "no-cache")
+			c.Data(200, hlsContentType, hls.SignPlaylist([]byte(playlist), query))
+			return
+		}
+	}
+	p, err := hls.Get(c, rawPath, obj, file)
+	if err != nil {
+		if errors.Is(err, hls.ErrBusy) {
+			common.ErrorResp(c, err, 503)
+		} else if errs.IsObjectNotFound(err) {
+			common.ErrorResp(c, err, 404)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	if stdpath.Base(file) != hls.IndexPlaylist {
+		c.Header("Cache-Control", "max-age=86400")
+		c.File(p)
+		return
+	}
+	playlist, err := os.ReadFile(p)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Data(200, hlsContentType, hls.SignPlaylist(playlist, query))
+}
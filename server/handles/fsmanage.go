@@ -10,7 +10,6 @@ import (
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
-	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/pkg/generic"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
@@ -348,7 +347,7 @@ func Link(c *gin.Context) {
 			URL: fmt.Sprintf("%s/p%s?d&sign=%s",
 				common.GetApiUrl(c.Request),
 				utils.EncodePath(rawPath, true),
-				sign.Sign(rawPath)),
+				common.SignPath(rawPath, true, c.ClientIP(), c.MustGet("user").(*model.User))),
 		})
 		return
 	}
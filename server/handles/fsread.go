@@ -6,17 +6,18 @@ import (
 	"strings"
 	"time"
 
-	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/media"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
-	"github.com/alist-org/alist/v3/internal/setting"
-	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/internal/proxypool"
+	"github.com/alist-org/alist/v3/internal/thumb"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 type ListReq struct {
@@ -97,7 +98,7 @@ func FsList(c *gin.Context) {
 		provider = storage.GetStorage().Driver
 	}
 	common.SuccessResp(c, FsListResp{
-		Content:  toObjsResp(objs, reqPath, isEncrypt(meta, reqPath)),
+		Content:  toObjsResp(objs, reqPath, isEncrypt(meta, reqPath), c.ClientIP(), user),
 		Total:    int64(total),
 		Readme:   getReadme(meta, reqPath),
 		Header:   getHeader(meta, reqPath),
@@ -207,10 +208,9 @@ func pagination(objs []model.Obj, req *model.PageReq) (int, []model.Obj) {
 	return total, objs[start:end]
 }
 
-func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
+func toObjsResp(objs []model.Obj, parent string, encrypt bool, clientIP string, user *model.User) []ObjResp {
 	var resp []ObjResp
 	for _, obj := range objs {
-		thumb, _ := model.GetThumb(obj)
 		resp = append(resp, ObjResp{
 			Id:          obj.GetID(),
 			Path:        obj.GetPath(),
@@ -221,14 +221,29 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
 			Created:     obj.CreateTime(),
 			HashInfoStr: obj.GetHash().String(),
 			HashInfo:    obj.GetHash().Export(),
-			Sign:        common.Sign(obj, parent, encrypt),
-			Thumb:       thumb,
+			Sign:        common.Sign(obj, parent, encrypt, clientIP, user),
+			Thumb:       getThumb(obj, parent, encrypt, clientIP, user),
 			Type:        utils.GetObjType(obj.GetName(), obj.IsDir()),
 		})
 	}
 	return resp
 }
 
+// getThumb returns the thumb given by the storage, or the one of the thumbnail service if enabled
+func getThumb(obj model.Obj, parent string, encrypt bool, clientIP string, user *model.User) string {
+	if t, _ := model.GetThumb(obj); t != "" {
+		return t
+	}
+	if !thumb.Enabled() || !thumb.Supported(obj) {
+		return ""
+	}
+	url := common.GetApiUrl(nil) + "/api/fs/thumb" + utils.EncodePath(stdpath.Join(parent, obj.GetName()), true)
+	if s := common.Sign(obj, parent, encrypt, clientIP, user); s != "" {
+		url += "?sign=" + s
+	}
+	return url
+}
+
 type FsGetReq struct {
 	Path     string `json:"path" form:"path"`
 	Password string `json:"password" form:"password"`
@@ -241,6 +256,9 @@ type FsGetResp struct {
 	Header   string    `json:"header"`
 	Provider string    `json:"provider"`
 	Related  []ObjResp `json:"related"`
+	// Media is the metadata of audios, images and videos, Cover is the link of the cover art of audios
+	Media *model.MediaInfo `json:"media,omitempty"`
+	Cover string           `json:"cover,omitempty"`
 }
 
 func FsGet(c *gin.Context) {
@@ -286,14 +304,18 @@ func FsGet(c *gin.Context) {
 		}
 		if storage.Config().MustProxy() || storage.GetStorage().WebProxy {
 			query := ""
-			if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) {
-				query = "?sign=" + sign.Sign(reqPath)
+			if s := common.SignPath(reqPath, isEncrypt(meta, reqPath), c.ClientIP(), user); s != "" {
+				query = "?sign=" + s
 			}
+			downProxy := ""
 			if storage.GetStorage().DownProxyUrl != "" {
+				downProxy = proxypool.Pick(storage.GetStorage().DownProxyUrl, reqPath, c.ClientIP())
+			}
+			if downProxy != "" {
 				rawURL = fmt.Sprintf("%s%s?sign=%s",
-					strings.Split(storage.GetStorage().DownProxyUrl, "\n")[0],
+					downProxy,
 					utils.EncodePath(reqPath, true),
-					sign.Sign(reqPath))
+					common.SignDownProxy(reqPath, c.ClientIP()))
 			} else {
 				rawURL = fmt.Sprintf("%s/p%s%s",
 					common.GetApiUrl(c.Request),
@@ -327,7 +349,7 @@ func FsGet(c *gin.Context) {
 		related = filterRelated(sameLevelFiles, obj)
 	}
 	parentMeta, _ := op.GetNearestMeta(parentPath)
-	thumb, _ := model.GetThumb(obj)
+	info, cover := getMedia(c, obj, reqPath, isEncrypt(meta, reqPath), user)
 	common.SuccessResp(c, FsGetResp{
 		ObjResp: ObjResp{
 			Id:          obj.GetID(),
@@ -339,18 +361,41 @@ func FsGet(c *gin.Context) {
 			Created:     obj.CreateTime(),
 			HashInfoStr: obj.GetHash().String(),
 			HashInfo:    obj.GetHash().Export(),
-			Sign:        common.Sign(obj, parentPath, isEncrypt(meta, reqPath)),
+			Sign:        common.Sign(obj, parentPath, isEncrypt(meta, reqPath), c.ClientIP(), user),
 			Type:        utils.GetFileType(obj.GetName()),
-			Thumb:       thumb,
+			Thumb:       getThumb(obj, parentPath, isEncrypt(meta, reqPath), c.ClientIP(), user),
 		},
 		RawURL:   rawURL,
 		Readme:   getReadme(meta, reqPath),
 		Header:   getHeader(meta, reqPath),
 		Provider: provider,
-		Related:  toObjsResp(related, parentPath, isEncrypt(parentMeta, parentPath)),
+		Related:  toObjsResp(related, parentPath, isEncrypt(parentMeta, parentPath), c.ClientIP(), user),
+		Media:    info,
+		Cover:    cover,
 	})
 }
 
+// getMedia returns the media info of the file and the link of its cover art,
+// the files failed to be read are returned without them
+func getMedia(c *gin.Context, obj model.Obj, path string, encrypt bool, user *model.User) (*model.MediaInfo, string) {
+	if !media.Enabled() || !media.Supported(obj) {
+		return nil, ""
+	}
+	info, err := media.Get(c, path, obj)
+	if err != nil {
+		log.Debugf("failed get media info of %s: %+v", path, err)
+		return nil, ""
+	}
+	if !info.HasCover {
+		return info, ""
+	}
+	cover := common.GetApiUrl(c.Request) + "/api/fs/cover" + utils.EncodePath(path, true)
+	if s := common.Sign(obj, stdpath.Dir(path), encrypt, c.ClientIP(), user); s != "" {
+		cover += "?sign=" + s
+	}
+	return info, cover
+}
+
 func filterRelated(objs []model.Obj, obj model.Obj) []model.Obj {
 	var related []model.Obj
 	nameWithoutExt := strings.TrimSuffix(obj.GetName(), stdpath.Ext(obj.GetName()))
@@ -0,0 +1,132 @@
+package handles
+
+import (
+	"fmt"
+	"net/http"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/media"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/internal/thumb"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// FsThumb serves the thumbnail made by the thumbnail service, it's signed like the downloads
+func FsThumb(c *gin.Context) {
+	rawPath := c.MustGet("path").(string)
+	if !thumb.Enabled() {
+		common.ErrorStrResp(c, "thumbnail is not enabled", 404)
+		return
+	}
+	obj, err := fs.Get(c, rawPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !thumb.Supported(obj) {
+		common.ErrorResp(c, errs.NotSupport, 404)
+		return
+	}
+	p, err := thumb.Get(c, rawPath, obj)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.Header("Cache-Control", "max-age=86400")
+	c.File(p)
+}
+
+// FsCover serves the cover art embedded in an audio, it's signed like the downloads
+func FsCover(c *gin.Context) {
+	rawPath := c.MustGet("path").(string)
+	if !media.Enabled() {
+		common.ErrorStrResp(c, "media info is not enabled", 404)
+		return
+	}
+	obj, err := fs.Get(c, rawPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	pic, err := media.Cover(c, rawPath, obj)
+	if err != nil {
+		if errs.IsObjectNotFound(err) || errors.Is(err, errs.NotSupport) {
+			common.ErrorResp(c, err, 404)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	c.Header("Cache-Control", "max-age=86400")
+	mimetype := pic.MIMEType
+	if mimetype == "" {
+		mimetype = http.DetectContentType(pic.Data)
+	}
+	c.Data(200, mimetype, pic.Data)
+}
+
+type FsRenditionReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	Width    string `json:"w" form:"w"`
+	Height   string `json:"h" form:"h"`
+	Fit      string `json:"fit" form:"fit"`
+	Quality  string `json:"q" form:"q"`
+	Format   string `json:"fmt" form:"fmt"`
+}
+
+// FsRendition returns the proxy link of a rendition of the image, whose sign covers the rendition parameters
+func FsRendition(c *gin.Context) {
+	var req FsRenditionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	query := c.Request.URL.Query()
+	for k, v := range map[string]string{"w": req.Width, "h": req.Height, "fit": req.Fit, "q": req.Quality, "fmt": req.Format} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	r, err := thumb.ParseRendition(query, stdpath.Base(reqPath))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if r == nil {
+		common.ErrorStrResp(c, "no rendition parameter given", 400)
+		return
+	}
+	q := thumb.RenditionQuery(query)
+	common.SuccessResp(c, gin.H{
+		"url": fmt.Sprintf("%s/p%s?%s&sign=%s",
+			common.GetApiUrl(c.Request),
+			utils.EncodePath(reqPath, true),
+			q,
+			common.SignUser(common.BindSignData(reqPath+"?"+q, common.GetAccessRule(reqPath), c.ClientIP()), user, sign.Sign)),
+	})
+}
@@ -2,11 +2,13 @@ package handles
 
 import (
 	"io"
+	"net/http"
 	"net/url"
 	stdpath "path"
 	"strconv"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/stream"
@@ -14,6 +16,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 func getLastModified(c *gin.Context) time.Time {
@@ -89,7 +92,7 @@ func FsStream(c *gin.Context) {
 	}
 	defer c.Request.Body.Close()
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, putErrCode(err))
 		return
 	}
 	if t == nil {
@@ -182,7 +185,7 @@ func FsForm(c *gin.Context) {
 		err = fs.PutDirectly(c, dir, &s, true)
 	}
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, putErrCode(err))
 		return
 	}
 	if t == nil {
@@ -193,3 +196,10 @@ func FsForm(c *gin.Context) {
 		"task": getTaskInfo(t),
 	})
 }
+
+func putErrCode(err error) int {
+	if errors.Is(err, errs.QuotaExceeded) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return 500
+}
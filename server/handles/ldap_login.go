@@ -8,6 +8,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/loginlimit"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -36,10 +37,8 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 
 	// check count of login
 	ip := c.ClientIP()
-	count, ok := loginCache.Get(ip)
-	if ok && count >= defaultTimes {
-		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
-		loginCache.Expire(ip, defaultDuration)
+	if err := loginlimit.Check(req.Username, ip); err != nil {
+		common.ErrorResp(c, err, 429)
 		return
 	}
 
@@ -94,7 +93,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	if err != nil {
 		utils.Log.Errorf("Failed to auth. %v", err)
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		loginlimit.Fail("ldap", req.Username, ip)
 		return
 	} else {
 		utils.Log.Infof("Auth successful username:%s", req.Username)
@@ -106,19 +105,19 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		user, err = ladpRegister(req.Username)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
-			loginCache.Set(ip, count+1)
+			loginlimit.Fail("ldap", req.Username, ip)
 			return
 		}
 	}
 
 	// generate token
-	token, err := common.GenerateToken(user)
+	token, err := common.GenerateToken(c, user, model.LoginLDAP)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
-	loginCache.Del(ip)
+	loginlimit.Succeed(req.Username)
 }
 
 func ladpRegister(username string) (*model.User, error) {
@@ -0,0 +1,91 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListLimitProfiles(c *gin.Context) {
+	profiles, err := op.GetLimitProfiles()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, profiles)
+}
+
+func GetLimitProfile(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	profile, err := op.GetLimitProfileById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, profile)
+}
+
+func validLimitProfile(p *model.LimitProfile) bool {
+	return p.DownloadSpeed >= 0 && p.UploadSpeed >= 0 && p.MaxTransfers >= 0
+}
+
+func CreateLimitProfile(c *gin.Context) {
+	var req model.LimitProfile
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !validLimitProfile(&req) {
+		common.ErrorStrResp(c, "the limits can't be negative", 400)
+		return
+	}
+	req.ID = 0
+	if err := op.CreateLimitProfile(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c, req)
+	}
+}
+
+func UpdateLimitProfile(c *gin.Context) {
+	var req model.LimitProfile
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !validLimitProfile(&req) {
+		common.ErrorStrResp(c, "the limits can't be negative", 400)
+		return
+	}
+	if _, err := op.GetLimitProfileById(req.ID); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.UpdateLimitProfile(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func DeleteLimitProfile(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteLimitProfileById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
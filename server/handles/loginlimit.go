@@ -0,0 +1,17 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/loginlimit"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListLoginLocks(c *gin.Context) {
+	common.SuccessResp(c, loginlimit.List())
+}
+
+// ClearLoginLock unlocks the key such as user:alice or ip:1.2.3.4, all keys are unlocked if it's empty
+func ClearLoginLock(c *gin.Context) {
+	loginlimit.Clear(c.Query("key"))
+	common.SuccessResp(c)
+}
@@ -0,0 +1,16 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/proxypool"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func DownProxyStatus(c *gin.Context) {
+	common.SuccessResp(c, proxypool.Statuses())
+}
+
+func CheckDownProxies(c *gin.Context) {
+	proxypool.CheckAll()
+	common.SuccessResp(c, proxypool.Statuses())
+}
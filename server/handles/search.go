@@ -45,6 +45,10 @@ func Search(c *gin.Context) {
 	}
 	nodes, total, err := search.Search(c, req.SearchReq)
 	if err != nil {
+		if errors.Is(err, errs.NotSupport) {
+			common.ErrorStrResp(c, "the searcher doesn't support searching the content", 400)
+			return
+		}
 		common.ErrorResp(c, err, 500)
 		return
 	}
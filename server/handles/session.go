@@ -0,0 +1,81 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionResp struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+func ListMySessions(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listSessions(c, userObj.ID, c.GetString("session_id"))
+}
+
+func RevokeMySession(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	s, err := op.GetSessionByIdAndUserId(c.Query("id"), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get session", 404)
+		return
+	}
+	if err = op.DeleteSessionById(s.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListSessions(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	listSessions(c, uint(userId), "")
+}
+
+// ForceLogout revokes all the sessions of the user
+func ForceLogout(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteSessionsByUserId(uint(userId)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func listSessions(c *gin.Context, userId uint, current string) {
+	sessions, err := op.GetSessionsByUserId(userId)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]SessionResp, 0, len(sessions))
+	for _, s := range sessions {
+		if s.IsExpired() {
+			continue
+		}
+		resp = append(resp, SessionResp{Session: s, Current: s.ID == current})
+	}
+	common.SuccessResp(c, resp)
+}
@@ -1,8 +1,10 @@
 package handles
 
 import (
+	"context"
 	"strconv"
 
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -39,6 +41,9 @@ func CreateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin or guest user can not be created", 400, true)
 		return
 	}
+	if !validUserLimitProfile(c, &req) {
+		return
+	}
 	req.SetPassword(req.Password)
 	req.Password = ""
 	req.Authn = "[]"
@@ -49,6 +54,18 @@ func CreateUser(c *gin.Context) {
 	}
 }
 
+// validUserLimitProfile replies 400 if the limit profile of the user doesn't exist
+func validUserLimitProfile(c *gin.Context, user *model.User) bool {
+	if user.LimitProfileID == 0 {
+		return true
+	}
+	if _, err := op.GetLimitProfileById(user.LimitProfileID); err != nil {
+		common.ErrorStrResp(c, "limit profile not found", 400)
+		return false
+	}
+	return true
+}
+
 func UpdateUser(c *gin.Context) {
 	var req model.User
 	if err := c.ShouldBind(&req); err != nil {
@@ -78,6 +95,9 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin user can not be disabled", 400)
 		return
 	}
+	if !validUserLimitProfile(c, &req) {
+		return
+	}
 	if err := op.UpdateUser(&req); err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
@@ -137,3 +157,23 @@ func DelUserCache(c *gin.Context) {
 	}
 	common.SuccessResp(c)
 }
+
+func RecalculateUserUsage(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user, err := op.GetUserById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	go func() {
+		if _, err := fs.RecalculateUsage(context.Background(), user); err != nil {
+			log.Errorf("failed recalculate usage of user [%s]: %+v", user.Username, err)
+		}
+	}()
+	common.SuccessResp(c)
+}
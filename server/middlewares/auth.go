@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -45,6 +46,19 @@ func Auth(c *gin.Context) {
 		c.Next()
 		return
 	}
+	if strings.HasPrefix(token, model.APITokenPrefix) {
+		user, err := op.ValidateAPIToken(token, c.ClientIP())
+		if err != nil {
+			common.ErrorResp(c, err, 401)
+			c.Abort()
+			return
+		}
+		c.Set("user", user)
+		c.Set("api_token", true)
+		log.Debugf("use api token: %+v", user)
+		c.Next()
+		return
+	}
 	userClaims, err := common.ParseToken(token)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -68,7 +82,9 @@ func Auth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	op.TouchSession(userClaims.Session, c.ClientIP())
 	c.Set("user", user)
+	c.Set("session_id", userClaims.ID)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
 }
@@ -122,7 +138,9 @@ func Authn(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	op.TouchSession(userClaims.Session, c.ClientIP())
 	c.Set("user", user)
+	c.Set("session_id", userClaims.ID)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
 }
@@ -137,6 +155,17 @@ func AuthNotGuest(c *gin.Context) {
 	}
 }
 
+// AuthNotAPIToken rejects api tokens, the user set by an api token is a
+// restricted copy which must not be saved, and a token can't mint tokens
+func AuthNotAPIToken(c *gin.Context) {
+	if c.GetBool("api_token") {
+		common.ErrorStrResp(c, "Not allowed with an api token", 403)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
+
 func AuthAdmin(c *gin.Context) {
 	user := c.MustGet("user").(*model.User)
 	if !user.IsAdmin() {
@@ -1,10 +1,13 @@
 package middlewares
 
 import (
+	stdpath "path"
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/hls"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/thumb"
 
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -16,31 +19,122 @@ import (
 )
 
 func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
+	return down(verifyFunc, false)
+}
+
+// ProxyDown is Down whose sign covers the rendition parameters too,
+// the rendition asked is set in the context once it's verified
+func ProxyDown(verifyFunc func(string, string) error) func(c *gin.Context) {
+	return down(verifyFunc, true)
+}
+
+func down(verifyFunc func(string, string) error, rendition bool) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		rawPath := parsePath(c.Param("path"))
-		c.Set("path", rawPath)
-		meta, err := op.GetNearestMeta(rawPath)
-		if err != nil {
-			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
-				common.ErrorResp(c, err, 500, true)
-				return
+		signData := rawPath
+		if rendition {
+			if q := thumb.RenditionQuery(c.Request.URL.Query()); q != "" {
+				r, err := thumb.ParseRendition(c.Request.URL.Query(), stdpath.Base(rawPath))
+				if err != nil {
+					common.ErrorResp(c, err, 400)
+					c.Abort()
+					return
+				}
+				c.Set("rendition", r)
+				signData += "?" + q
 			}
 		}
-		c.Set("meta", meta)
-		// verify sign
-		if needSign(meta, rawPath) {
-			s := c.Query("sign")
-			err = verifyFunc(rawPath, strings.TrimSuffix(s, "/"))
-			if err != nil {
-				common.ErrorResp(c, err, 401)
-				c.Abort()
-				return
-			}
+		if !verify(c, verifyFunc, rawPath, signData) {
+			return
+		}
+		defer releaseConn(c)
+		c.Next()
+	}
+}
+
+// HLS checks the files of the HLS streams of a video like the downloads of the video,
+// the file asked is set in the context
+func HLS(verifyFunc func(string, string) error) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		rawPath, file, ok := hls.SplitPath(parsePath(c.Param("path")))
+		if !ok {
+			common.ErrorStrResp(c, "invalid hls path", 404)
+			c.Abort()
+			return
 		}
+		c.Set("hls_file", file)
+		if !verify(c, verifyFunc, rawPath, rawPath) {
+			return
+		}
+		defer releaseConn(c)
 		c.Next()
 	}
 }
 
+// verify sets the path and the meta of the file in the context, checks the access rule of the file,
+// then verifies the sign of signData
+func verify(c *gin.Context, verifyFunc func(string, string) error, rawPath, signData string) bool {
+	c.Set("path", rawPath)
+	meta, err := op.GetNearestMeta(rawPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return false
+		}
+	}
+	c.Set("meta", meta)
+	rule := common.GetAccessRule(rawPath)
+	if err = common.CheckAccess(rule, c.Request.Referer(), c.Request.Host, c.Request.UserAgent()); err != nil {
+		common.ErrorResp(c, err, 403)
+		c.Abort()
+		return false
+	}
+	userID, s := common.SplitSignUser(strings.TrimSuffix(c.Query("sign"), "/"))
+	bindIP := rule != nil && rule.BindIP > 0
+	if bindIP {
+		signData = common.BindSignData(signData, rule, c.ClientIP())
+	}
+	signData = common.BindSignUser(signData, userID)
+	if bindIP {
+		if verifyFunc(signData, s) != nil {
+			common.ErrorStrResp(c, "sign is invalid, expired or bound to another client", 403)
+			c.Abort()
+			return false
+		}
+	} else if needSign(meta, rawPath) {
+		err = verifyFunc(signData, s)
+		if err != nil {
+			common.ErrorResp(c, err, 401)
+			c.Abort()
+			return false
+		}
+	} else if userID != "" && verifyFunc(signData, s) != nil {
+		// the sign isn't needed, but the user bound is only trusted with a valid one
+		userID = ""
+	}
+	if userID != "" {
+		c.Set("sign_user", userID)
+	}
+	if rule != nil && rule.MaxConns > 0 {
+		link := rawPath + "\n" + s
+		if !common.AcquireConn(link, rule.MaxConns) {
+			common.ErrorStrResp(c, "too many connections of the link", 403)
+			c.Abort()
+			return false
+		}
+		// released once the download is done
+		c.Set("release_conn", link)
+	}
+	return true
+}
+
+// releaseConn releases the connection of the link taken by verify after the handlers
+func releaseConn(c *gin.Context) {
+	if link, ok := c.Get("release_conn"); ok {
+		common.ReleaseConn(link.(string))
+	}
+}
+
 // TODO: implement
 // path maybe contains # ? etc.
 func parsePath(path string) string {
@@ -1,9 +1,15 @@
 package middlewares
 
 import (
-	"github.com/alist-org/alist/v3/internal/stream"
-	"github.com/gin-gonic/gin"
 	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
 )
 
 func MaxAllowed(n int) gin.HandlerFunc {
@@ -17,13 +23,48 @@ func MaxAllowed(n int) gin.HandlerFunc {
 	}
 }
 
-func UploadRateLimiter(limiter stream.Limiter) gin.HandlerFunc {
+// transferUser is the user limited by the profile, the downloads by links are of the user
+// bound into the sign, or the guest
+func transferUser(c *gin.Context) *model.User {
+	if user, ok := c.Value("user").(*model.User); ok {
+		return user
+	}
+	if id, ok := c.Get("sign_user"); ok {
+		if n, err := strconv.ParseUint(id.(string), 10, 64); err == nil {
+			if user, err := op.GetUserById(uint(n)); err == nil {
+				return user
+			}
+		}
+	}
+	guest, err := op.GetGuest()
+	if err != nil {
+		return nil
+	}
+	return guest
+}
+
+func acquireTransfer(c *gin.Context, d bandwidth.Direction) (*bandwidth.Transfer, bool) {
+	t, err := bandwidth.Acquire(transferUser(c), d)
+	if err != nil {
+		common.ErrorResp(c, err, 429)
+		c.Abort()
+		return nil, false
+	}
+	return t, true
+}
+
+func UploadRateLimiter() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Request.Body = &stream.RateLimitReader{
-			Reader:  c.Request.Body,
-			Limiter: limiter,
-			Ctx:     c,
+		if c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+		t, ok := acquireTransfer(c, bandwidth.Upload)
+		if !ok {
+			return
 		}
+		defer t.Release()
+		c.Request.Body = t.Reader(c, c.Request.Body)
 		c.Next()
 	}
 }
@@ -37,16 +78,72 @@ func (w *ResponseWriterWrapper) Write(p []byte) (n int, err error) {
 	return w.WrapWriter.Write(p)
 }
 
-func DownloadRateLimiter(limiter stream.Limiter) gin.HandlerFunc {
+// transferWriter takes a transfer once the response turns out to be a download,
+// so the redirects and the errors take none
+type transferWriter struct {
+	gin.ResponseWriter
+	c   *gin.Context
+	t   *bandwidth.Transfer
+	w   io.Writer
+	err error
+}
+
+func (w *transferWriter) acquire(code int) error {
+	if w.t != nil || w.err != nil || code != http.StatusOK && code != http.StatusPartialContent {
+		return w.err
+	}
+	w.t, w.err = bandwidth.Acquire(transferUser(w.c), bandwidth.Download)
+	if w.err != nil {
+		h := w.ResponseWriter.Header()
+		for _, k := range []string{"Content-Length", "Content-Range", "Content-Disposition", "Content-Encoding", "Etag", "Last-Modified"} {
+			h.Del(k)
+		}
+		h.Set("Content-Type", "text/plain; charset=utf-8")
+		w.ResponseWriter.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.ResponseWriter.WriteString(w.err.Error())
+		return w.err
+	}
+	w.w = w.t.Writer(w.c, w.ResponseWriter)
+	return nil
+}
+
+func (w *transferWriter) WriteHeader(code int) {
+	if w.acquire(code) == nil {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *transferWriter) Write(p []byte) (int, error) {
+	if err := w.acquire(w.Status()); err != nil {
+		return 0, err
+	}
+	if w.w == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.w.Write(p)
+}
+
+func (w *transferWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *transferWriter) release() {
+	if w.t != nil {
+		w.t.Release()
+	}
+}
+
+// DownloadRateLimiter limits the downloads served by the handlers, the transfer is taken
+// only when the file is written, the downloads redirected take no slot of the user
+func DownloadRateLimiter() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer = &ResponseWriterWrapper{
-			ResponseWriter: c.Writer,
-			WrapWriter: &stream.RateLimitWriter{
-				Writer:  c.Writer,
-				Limiter: limiter,
-				Ctx:     c,
-			},
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
 		}
+		w := &transferWriter{ResponseWriter: c.Writer, c: c}
+		defer w.release()
+		c.Writer = w
 		c.Next()
 	}
 }
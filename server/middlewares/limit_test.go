@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestDownloadRateLimiter(t *testing.T) {
+	p := &model.LimitProfile{Name: "one", MaxTransfers: 1}
+	if err := op.CreateLimitProfile(p); err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 300, LimitProfileID: p.ID}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user", user) })
+	r.GET("/file", DownloadRateLimiter(), func(c *gin.Context) { c.String(200, "content") })
+	r.GET("/redirect", DownloadRateLimiter(), func(c *gin.Context) { c.Redirect(302, "/file") })
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	if code := get("/file"); code != 200 {
+		t.Errorf("download: got %d, want 200", code)
+	}
+	tr, err := bandwidth.Acquire(user, bandwidth.Download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Release()
+	if code := get("/file"); code != http.StatusTooManyRequests {
+		t.Errorf("download over the max transfers: got %d, want 429", code)
+	}
+	// a redirect transfers nothing, so it takes no slot
+	if code := get("/redirect"); code != 302 {
+		t.Errorf("redirect over the max transfers: got %d, want 302", code)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/message"
 	"github.com/alist-org/alist/v3/internal/sign"
-	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/alist-org/alist/v3/server/handles"
@@ -40,12 +39,13 @@ func Init(e *gin.Engine) {
 	WebDav(g.Group("/dav"))
 	S3(g.Group("/s3"))
 
-	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
+	downloadLimiter := middlewares.DownloadRateLimiter()
 	signCheck := middlewares.Down(sign.Verify)
 	g.GET("/d/*path", signCheck, downloadLimiter, handles.Down)
-	g.GET("/p/*path", signCheck, downloadLimiter, handles.Proxy)
+	proxySignCheck := middlewares.ProxyDown(sign.Verify)
+	g.GET("/p/*path", proxySignCheck, downloadLimiter, handles.Proxy)
 	g.HEAD("/d/*path", signCheck, handles.Down)
-	g.HEAD("/p/*path", signCheck, handles.Proxy)
+	g.HEAD("/p/*path", proxySignCheck, handles.Proxy)
 	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
 	g.GET("/ad/*path", archiveSignCheck, downloadLimiter, handles.ArchiveDown)
 	g.GET("/ap/*path", archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
@@ -56,18 +56,27 @@ func Init(e *gin.Engine) {
 
 	api := g.Group("/api")
 	auth := api.Group("", middlewares.Auth)
+	// loaded by img tags, so it's signed like the downloads instead of the token
+	api.GET("/fs/thumb/*path", signCheck, handles.FsThumb)
+	api.GET("/fs/cover/*path", signCheck, handles.FsCover)
+	api.GET("/fs/hls/*path", middlewares.HLS(sign.Verify), downloadLimiter, handles.FsHLS)
 	webauthn := api.Group("/authn", middlewares.Authn)
 
 	api.POST("/auth/login", handles.Login)
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
+	auth.POST("/me/update", middlewares.AuthNotAPIToken, handles.UpdateCurrent)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
-	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
-	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	auth.POST("/me/sshkey/add", middlewares.AuthNotAPIToken, handles.AddMyPublicKey)
+	auth.POST("/me/sshkey/delete", middlewares.AuthNotAPIToken, handles.DeleteMyPublicKey)
+	auth.GET("/me/token/list", handles.ListMyAPITokens)
+	auth.POST("/me/token/create", middlewares.AuthNotAPIToken, handles.CreateMyAPIToken)
+	auth.POST("/me/token/delete", middlewares.AuthNotAPIToken, handles.DeleteMyAPIToken)
+	auth.GET("/me/sessions", handles.ListMySessions)
+	auth.POST("/me/sessions/revoke", middlewares.AuthNotAPIToken, handles.RevokeMySession)
+	auth.POST("/auth/2fa/generate", middlewares.AuthNotAPIToken, handles.Generate2FA)
+	auth.POST("/auth/2fa/verify", middlewares.AuthNotAPIToken, handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
 
 	// auth
@@ -109,6 +118,13 @@ func admin(g *gin.RouterGroup) {
 	meta.POST("/update", handles.UpdateMeta)
 	meta.POST("/delete", handles.DeleteMeta)
 
+	limitProfile := g.Group("/limit_profile")
+	limitProfile.GET("/list", handles.ListLimitProfiles)
+	limitProfile.GET("/get", handles.GetLimitProfile)
+	limitProfile.POST("/create", handles.CreateLimitProfile)
+	limitProfile.POST("/update", handles.UpdateLimitProfile)
+	limitProfile.POST("/delete", handles.DeleteLimitProfile)
+
 	user := g.Group("/user")
 	user.GET("/list", handles.ListUsers)
 	user.GET("/get", handles.GetUser)
@@ -117,8 +133,13 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
 	user.POST("/delete", handles.DeleteUser)
 	user.POST("/del_cache", handles.DelUserCache)
+	user.POST("/recalc_usage", handles.RecalculateUserUsage)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/token/list", handles.ListAPITokens)
+	user.POST("/token/delete", handles.DeleteAPIToken)
+	user.GET("/sessions", handles.ListSessions)
+	user.POST("/force_logout", handles.ForceLogout)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
@@ -148,6 +169,10 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/set_pikpak", handles.SetPikPak)
 	setting.POST("/set_thunder", handles.SetThunder)
 
+	loginLock := g.Group("/login_lock")
+	loginLock.GET("/list", handles.ListLoginLocks)
+	loginLock.POST("/clear", handles.ClearLoginLock)
+
 	// retain /admin/task API to ensure compatibility with legacy automation scripts
 	_task(g.Group("/task"))
 
@@ -161,6 +186,22 @@ func admin(g *gin.RouterGroup) {
 	index.POST("/stop", middlewares.SearchIndex, handles.StopIndex)
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
+
+	blockCache := g.Group("/block_cache")
+	blockCache.GET("/stats", handles.BlockCacheStats)
+	blockCache.POST("/purge", handles.PurgeBlockCache)
+
+	downProxy := g.Group("/down_proxy")
+	downProxy.GET("/status", handles.DownProxyStatus)
+	downProxy.POST("/check", handles.CheckDownProxies)
+
+	dedup := g.Group("/dedup")
+	dedup.POST("/scan", handles.DedupScan)
+	dedup.POST("/stop", handles.DedupStop)
+	dedup.GET("/progress", handles.DedupProgress)
+	dedup.GET("/list", handles.ListDuplicates)
+	dedup.POST("/delete", handles.DedupDelete)
+	dedup.POST("/link", handles.DedupLink)
 }
 
 func _fs(g *gin.RouterGroup) {
@@ -178,7 +219,10 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/copy", handles.FsCopy)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
-	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
+	g.Any("/rendition", handles.FsRendition)
+	g.Any("/versions", handles.FsVersions)
+	g.POST("/versions/restore", handles.FsRestoreVersion)
+	uploadLimiter := middlewares.UploadRateLimiter()
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
@@ -0,0 +1,163 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/gofakes3"
+	"github.com/alist-org/gofakes3/signature"
+	log "github.com/sirupsen/logrus"
+)
+
+// getAccessKey gets the access key id from the v4 or v2 authorization
+func getAccessKey(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if cred := r.URL.Query().Get("X-Amz-Credential"); auth == "" && cred != "" {
+		return strings.SplitN(cred, "/", 2)[0]
+	}
+	if i := strings.Index(auth, "Credential="); i >= 0 {
+		return strings.SplitN(auth[i+len("Credential="):], "/", 2)[0]
+	}
+	if strings.HasPrefix(auth, "AWS ") {
+		return strings.SplitN(strings.TrimPrefix(auth, "AWS "), ":", 2)[0]
+	}
+	return ""
+}
+
+func denied(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>%s</Message></Error>`, msg)
+}
+
+// tokenKeys keeps the keys of the api tokens in the credentials of the signatures only while
+// the requests signed by them are served, so that the revoked and the unused ones don't pile up there.
+// The keys of the settings are always kept
+type tokenKeys struct {
+	mu       sync.Mutex
+	settings map[string]string
+	secrets  map[string]string
+	using    map[string]int
+}
+
+// the credentials are global, so are the keys in use
+var apiTokenKeys = &tokenKeys{secrets: make(map[string]string), using: make(map[string]int)}
+
+func (k *tokenKeys) setSettings(settings map[string]string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.settings = settings
+}
+
+func (k *tokenKeys) acquire(accessKey, secretKey string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.using[accessKey]++
+	k.secrets[accessKey] = secretKey
+	signature.StoreKeys(map[string]string{accessKey: secretKey})
+}
+
+func (k *tokenKeys) release(accessKey string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.using[accessKey]--; k.using[accessKey] > 0 {
+		return
+	}
+	delete(k.using, accessKey)
+	delete(k.secrets, accessKey)
+	// the credentials can only be removed by reloading the ones to keep
+	pairs := make(map[string]string, len(k.settings)+len(k.secrets))
+	for key, secret := range k.settings {
+		pairs[key] = secret
+	}
+	for key, secret := range k.secrets {
+		pairs[key] = secret
+	}
+	signature.ReloadKeys(pairs)
+}
+
+// apiTokenAuth authenticates the requests signed with the access key of an api token,
+// the restricted owner of the token is put into the context of the request
+func apiTokenAuth(next http.Handler, keys *tokenKeys) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessKey := getAccessKey(r)
+		if !strings.HasPrefix(accessKey, model.APITokenAccessKeyPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		t, err := op.GetAPITokenByAccessKey(accessKey)
+		if err != nil {
+			// never fall back to the keys of the settings, the token may be revoked
+			denied(w, "The api token is invalid or revoked")
+			return
+		}
+		// gofakes3 verifies the signature again with the keys of the settings
+		keys.acquire(accessKey, op.APITokenSecretKey(t))
+		defer keys.release(accessKey)
+		result := signature.V4SignVerify(r)
+		if result == signature.ErrUnsupportAlgorithm {
+			result = signature.V2SignVerify(r)
+		}
+		if result != signature.ErrNone {
+			resp := signature.GetAPIError(result)
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(resp.HTTPStatusCode)
+			_, _ = w.Write(signature.EncodeAPIErrorToResponse(resp))
+			return
+		}
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		user, err := op.UseAPIToken(t, ip)
+		if err != nil {
+			log.Debugf("[s3] api token %d rejected: %+v", t.ID, err)
+			denied(w, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user)))
+	})
+}
+
+// getUserBucket gets the bucket usable by the user in ctx,
+// a user restricted by an api token only sees the buckets inside its base path
+func getUserBucket(ctx context.Context, name string) (Bucket, error) {
+	bucket, err := getBucketByName(name)
+	if err != nil {
+		return bucket, err
+	}
+	if !canUseBucket(ctx, bucket) {
+		return Bucket{}, gofakes3.BucketNotFound(name)
+	}
+	return bucket, nil
+}
+
+func canUseBucket(ctx context.Context, bucket Bucket) bool {
+	user, ok := ctx.Value("user").(*model.User)
+	return !ok || utils.IsSubPath(user.BasePath, bucket.Path)
+}
+
+// checkUserPath checks the joined path doesn't escape the base path of the user in ctx
+func checkUserPath(ctx context.Context, fp string) error {
+	user, ok := ctx.Value("user").(*model.User)
+	if ok && !utils.IsSubPath(user.BasePath, fp) {
+		return gofakes3.ErrorMessage(ErrAccessDenied, "path is out of the api token")
+	}
+	return nil
+}
+
+// checkUserPerm checks the user in ctx by perm, requests without a user are not restricted
+func checkUserPerm(ctx context.Context, perm func(u *model.User) bool) error {
+	user, ok := ctx.Value("user").(*model.User)
+	if ok && !perm(user) {
+		return gofakes3.ErrorMessage(ErrAccessDenied, "permission denied by the api token")
+	}
+	return nil
+}
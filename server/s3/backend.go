@@ -14,6 +14,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/alist-org/alist/v3/internal/bandwidth"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -31,6 +32,15 @@ var (
 	timeFormat  = "Mon, 2 Jan 2006 15:04:05 GMT"
 )
 
+// ErrQuotaExceeded is not a standard S3 error code, gofakes3 replies it with 500
+const ErrQuotaExceeded gofakes3.ErrorCode = "QuotaExceeded"
+
+// ErrSlowDown is replied when the user reaches the max transfers of its limit profile
+const ErrSlowDown gofakes3.ErrorCode = "SlowDown"
+
+// ErrAccessDenied is replied when the api token doesn't permit the operation
+const ErrAccessDenied gofakes3.ErrorCode = "AccessDenied"
+
 // s3Backend implements the gofacess3.Backend interface to make an S3
 // backend for gofakes3
 type s3Backend struct {
@@ -52,6 +62,9 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 	}
 	var response []gofakes3.BucketInfo
 	for _, b := range buckets {
+		if !canUseBucket(ctx, b) {
+			continue
+		}
 		node, _ := fs.Get(ctx, b.Path, &fs.GetArgs{})
 		response = append(response, gofakes3.BucketInfo{
 			// Name:         gofakes3.URLEncode(b.Name),
@@ -64,7 +77,7 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 
 // ListBucket lists the objects in the given bucket.
 func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *gofakes3.Prefix, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getUserBucket(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
@@ -83,9 +96,13 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 	}
 
 	response := gofakes3.NewObjectList()
-	path, remaining := prefixParser(prefix)
+	fdPath, remaining := prefixParser(prefix)
+	// the prefix may climb out of the path of the api token by ".."
+	if err := checkUserPath(ctx, path.Join(bucketPath, fdPath)); err != nil {
+		return nil, err
+	}
 
-	err = b.entryListR(bucketPath, path, remaining, prefix.HasDelimiter, response)
+	err = b.entryListR(bucketPath, fdPath, remaining, prefix.HasDelimiter, response)
 	if err == gofakes3.ErrNoSuchKey {
 		// AWS just returns an empty list
 		response = gofakes3.NewObjectList()
@@ -100,13 +117,16 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 //
 // Note that the metadata is not supported yet.
 func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName string) (*gofakes3.Object, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getUserBucket(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err := checkUserPath(ctx, fp); err != nil {
+		return nil, err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, "meta", fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -143,13 +163,16 @@ func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName strin
 
 // GetObject fetchs the object from the filesystem.
 func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string, rangeRequest *gofakes3.ObjectRangeRequest) (obj *gofakes3.Object, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getUserBucket(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err := checkUserPath(ctx, fp); err != nil {
+		return nil, err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, "meta", fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -212,6 +235,13 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 		}
 	}
 
+	user, _ := ctx.Value("user").(*model.User)
+	transfer, err := bandwidth.Acquire(user, bandwidth.Download)
+	if err != nil {
+		_ = rdr.Close()
+		return nil, gofakes3.ErrorMessage(ErrSlowDown, err.Error())
+	}
+
 	meta := map[string]string{
 		"Last-Modified": node.ModTime().Format(timeFormat),
 		"Content-Type":  utils.GetMimeType(fp),
@@ -231,7 +261,7 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 		Metadata: meta,
 		Size:     size,
 		Range:    rnge,
-		Contents: rdr,
+		Contents: transfer.Reader(ctx, rdr),
 	}, nil
 }
 
@@ -247,7 +277,7 @@ func (b *s3Backend) PutObject(
 	meta map[string]string,
 	input io.Reader, size int64,
 ) (result gofakes3.PutObjectResult, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getUserBucket(ctx, bucketName)
 	if err != nil {
 		return result, err
 	}
@@ -258,6 +288,12 @@ func (b *s3Backend) PutObject(
 
 	fp := path.Join(bucketPath, objectName)
 	log.Debugf("fp: %s, bucketPath: %s, objectName: %s", fp, bucketPath, objectName)
+	if err := checkUserPath(ctx, fp); err != nil {
+		return result, err
+	}
+	if err := checkUserPerm(ctx, (*model.User).CanWrite); err != nil {
+		return result, err
+	}
 
 	var reqPath string
 	if isDir {
@@ -296,6 +332,13 @@ func (b *s3Backend) PutObject(
 		ti, _ = swift.FloatStringToTime(val)
 	}
 
+	user, _ := ctx.Value("user").(*model.User)
+	transfer, err := bandwidth.Acquire(user, bandwidth.Upload)
+	if err != nil {
+		return result, gofakes3.ErrorMessage(ErrSlowDown, err.Error())
+	}
+	defer transfer.Release()
+
 	obj := model.Object{
 		Name:     path.Base(fp),
 		Size:     size,
@@ -304,11 +347,14 @@ func (b *s3Backend) PutObject(
 	}
 	stream := &stream.FileStream{
 		Obj:      &obj,
-		Reader:   input,
+		Reader:   transfer.Reader(ctx, input),
 		Mimetype: meta["Content-Type"],
 	}
 
 	err = fs.PutDirectly(ctx, reqPath, stream)
+	if errors.Is(err, errs.QuotaExceeded) {
+		return result, gofakes3.ErrorMessage(ErrQuotaExceeded, err.Error())
+	}
 	if err != nil {
 		return result, err
 	}
@@ -351,13 +397,19 @@ func (b *s3Backend) DeleteObject(ctx context.Context, bucketName, objectName str
 
 // deleteObject deletes the object from the filesystem.
 func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName string) error {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getUserBucket(ctx, bucketName)
 	if err != nil {
 		return err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err := checkUserPath(ctx, fp); err != nil {
+		return err
+	}
+	if err := checkUserPerm(ctx, (*model.User).CanRemove); err != nil {
+		return err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	// S3 does not report an error when attemping to delete a key that does not exist, so
 	// we need to skip IsNotExist errors.
@@ -400,7 +452,7 @@ func (b *s3Backend) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket
 		return result, nil
 	}
 
-	srcB, err := getBucketByName(srcBucket)
+	srcB, err := getUserBucket(ctx, srcBucket)
 	if err != nil {
 		return result, err
 	}
@@ -2,13 +2,12 @@ package server
 
 import (
 	"context"
-	"github.com/KirCute/sftpd-alist"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/loginlimit"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
-	"github.com/alist-org/alist/v3/server/ftp"
 	"github.com/alist-org/alist/v3/server/sftp"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh"
@@ -18,7 +17,7 @@ import (
 
 type SftpDriver struct {
 	proxyHeader *http.Header
-	config      *sftpd.Config
+	config      *ssh.ServerConfig
 }
 
 func NewSftpDriver() (*SftpDriver, error) {
@@ -30,11 +29,11 @@ func NewSftpDriver() (*SftpDriver, error) {
 	}, nil
 }
 
-func (d *SftpDriver) GetConfig() *sftpd.Config {
+func (d *SftpDriver) GetConfig() *ssh.ServerConfig {
 	if d.config != nil {
 		return d.config
 	}
-	serverConfig := ssh.ServerConfig{
+	serverConfig := &ssh.ServerConfig{
 		NoClientAuth:         true,
 		NoClientAuthCallback: d.NoClientAuth,
 		PasswordCallback:     d.PasswordAuth,
@@ -45,16 +44,11 @@ func (d *SftpDriver) GetConfig() *sftpd.Config {
 	for _, k := range sftp.SSHSigners {
 		serverConfig.AddHostKey(k)
 	}
-	d.config = &sftpd.Config{
-		ServerConfig: serverConfig,
-		HostPort:     conf.Conf.SFTP.Listen,
-		ErrorLogFunc: utils.Log.Error,
-		//DebugLogFunc: utils.Log.Debugf,
-	}
+	d.config = serverConfig
 	return d.config
 }
 
-func (d *SftpDriver) GetFileSystem(sc *ssh.ServerConn) (sftpd.FileSystem, error) {
+func (d *SftpDriver) GetContext(sc *ssh.ServerConn) (context.Context, error) {
 	userObj, err := op.GetUserByName(sc.User())
 	if err != nil {
 		return nil, err
@@ -64,10 +58,7 @@ func (d *SftpDriver) GetFileSystem(sc *ssh.ServerConn) (sftpd.FileSystem, error)
 	ctx = context.WithValue(ctx, "meta_pass", "")
 	ctx = context.WithValue(ctx, "client_ip", sc.RemoteAddr().String())
 	ctx = context.WithValue(ctx, "proxy_header", d.proxyHeader)
-	return &sftp.DriverAdapter{FtpDriver: ftp.NewAferoAdapter(ctx)}, nil
-}
-
-func (d *SftpDriver) Close() {
+	return ctx, nil
 }
 
 func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, error) {
@@ -85,8 +76,13 @@ func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, erro
 }
 
 func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	ip := conn.RemoteAddr().String()
+	if err := loginlimit.Check(conn.User(), ip); err != nil {
+		return nil, err
+	}
 	userObj, err := op.GetUserByName(conn.User())
 	if err != nil {
+		loginlimit.Fail("sftp", conn.User(), ip)
 		return nil, err
 	}
 	if userObj.Disabled || !userObj.CanFTPAccess() {
@@ -94,8 +90,10 @@ func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.
 	}
 	passHash := model.StaticHash(string(password))
 	if err = userObj.ValidatePwdStaticHash(passHash); err != nil {
+		loginlimit.Fail("sftp", conn.User(), ip)
 		return nil, err
 	}
+	loginlimit.Succeed(conn.User())
 	return nil, nil
 }
 
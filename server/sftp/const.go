@@ -1,11 +0,0 @@
-package sftp
-
-// From leffss/sftpd
-const (
-	SSH_FXF_READ   = 0x00000001
-	SSH_FXF_WRITE  = 0x00000002
-	SSH_FXF_APPEND = 0x00000004
-	SSH_FXF_CREAT  = 0x00000008
-	SSH_FXF_TRUNC  = 0x00000010
-	SSH_FXF_EXCL   = 0x00000020
-)
@@ -0,0 +1,403 @@
+package sftp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/server/ftp"
+	"github.com/pkg/errors"
+)
+
+// scp implements the legacy rcp protocol that `scp -t` (sink) and `scp -f` (source) speak,
+// see https://web.archive.org/web/20170215184048/https://blogs.oracle.com/janp/entry/how_the_scp_protocol_works
+type scp struct {
+	ctx       context.Context
+	r         *bufio.Reader
+	w         io.Writer
+	recursive bool
+	preserve  bool
+	targetDir bool
+	// the number of the files failed, which makes the exit status non-zero like openssh
+	errs int
+}
+
+// fatalErr ends the session, the others are reported to the client which goes on with the next file
+type fatalErr struct {
+	error
+}
+
+// runExec runs the command of an exec request and returns the exit status
+func runExec(ctx context.Context, rw io.ReadWriter, stderr io.Writer, command string) uint32 {
+	args, err := splitCommand(command)
+	if err != nil || len(args) == 0 {
+		_, _ = fmt.Fprintf(stderr, "invalid command: %s\n", command)
+		return 1
+	}
+	if stdpath.Base(args[0]) != "scp" {
+		_, _ = fmt.Fprintf(stderr, "%s: command not supported, only scp and the sftp subsystem are available\n", args[0])
+		return 127
+	}
+	s := &scp{ctx: ctx, r: bufio.NewReader(rw), w: rw}
+	var sink, source bool
+	var paths []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			paths = append(paths, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			paths = append(paths, arg)
+			continue
+		}
+		for _, c := range arg[1:] {
+			switch c {
+			case 't':
+				sink = true
+			case 'f':
+				source = true
+			case 'r':
+				s.recursive = true
+			case 'p':
+				s.preserve = true
+			case 'd':
+				s.targetDir = true
+			}
+		}
+	}
+	if sink == source || len(paths) == 0 || sink && len(paths) != 1 {
+		_, _ = fmt.Fprintln(stderr, "scp: exactly one of -t and -f is required with the paths")
+		return 1
+	}
+	if sink {
+		err = s.sink(paths[0])
+	} else {
+		err = s.source(paths)
+	}
+	if err != nil {
+		var fatal fatalErr
+		if errors.As(err, &fatal) {
+			_ = s.sendErr(fatal.error)
+		}
+		return 1
+	}
+	if s.errs > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (s *scp) ack() error {
+	_, err := s.w.Write([]byte{0})
+	return err
+}
+
+func (s *scp) sendErr(err error) error {
+	s.errs++
+	_, e := fmt.Fprintf(s.w, "\x01scp: %s\n", strings.ReplaceAll(err.Error(), "\n", " "))
+	return e
+}
+
+// readAck reads the response of the client to a command or a file
+func (s *scp) readAck() error {
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == 0 {
+		return nil
+	}
+	msg, _ := s.r.ReadString('\n')
+	return fmt.Errorf("client: %s", strings.TrimSpace(msg))
+}
+
+func (s *scp) sink(target string) error {
+	target = cleanPath(target)
+	info, err := ftp.Stat(s.ctx, target)
+	targetIsDir := err == nil && info.IsDir()
+	if s.targetDir && !targetIsDir {
+		return fatalErr{errors.Errorf("%s: not a directory", target)}
+	}
+	if err = s.ack(); err != nil {
+		return err
+	}
+	var dirs []string
+	var modTime time.Time
+	for {
+		line, err := s.r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fatalErr{errors.New("empty command")}
+		}
+		switch line[0] {
+		case '\x01':
+			continue
+		case '\x02':
+			return errors.New(line[1:])
+		case 'E':
+			if len(dirs) == 0 {
+				return fatalErr{errors.New("unexpected end of directory")}
+			}
+			dirs = dirs[:len(dirs)-1]
+			if err = s.ack(); err != nil {
+				return err
+			}
+			continue
+		case 'T':
+			var mtime, mtimeUsec, atime, atimeUsec int64
+			if _, err = fmt.Sscanf(line[1:], "%d %d %d %d", &mtime, &mtimeUsec, &atime, &atimeUsec); err != nil {
+				return fatalErr{errors.Errorf("invalid times: %s", line)}
+			}
+			modTime = time.Unix(mtime, mtimeUsec*1000)
+			if err = s.ack(); err != nil {
+				return err
+			}
+			continue
+		case 'C', 'D':
+		default:
+			return fatalErr{errors.Errorf("unexpected command: %q", line)}
+		}
+		parts := strings.SplitN(line[1:], " ", 3)
+		if len(parts) != 3 {
+			return fatalErr{errors.Errorf("invalid command: %q", line)}
+		}
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		name := parts[2]
+		if err != nil || size < 0 || name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			return fatalErr{errors.Errorf("invalid command: %q", line)}
+		}
+		var dst string
+		if len(dirs) > 0 {
+			dst = stdpath.Join(dirs[len(dirs)-1], name)
+		} else if targetIsDir {
+			dst = stdpath.Join(target, name)
+		} else {
+			dst = target
+		}
+		mtime := modTime
+		modTime = time.Time{}
+		if line[0] == 'D' {
+			if !s.recursive {
+				return fatalErr{errors.New("received directory without -r")}
+			}
+			if info, err := ftp.Stat(s.ctx, dst); err != nil || !info.IsDir() {
+				if err = ftp.Mkdir(s.ctx, dst); err != nil {
+					return fatalErr{errors.WithMessagef(err, "%s", dst)}
+				}
+			}
+			dirs = append(dirs, dst)
+			if err = s.ack(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = s.receiveFile(dst, size, mtime); err != nil {
+			var fatal fatalErr
+			if errors.As(err, &fatal) {
+				return err
+			}
+			if err = s.sendErr(errors.WithMessagef(err, "%s", dst)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// receiveFile reads the content following a C command, the errors not being fatalErr
+// are returned before the content is read, or after it is drained
+func (s *scp) receiveFile(path string, size int64, modTime time.Time) error {
+	user := s.ctx.Value("user").(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return err
+	}
+	if err = ftp.UploadAuth(s.ctx, reqPath); err != nil {
+		return err
+	}
+	transfer, err := bandwidth.Acquire(user, bandwidth.Upload)
+	if err != nil {
+		return err
+	}
+	defer transfer.Release()
+	if err = s.ack(); err != nil {
+		return fatalErr{err}
+	}
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	head, _ := s.r.Peek(int(min(size, 512)))
+	reader := io.LimitReader(s.r, size)
+	dir, name := stdpath.Split(reqPath)
+	file := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: modTime,
+		},
+		Mimetype: http.DetectContentType(head),
+		Reader:   transfer.Reader(s.ctx, reader),
+	}
+	err = fs.PutDirectly(s.ctx, dir, file, true)
+	// the remaining content must be consumed to keep the protocol in sync
+	if _, e := io.Copy(io.Discard, reader); e != nil {
+		return fatalErr{e}
+	}
+	if e := s.readAck(); e != nil {
+		return fatalErr{e}
+	}
+	if err != nil {
+		return err
+	}
+	return s.ack()
+}
+
+func (s *scp) source(paths []string) error {
+	if err := s.readAck(); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		path = cleanPath(path)
+		info, err := ftp.Stat(s.ctx, path)
+		if err != nil {
+			if err = s.sendErr(errors.WithMessagef(err, "%s", path)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = s.send(path, info.Name(), info.IsDir(), info.Size(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send sends a file or a directory, only the errors breaking the protocol are returned
+func (s *scp) send(path, name string, isDir bool, size int64, modTime time.Time) error {
+	if isDir && !s.recursive {
+		return s.sendErr(errors.Errorf("%s: not a regular file", path))
+	}
+	if s.preserve {
+		t := modTime.Unix()
+		if _, err := fmt.Fprintf(s.w, "T%d 0 %d 0\n", t, t); err != nil {
+			return err
+		}
+		if err := s.readAck(); err != nil {
+			return err
+		}
+	}
+	if isDir {
+		files, err := ftp.List(s.ctx, path)
+		if err != nil {
+			return s.sendErr(errors.WithMessagef(err, "%s", path))
+		}
+		if _, err = fmt.Fprintf(s.w, "D0755 0 %s\n", name); err != nil {
+			return err
+		}
+		if err = s.readAck(); err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err = s.send(stdpath.Join(path, f.Name()), f.Name(), f.IsDir(), f.Size(), f.ModTime()); err != nil {
+				return err
+			}
+		}
+		if _, err = fmt.Fprint(s.w, "E\n"); err != nil {
+			return err
+		}
+		return s.readAck()
+	}
+	user := s.ctx.Value("user").(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return s.sendErr(errors.WithMessagef(err, "%s", path))
+	}
+	proxy, err := ftp.OpenDownload(s.ctx, reqPath, 0)
+	if err != nil {
+		return s.sendErr(errors.WithMessagef(err, "%s", path))
+	}
+	defer proxy.Close()
+	if _, err = fmt.Fprintf(s.w, "C0644 %d %s\n", size, name); err != nil {
+		return err
+	}
+	if err = s.readAck(); err != nil {
+		return err
+	}
+	// a short read leaves the client waiting for the rest, so it is fatal
+	if _, err = io.CopyN(s.w, proxy, size); err != nil {
+		return fatalErr{errors.WithMessagef(err, "%s", path)}
+	}
+	if err = s.ack(); err != nil {
+		return err
+	}
+	return s.readAck()
+}
+
+// cleanPath converts the paths given by scp clients, which are relative to the home, into absolute ones
+func cleanPath(path string) string {
+	path = strings.TrimPrefix(path, "~")
+	return stdpath.Clean("/" + path)
+}
+
+// splitCommand splits a command line like a posix shell does, without the expansions
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg, escaped := false, false
+	var quote rune
+	for _, c := range command {
+		switch {
+		case escaped:
+			cur.WriteRune(c)
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(c)
+			}
+		case c == '\\':
+			escaped, inArg = true, true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(c)
+			inArg = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,63 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	args, err := splitCommand(`scp -r -t -- '/a b/c' "d\"e" f\ g`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"scp", "-r", "-t", "--", "/a b/c", `d"e`, "f g"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+	if _, err = splitCommand(`scp -t 'a`); err == nil {
+		t.Error("unterminated quote should fail")
+	}
+}
+
+// runSCP runs the command with the input of the client and returns the output of the server
+func runSCP(t *testing.T, ctx context.Context, command string, input string) (string, uint32) {
+	var out, stderr bytes.Buffer
+	status := runExec(ctx, struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewBufferString(input), &out}, &stderr, command)
+	if stderr.Len() > 0 {
+		t.Logf("stderr: %s", stderr.String())
+	}
+	return out.String(), status
+}
+
+func TestSCP(t *testing.T) {
+	ctx := newContext(t)
+	// a directory with a file, the server acks every line and the content
+	input := "D0755 0 dir\n" + "T1500000000 0 1500000000 0\n" + "C0644 5 a.txt\nhello\x00" + "E\n"
+	out, status := runSCP(t, ctx, "scp -r -p -t /local", input)
+	if status != 0 || out != "\x00\x00\x00\x00\x00\x00" {
+		t.Fatalf("unexpected sink result %d: %q", status, out)
+	}
+
+	// the client acks the start, the header and the content
+	out, status = runSCP(t, ctx, "scp -f /local/dir/a.txt", "\x00\x00\x00")
+	if status != 0 || out != "C0644 5 a.txt\nhello\x00" {
+		t.Fatalf("unexpected source result %d: %q", status, out)
+	}
+
+	out, status = runSCP(t, ctx, "scp -f /local/dir", "\x00")
+	if status != 1 || !strings.HasPrefix(out, "\x01") {
+		t.Errorf("sending a directory without -r should be refused, got %d: %q", status, out)
+	}
+
+	out, status = runSCP(t, ctx, "scp -t /local", "\n")
+	if status != 1 || !strings.HasPrefix(out, "\x00\x01") {
+		t.Errorf("an empty command should be refused, got %d: %q", status, out)
+	}
+}
@@ -0,0 +1,150 @@
+package sftp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"runtime/debug"
+	"sync"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Driver provides the ssh config and builds the context of an authenticated connection
+type Driver interface {
+	GetConfig() *ssh.ServerConfig
+	GetContext(sc *ssh.ServerConn) (context.Context, error)
+}
+
+type Server struct {
+	addr   string
+	driver Driver
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+func NewServer(addr string, driver Driver) *Server {
+	return &Server{addr: addr, driver: driver}
+}
+
+func (s *Server) ListenAndServe() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	sc, chans, reqs, err := ssh.NewServerConn(conn, s.driver.GetConfig())
+	if err != nil {
+		utils.Log.Debugf("[SFTP] failed to handshake with %s: %+v", conn.RemoteAddr(), err)
+		return
+	}
+	defer sc.Close()
+	go ssh.DiscardRequests(reqs)
+	ctx, err := s.driver.GetContext(sc)
+	if err != nil {
+		utils.Log.Errorf("[SFTP] failed to prepare the session of %s: %+v", sc.User(), err)
+		return
+	}
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			_ = newChan.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChan.Accept()
+		if err != nil {
+			utils.Log.Errorf("[SFTP] failed to accept the channel of %s: %+v", sc.User(), err)
+			continue
+		}
+		go s.handleSession(ctx, channel, requests)
+	}
+}
+
+func (s *Server) handleSession(ctx context.Context, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	// a bug triggered by one client must not bring the whole server down
+	defer func() {
+		if r := recover(); r != nil {
+			utils.Log.Errorf("[SFTP] session panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+	for req := range requests {
+		switch req.Type {
+		case "subsystem":
+			if parseString(req.Payload) != "sftp" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			go ssh.DiscardRequests(requests)
+			server := sftp.NewRequestServer(channel, NewHandlers(ctx))
+			if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+				utils.Log.Errorf("[SFTP] session ended with error: %+v", err)
+			}
+			_ = server.Close()
+			return
+		case "exec":
+			command := parseString(req.Payload)
+			_ = req.Reply(true, nil)
+			go ssh.DiscardRequests(requests)
+			status := runExec(ctx, channel, channel.Stderr(), command)
+			_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+			return
+		case "env":
+			// the variables of clients are not used, but refusing them breaks some clients
+			_ = req.Reply(true, nil)
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+// parseString reads a string of the ssh wire format
+func parseString(payload []byte) string {
+	if len(payload) < 4 {
+		return ""
+	}
+	n := binary.BigEndian.Uint32(payload)
+	if uint32(len(payload)-4) < n {
+		return ""
+	}
+	return string(payload[4 : 4+n])
+}
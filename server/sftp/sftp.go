@@ -1,123 +1,450 @@
 package sftp
 
 import (
-	"github.com/KirCute/sftpd-alist"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	stdpath "path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/bandwidth"
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/ftp"
-	"os"
+	"github.com/pkg/sftp"
 )
 
-type DriverAdapter struct {
-	FtpDriver *ftp.AferoAdapter
-}
+// Handler serves the requests of a sftp session,
+// the ctx carries the user and the values the ftp package needs
+type Handler struct {
+	ctx context.Context
 
-func (s *DriverAdapter) OpenFile(_ string, _ uint32, _ *sftpd.Attr) (sftpd.File, error) {
-	// See also GetHandle
-	return nil, errs.NotImplement
+	mu      sync.Mutex
+	uploads map[string]*fileUpload
 }
 
-func (s *DriverAdapter) OpenDir(_ string) (sftpd.Dir, error) {
-	// See also GetHandle
-	return nil, errs.NotImplement
+func NewHandlers(ctx context.Context) sftp.Handlers {
+	h := &Handler{ctx: ctx, uploads: make(map[string]*fileUpload)}
+	return sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h}
 }
 
-func (s *DriverAdapter) Remove(name string) error {
-	return s.FtpDriver.Remove(name)
+func (h *Handler) user() *model.User {
+	return h.ctx.Value("user").(*model.User)
 }
 
-func (s *DriverAdapter) Rename(old, new string, _ uint32) error {
-	return s.FtpDriver.Rename(old, new)
+func (h *Handler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	reqPath, err := h.user().JoinPath(r.Filepath)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	obj, err := ftp.Stat(h.ctx, r.Filepath)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	if obj.IsDir() {
+		return nil, sftp.ErrSSHFxFailure
+	}
+	proxy, err := ftp.OpenDownload(h.ctx, reqPath, 0)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	return &fileReader{proxy: proxy, size: proxy.Size()}, nil
 }
 
-func (s *DriverAdapter) Mkdir(name string, attr *sftpd.Attr) error {
-	return s.FtpDriver.Mkdir(name, attr.Mode)
+func (h *Handler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return h.openUpload(r)
 }
 
-func (s *DriverAdapter) Rmdir(name string) error {
-	return s.Remove(name)
+// OpenFile serves the handles opened for both reading and writing, such as the ones of sshfs
+func (h *Handler) OpenFile(r *sftp.Request) (sftp.WriterAtReaderAt, error) {
+	return h.openUpload(r)
 }
 
-func (s *DriverAdapter) Stat(name string, _ bool) (*sftpd.Attr, error) {
-	stat, err := s.FtpDriver.Stat(name)
+func (h *Handler) openUpload(r *sftp.Request) (*fileUpload, error) {
+	reqPath, err := h.user().JoinPath(r.Filepath)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	if err = ftp.UploadAuth(h.ctx, reqPath); err != nil {
+		return nil, convertErr(err)
+	}
+	flags := r.Pflags()
+	obj, err := fs.Get(h.ctx, reqPath, &fs.GetArgs{})
+	exists := err == nil
+	if exists && obj.IsDir() {
+		return nil, sftp.ErrSSHFxFailure
+	}
+	if !exists && !flags.Creat {
+		return nil, sftp.ErrSSHFxNoSuchFile
+	}
+	if exists && flags.Excl {
+		return nil, os.ErrExist
+	}
+	transfer, err := bandwidth.Acquire(h.user(), bandwidth.Upload)
 	if err != nil {
+		return nil, convertErr(err)
+	}
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
+	if err != nil {
+		transfer.Release()
 		return nil, err
 	}
-	return fileInfoToSftpAttr(stat), nil
+	u := &fileUpload{h: h, path: reqPath, tmp: tmp, transfer: transfer}
+	// the file is modified in place, so its content is needed once it's written
+	if exists && !flags.Trunc && obj.GetSize() > 0 {
+		u.unfilled = true
+	} else {
+		u.dirty.Store(true)
+	}
+	h.mu.Lock()
+	h.uploads[reqPath] = u
+	h.mu.Unlock()
+	return u, nil
 }
 
-func (s *DriverAdapter) SetStat(_ string, _ *sftpd.Attr) error {
-	return errs.NotSupport
+func (h *Handler) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		return convertErr(h.setStat(r))
+	case "Rename":
+		// sftp v3 requires the rename to fail if the target exists
+		if _, err := ftp.Stat(h.ctx, r.Target); err == nil {
+			return os.ErrExist
+		}
+		return convertErr(ftp.Rename(h.ctx, r.Filepath, r.Target))
+	case "Rmdir", "Remove":
+		return convertErr(ftp.Remove(h.ctx, r.Filepath))
+	case "Mkdir":
+		return convertErr(ftp.Mkdir(h.ctx, r.Filepath))
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+// PosixRename replaces the target if it exists, rsync relies on it.
+// The target is renamed aside first, so it's restored if the rename fails
+func (h *Handler) PosixRename(r *sftp.Request) error {
+	if _, err := ftp.Stat(h.ctx, r.Target); err != nil {
+		return convertErr(ftp.Rename(h.ctx, r.Filepath, r.Target))
+	}
+	aside := r.Target + ".alist_to_delete"
+	if err := ftp.Rename(h.ctx, r.Target, aside); err != nil {
+		return convertErr(err)
+	}
+	if err := ftp.Rename(h.ctx, r.Filepath, r.Target); err != nil {
+		if err := ftp.Rename(h.ctx, aside, r.Target); err != nil {
+			utils.Log.Errorf("failed restore %s: %+v", r.Target, err)
+		}
+		return convertErr(err)
+	}
+	return convertErr(ftp.Remove(h.ctx, aside))
 }
 
-func (s *DriverAdapter) ReadLink(_ string) (string, error) {
-	return "", errs.NotSupport
+func (h *Handler) StatVFS(r *sftp.Request) (*sftp.StatVFS, error) {
+	reqPath, err := h.user().JoinPath(r.Filepath)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	details, err := op.GetStorageDetails(h.ctx, storage)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	const blockSize = 4096
+	return &sftp.StatVFS{
+		Bsize:   blockSize,
+		Frsize:  blockSize,
+		Blocks:  uint64(details.TotalSpace) / blockSize,
+		Bfree:   uint64(details.FreeSpace) / blockSize,
+		Bavail:  uint64(details.FreeSpace) / blockSize,
+		Namemax: 255,
+	}, nil
+}
+
+func (h *Handler) setStat(r *sftp.Request) error {
+	reqPath, err := h.user().JoinPath(r.Filepath)
+	if err != nil {
+		return err
+	}
+	flags, attrs := r.AttrFlags(), r.Attributes()
+	h.mu.Lock()
+	u := h.uploads[reqPath]
+	h.mu.Unlock()
+	if u != nil {
+		// FSETSTAT of an open handle applies when the file is uploaded
+		if flags.Size {
+			if err = u.truncate(int64(attrs.Size)); err != nil {
+				return err
+			}
+		}
+		if flags.Acmodtime {
+			u.setModTime(time.Unix(int64(attrs.Mtime), 0))
+		}
+		return nil
+	}
+	if flags.Size {
+		obj, err := ftp.Stat(h.ctx, r.Filepath)
+		if err != nil {
+			return err
+		}
+		if uint64(obj.Size()) != attrs.Size {
+			return errs.NotSupport
+		}
+	}
+	if flags.Acmodtime {
+		if err = ftp.UploadAuth(h.ctx, reqPath); err != nil {
+			return err
+		}
+		err = fs.SetModTime(h.ctx, reqPath, time.Unix(int64(attrs.Mtime), 0))
+		// the time is kept by the storage itself if it can't be changed
+		if errs.IsNotImplement(err) {
+			return nil
+		}
+		return err
+	}
+	// permissions and owners don't exist in alist
+	return nil
 }
 
-func (s *DriverAdapter) CreateLink(_, _ string, _ uint32) error {
-	return errs.NotSupport
+func (h *Handler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		files, err := ftp.List(h.ctx, r.Filepath)
+		if err != nil {
+			return nil, convertErr(err)
+		}
+		return listerAt(files), nil
+	case "Stat":
+		file, err := ftp.Stat(h.ctx, r.Filepath)
+		if err != nil {
+			return nil, convertErr(err)
+		}
+		return listerAt{file}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
 }
 
-func (s *DriverAdapter) RealPath(path string) (string, error) {
+func (h *Handler) RealPath(path string) (string, error) {
 	return utils.FixAndCleanPath(path), nil
 }
 
-func (s *DriverAdapter) GetHandle(name string, flags uint32, _ *sftpd.Attr, offset uint64) (sftpd.FileTransfer, error) {
-	return s.FtpDriver.GetHandle(name, sftpFlagToOpenMode(flags), int64(offset))
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fileReader serves reads at any offset, the requests of a handle may come concurrently
+type fileReader struct {
+	mu    sync.Mutex
+	proxy *ftp.FileDownloadProxy
+	size  int64
+}
+
+func (f *fileReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	if remain := f.size - off; int64(len(p)) > remain {
+		p = p[:remain]
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.proxy.ReadAt(p, off)
+	if err == nil && off+int64(n) >= f.size {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *fileReader) Close() error {
+	return f.proxy.Close()
+}
+
+// fileUpload buffers the writes in a temp file, so they can come at any offset,
+// the file is uploaded when the handle is closed
+type fileUpload struct {
+	h     *Handler
+	path  string
+	tmp   *os.File
+	dirty atomic.Bool
+	// transfer limits the writes by the profile of the user,
+	// the file is read from the storage within it too
+	transfer *bandwidth.Transfer
+
+	mu      sync.Mutex
+	modTime time.Time
+
+	// unfilled tells that the file opened without truncating isn't downloaded yet,
+	// it's read from the storage by reader until it's modified
+	fillMu   sync.Mutex
+	unfilled bool
+	reader   *fileReader
 }
 
-func (s *DriverAdapter) ReadDir(name string) ([]sftpd.NamedAttr, error) {
-	dir, err := s.FtpDriver.ReadDir(name)
+// fill downloads the content of the file before it's first modified
+func (u *fileUpload) fill() error {
+	u.fillMu.Lock()
+	defer u.fillMu.Unlock()
+	if !u.unfilled {
+		return nil
+	}
+	u.closeReader()
+	proxy, err := ftp.OpenDownloadIn(u.h.ctx, u.path, 0, u.transfer)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	ret := make([]sftpd.NamedAttr, len(dir))
-	for i, d := range dir {
-		ret[i] = *fileInfoToSftpNamedAttr(d)
+	defer proxy.Close()
+	if _, err = utils.CopyWithBuffer(u.tmp, proxy); err != nil {
+		return err
 	}
-	return ret, nil
+	u.unfilled = false
+	return nil
 }
 
-// From leffss/sftpd
-func sftpFlagToOpenMode(flags uint32) int {
-	mode := 0
-	if (flags & SSH_FXF_READ) != 0 {
-		mode |= os.O_RDONLY
+// closeReader closes the reader of the storage, fillMu must be held
+func (u *fileUpload) closeReader() {
+	if u.reader != nil {
+		_ = u.reader.Close()
+		u.reader = nil
 	}
-	if (flags & SSH_FXF_WRITE) != 0 {
-		mode |= os.O_WRONLY
+}
+
+func (u *fileUpload) truncate(size int64) error {
+	if size == 0 {
+		// nothing of the old content is left
+		u.fillMu.Lock()
+		u.closeReader()
+		u.unfilled = false
+		u.fillMu.Unlock()
+	} else if err := u.fill(); err != nil {
+		return err
+	}
+	if err := u.tmp.Truncate(size); err != nil {
+		return err
+	}
+	u.dirty.Store(true)
+	return nil
+}
+
+func (u *fileUpload) setModTime(t time.Time) {
+	u.mu.Lock()
+	u.modTime = t
+	u.mu.Unlock()
+}
+
+func (u *fileUpload) WriteAt(p []byte, off int64) (int, error) {
+	if err := u.fill(); err != nil {
+		return 0, err
 	}
-	if (flags & SSH_FXF_APPEND) != 0 {
-		mode |= os.O_APPEND
+	n, err := u.tmp.WriteAt(p, off)
+	if n > 0 {
+		u.dirty.Store(true)
 	}
-	if (flags & SSH_FXF_CREAT) != 0 {
-		mode |= os.O_CREATE
+	if err != nil {
+		return n, err
 	}
-	if (flags & SSH_FXF_TRUNC) != 0 {
-		mode |= os.O_TRUNC
+	return n, u.transfer.WaitN(u.h.ctx, n)
+}
+
+func (u *fileUpload) ReadAt(p []byte, off int64) (int, error) {
+	u.fillMu.Lock()
+	if !u.unfilled {
+		u.fillMu.Unlock()
+		return u.tmp.ReadAt(p, off)
 	}
-	if (flags & SSH_FXF_EXCL) != 0 {
-		mode |= os.O_EXCL
+	defer u.fillMu.Unlock()
+	if u.reader == nil {
+		proxy, err := ftp.OpenDownloadIn(u.h.ctx, u.path, 0, u.transfer)
+		if err != nil {
+			return 0, err
+		}
+		u.reader = &fileReader{proxy: proxy, size: proxy.Size()}
 	}
-	return mode
+	return u.reader.ReadAt(p, off)
 }
 
-func fileInfoToSftpAttr(stat os.FileInfo) *sftpd.Attr {
-	ret := &sftpd.Attr{}
-	ret.Flags |= sftpd.ATTR_SIZE
-	ret.Size = uint64(stat.Size())
-	ret.Flags |= sftpd.ATTR_MODE
-	ret.Mode = stat.Mode()
-	ret.Flags |= sftpd.ATTR_TIME
-	ret.ATime = stat.Sys().(model.Obj).CreateTime()
-	ret.MTime = stat.ModTime()
-	return ret
+func (u *fileUpload) Close() error {
+	defer u.transfer.Release()
+	u.fillMu.Lock()
+	u.closeReader()
+	u.fillMu.Unlock()
+	u.h.mu.Lock()
+	if u.h.uploads[u.path] == u {
+		delete(u.h.uploads, u.path)
+	}
+	u.h.mu.Unlock()
+	u.mu.Lock()
+	modTime := u.modTime
+	u.mu.Unlock()
+	if !u.dirty.Load() {
+		_ = u.tmp.Close()
+		_ = os.Remove(u.tmp.Name())
+		if !modTime.IsZero() {
+			if err := fs.SetModTime(u.h.ctx, u.path, modTime); err != nil && !errs.IsNotImplement(err) {
+				return convertErr(err)
+			}
+		}
+		return nil
+	}
+	return convertErr(u.upload(modTime))
+}
+
+func (u *fileUpload) upload(modTime time.Time) error {
+	info, err := u.tmp.Stat()
+	if err != nil {
+		return err
+	}
+	head := make([]byte, 512)
+	n, _ := u.tmp.ReadAt(head, 0)
+	if _, err = u.tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	dir, name := stdpath.Split(u.path)
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     info.Size(),
+			Modified: modTime,
+		},
+		Mimetype: http.DetectContentType(head[:n]),
+	}
+	s.SetTmpFile(u.tmp)
+	return fs.PutDirectly(u.h.ctx, dir, s)
 }
 
-func fileInfoToSftpNamedAttr(stat os.FileInfo) *sftpd.NamedAttr {
-	return &sftpd.NamedAttr{
-		Name: stat.Name(),
-		Attr: *fileInfoToSftpAttr(stat),
+// convertErr maps the errors of alist to the status codes of sftp
+func convertErr(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errs.ObjectNotFound), errors.Is(err, errs.StorageNotFound):
+		return sftp.ErrSSHFxNoSuchFile
+	case errors.Is(err, errs.PermissionDenied):
+		return sftp.ErrSSHFxPermissionDenied
+	case errors.Is(err, errs.NotImplement), errors.Is(err, errs.NotSupport):
+		return sftp.ErrSSHFxOpUnsupported
 	}
+	return err
 }
@@ -0,0 +1,166 @@
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/pkg/sftp"
+	"golang.org/x/time/rate"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newContext(t *testing.T) context.Context {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	db.Init(dB)
+	stream.ClientDownloadLimit = rate.NewLimiter(rate.Inf, 0)
+	stream.ClientUploadLimit = rate.NewLimiter(rate.Inf, 0)
+	_, err = op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"mkdir_perm":"777","recycle_bin_path":"delete permanently"}`, t.TempDir()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one transfer at a time, reading a file opened for writing must not take another
+	p := &model.LimitProfile{Name: t.Name(), MaxTransfers: 1}
+	if err = op.CreateLimitProfile(p); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "user", &model.User{ID: 500, Role: model.ADMIN, BasePath: "/", Permission: 0xffff, LimitProfileID: p.ID})
+	ctx = context.WithValue(ctx, "meta_pass", "")
+	ctx = context.WithValue(ctx, "client_ip", "127.0.0.1")
+	ctx = context.WithValue(ctx, "proxy_header", &http.Header{})
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(context.Background(), 1)
+	})
+	return ctx
+}
+
+func newClient(t *testing.T) *sftp.Client {
+	ctx := newContext(t)
+
+	serverRead, clientWrite := io.Pipe()
+	clientRead, serverWrite := io.Pipe()
+	server := sftp.NewRequestServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverRead, serverWrite}, NewHandlers(ctx))
+	go func() {
+		_ = server.Serve()
+		_ = server.Close()
+	}()
+	client, err := sftp.NewClientPipe(clientRead, clientWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+func TestReadWrite(t *testing.T) {
+	client := newClient(t)
+	f, err := client.Create("/local/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// out of order writes
+	if _, err = f.WriteAt([]byte("world"), 6); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.WriteAt([]byte("hello "), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = client.Open("/local/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err = f.ReadAt(buf, 6); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if string(buf) != "world" {
+		t.Errorf("unexpected content %q", buf)
+	}
+	_ = f.Close()
+
+	mtime := time.Unix(1500000000, 0)
+	if err = client.Chtimes("/local/a.txt", mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	info, err := client.Stat("/local/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 11 || !info.ModTime().Equal(mtime) {
+		t.Errorf("unexpected attributes: size %d, mtime %s", info.Size(), info.ModTime())
+	}
+
+	if f, err = client.Create("/local/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Rename("/local/a.txt", "/local/b.txt"); err == nil {
+		t.Error("rename should fail if the target exists")
+	}
+	if err = client.PosixRename("/local/a.txt", "/local/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Stat("/local/a.txt"); err == nil {
+		t.Error("the source of posix-rename still exists")
+	}
+	if _, err = client.Stat("/local/b.txt.alist_to_delete"); err == nil {
+		t.Error("the target replaced by posix-rename is left aside")
+	}
+
+	// modified in place, the part not written is kept
+	if f, err = client.OpenFile("/local/b.txt", os.O_RDWR); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.ReadAt(buf, 0); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if string(buf) != "hello" {
+		t.Errorf("unexpected content before written %q", buf)
+	}
+	if _, err = f.WriteAt([]byte("HELLO"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = client.Open("/local/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil || string(data) != "HELLO world" {
+		t.Errorf("unexpected content %q: %v", data, err)
+	}
+}
@@ -3,13 +3,14 @@ package server
 import (
 	"context"
 	"crypto/subtle"
-	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/server/middlewares"
 	"net/http"
 	"path"
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/loginlimit"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -29,8 +30,8 @@ func WebDav(dav *gin.RouterGroup) {
 		},
 	}
 	dav.Use(WebDAVAuth)
-	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
-	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
+	uploadLimiter := middlewares.UploadRateLimiter()
+	downloadLimiter := middlewares.DownloadRateLimiter()
 	dav.Any("/*path", uploadLimiter, downloadLimiter, ServeWebDAV)
 	dav.Any("", uploadLimiter, downloadLimiter, ServeWebDAV)
 	dav.Handle("PROPFIND", "/*path", ServeWebDAV)
@@ -41,6 +42,9 @@ func WebDav(dav *gin.RouterGroup) {
 	dav.Handle("PROPPATCH", "/*path", ServeWebDAV)
 	dav.Handle("COPY", "/*path", ServeWebDAV)
 	dav.Handle("MOVE", "/*path", ServeWebDAV)
+	dav.Handle("SEARCH", "/*path", ServeWebDAV)
+	dav.Handle("SEARCH", "", ServeWebDAV)
+	dav.Handle("REPORT", "/*path", ServeWebDAV)
 }
 
 func ServeWebDAV(c *gin.Context) {
@@ -70,6 +74,13 @@ func WebDAVAuth(c *gin.Context) {
 				c.Next()
 				return
 			}
+			if strings.HasPrefix(bt, model.APITokenPrefix) {
+				user, err := op.ValidateAPIToken(bt, c.ClientIP())
+				if err == nil {
+					webDAVAuthorize(c, user, guest)
+					return
+				}
+			}
 		}
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
@@ -81,8 +92,14 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	user, err := op.GetUserByName(username)
-	if err != nil || user.ValidateRawPassword(password) != nil {
+	if err := loginlimit.Check(username, c.ClientIP()); err != nil {
+		c.Status(http.StatusTooManyRequests)
+		c.Abort()
+		return
+	}
+	user, err := webDAVUser(username, password, c.ClientIP())
+	if err != nil {
+		loginlimit.Fail("webdav", username, c.ClientIP())
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
 			c.Next()
@@ -92,6 +109,33 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	loginlimit.Succeed(username)
+	webDAVAuthorize(c, user, guest)
+}
+
+// webDAVUser checks the password of basic auth, an api token of the user is accepted as the password
+func webDAVUser(username, password, ip string) (*model.User, error) {
+	if strings.HasPrefix(password, model.APITokenPrefix) {
+		user, err := op.ValidateAPIToken(password, ip)
+		if err != nil {
+			return nil, err
+		}
+		if user.Username != username {
+			return nil, errs.InvalidAPIToken
+		}
+		return user, nil
+	}
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if err = user.ValidateRawPassword(password); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func webDAVAuthorize(c *gin.Context, user, guest *model.User) {
 	if user.Disabled || !user.CanWebdavRead() {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
@@ -6,11 +6,13 @@ package webdav
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"path"
 	"path/filepath"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
@@ -64,6 +66,9 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int, err error) {
 	dstDir := path.Dir(dst)
 	_, err = fs.Copy(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
+	if errors.Is(err, errs.QuotaExceeded) {
+		return StatusInsufficientStorage, err
+	}
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
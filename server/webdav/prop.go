@@ -17,8 +17,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
 )
 
 // Proppatch describes a property update instruction as defined in RFC 4918.
@@ -109,6 +113,9 @@ var liveProps = map[xml.Name]struct {
 	findFn func(context.Context, LockSystem, string, model.Obj) (string, error)
 	// dir is true if the property applies to directories.
 	dir bool
+	// explicit is true if the property is only returned when it is named,
+	// see https://www.rfc-editor.org/rfc/rfc4331#section-3
+	explicit bool
 }{
 	{Space: "DAV:", Local: "resourcetype"}: {
 		findFn: findResourceType,
@@ -165,15 +172,28 @@ var liveProps = map[xml.Name]struct {
 		findFn: findChecksums,
 		dir:    false,
 	},
+	{Space: "DAV:", Local: "quota-available-bytes"}: {
+		findFn:   findQuotaAvailableBytes,
+		dir:      true,
+		explicit: true,
+	},
+	{Space: "DAV:", Local: "quota-used-bytes"}: {
+		findFn:   findQuotaUsedBytes,
+		dir:      true,
+		explicit: true,
+	},
 }
 
+// errPropNotFound is returned by findFn if the property isn't available for the resource
+var errPropNotFound = errors.New("webdav: property not found")
+
 // TODO(nigeltao) merge props and allprop?
 
 // Props returns the status of the properties named pnames for resource name.
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
+func props(ctx context.Context, ls LockSystem, name string, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -204,7 +224,13 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 		}
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
-			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
+			innerXML, err := prop.findFn(ctx, ls, name, fi)
+			if errors.Is(err, errPropNotFound) {
+				pstatNotFound.Props = append(pstatNotFound.Props, Property{
+					XMLName: pn,
+				})
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -245,7 +271,7 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
-		if prop.findFn != nil && (prop.dir || !isDir) {
+		if prop.findFn != nil && (prop.dir || !isDir) && !prop.explicit {
 			pnames = append(pnames, pn)
 		}
 	}
@@ -263,7 +289,7 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
+func allprop(ctx context.Context, ls LockSystem, name string, fi model.Obj, include []xml.Name) ([]Propstat, error) {
 	pnames, err := propnames(ctx, ls, fi)
 	if err != nil {
 		return nil, err
@@ -278,7 +304,7 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, name, fi, pnames)
 }
 
 // Patch patches the properties of resource name. The return values are
@@ -489,6 +515,53 @@ func findSupportedLock(ctx context.Context, ls LockSystem, name string, fi model
 		`</D:lockentry>`, nil
 }
 
+// storageDetails returns the space of the storage containing name,
+// the results are kept in the map put in ctx as "storage_details" during a request
+func storageDetails(ctx context.Context, name string) (*model.StorageDetails, error) {
+	storage, err := fs.GetStorage(name, &fs.GetStoragesArgs{})
+	if err != nil {
+		return nil, errPropNotFound
+	}
+	cache, _ := ctx.Value("storage_details").(map[string]*model.StorageDetails)
+	mountPath := storage.GetStorage().MountPath
+	if details, ok := cache[mountPath]; ok {
+		if details == nil {
+			return nil, errPropNotFound
+		}
+		return details, nil
+	}
+	details, err := op.GetStorageDetails(ctx, storage)
+	if err != nil {
+		if !errs.IsNotImplement(err) {
+			log.Warnf("failed get details of storage %s: %+v", mountPath, err)
+		}
+		details = nil
+	}
+	if cache != nil {
+		cache[mountPath] = details
+	}
+	if details == nil {
+		return nil, errPropNotFound
+	}
+	return details, nil
+}
+
+func findQuotaAvailableBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := storageDetails(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(details.FreeSpace, 10), nil
+}
+
+func findQuotaUsedBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := storageDetails(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(details.UsedSpace(), 10), nil
+}
+
 func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
 	checksums := ""
 	for hashType, hashValue := range fi.GetHash().All() {
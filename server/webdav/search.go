@@ -0,0 +1,260 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/search"
+	"github.com/alist-org/alist/v3/server/common"
+	ixml "github.com/alist-org/alist/v3/server/webdav/internal/xml"
+)
+
+// maxSearchResults limits the results of a SEARCH without nresults
+const maxSearchResults = 1000
+
+var (
+	errInvalidSearch     = errors.New("webdav: invalid search")
+	errUnsupportedSearch = errors.New("webdav: unsupported search condition")
+)
+
+// https://www.rfc-editor.org/rfc/rfc5323#section-5.2
+type searchRequest struct {
+	XMLName     ixml.Name    `xml:"DAV: searchrequest"`
+	BasicSearch *basicSearch `xml:"DAV: basicsearch"`
+}
+
+type basicSearch struct {
+	Select struct {
+		Allprop *struct{}     `xml:"DAV: allprop"`
+		Prop    propfindProps `xml:"DAV: prop"`
+	} `xml:"DAV: select"`
+	From struct {
+		Scope []struct {
+			Href  string `xml:"DAV: href"`
+			Depth string `xml:"DAV: depth"`
+		} `xml:"DAV: scope"`
+	} `xml:"DAV: from"`
+	Where searchWhere `xml:"DAV: where"`
+	Limit struct {
+		NResults int `xml:"DAV: nresults"`
+	} `xml:"DAV: limit"`
+}
+
+// searchWhere is the part of the basicsearch grammar the search index can answer:
+// like on displayname, contains and is-collection, combined by and
+type searchWhere struct {
+	keywords string
+	// 0 for all, 1 for dir, 2 for file, the same as model.SearchReq
+	scope       int
+	unsupported bool
+}
+
+func (w *searchWhere) UnmarshalXML(d *ixml.Decoder, start ixml.StartElement) error {
+	var stack []string
+	for {
+		t, err := next(d)
+		if err != nil {
+			return err
+		}
+		switch elem := t.(type) {
+		case ixml.StartElement:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			switch name := elem.Name.Local; {
+			case elem.Name.Space != "DAV:":
+				w.unsupported = true
+			case name == "and" || name == "like" || name == "contains" || name == "literal":
+			case name == "prop":
+				if parent != "like" {
+					w.unsupported = true
+				}
+			case name == "displayname":
+				if parent != "prop" {
+					w.unsupported = true
+				}
+			case name == "is-collection":
+				w.scope = 1
+				if parent == "not" {
+					w.scope = 2
+				}
+			case name == "not":
+			default:
+				w.unsupported = true
+			}
+			stack = append(stack, elem.Name.Local)
+		case ixml.EndElement:
+			if len(stack) == 0 {
+				if w.keywords == "" && w.scope == 0 {
+					w.unsupported = true
+				}
+				return nil
+			}
+			stack = stack[:len(stack)-1]
+		case ixml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			switch stack[len(stack)-1] {
+			case "literal":
+				w.keywords = likeKeywords(string(elem))
+			case "contains":
+				w.keywords = strings.TrimSpace(string(elem))
+			}
+		}
+	}
+}
+
+// likeKeywords turns a like pattern into keywords by taking its longest literal part
+func likeKeywords(pattern string) string {
+	var parts []string
+	var cur strings.Builder
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			cur.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%' || c == '_':
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(c)
+		}
+	}
+	parts = append(parts, cur.String())
+	longest := ""
+	for _, p := range parts {
+		if len(p) > len(longest) {
+			longest = p
+		}
+	}
+	return longest
+}
+
+func readSearchRequest(r io.Reader) (bs *basicSearch, status int, err error) {
+	var sr searchRequest
+	if err = ixml.NewDecoder(r).Decode(&sr); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if sr.BasicSearch == nil || len(sr.BasicSearch.From.Scope) != 1 {
+		return nil, http.StatusBadRequest, errInvalidSearch
+	}
+	if sr.BasicSearch.Where.unsupported {
+		return nil, StatusUnprocessableEntity, errUnsupportedSearch
+	}
+	if sr.BasicSearch.Select.Allprop == nil && sr.BasicSearch.Select.Prop == nil {
+		return nil, http.StatusBadRequest, errInvalidSearch
+	}
+	return sr.BasicSearch, 0, nil
+}
+
+// handleSearch answers the basicsearch of RFC 5323 with the search index
+func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) (status int, err error) {
+	bs, status, err := readSearchRequest(r.Body)
+	if err != nil {
+		return status, err
+	}
+	scope := bs.From.Scope[0]
+	// the scope is either an absolute href or relative to the request uri
+	href := scope.Href
+	if u, err := url.Parse(href); err == nil {
+		href = u.Path
+	}
+	if !strings.HasPrefix(href, "/") {
+		href = path.Join(r.URL.Path, href)
+	}
+	scopePath, status, err := h.stripPrefix(href)
+	if err != nil {
+		return status, err
+	}
+	ctx := context.WithValue(r.Context(), "userAgent", r.Header.Get("User-Agent"))
+	ctx = context.WithValue(ctx, "storage_details", make(map[string]*model.StorageDetails))
+	user := ctx.Value("user").(*model.User)
+	scopePath, err = user.JoinPath(scopePath)
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+	limit := bs.Limit.NResults
+	if limit <= 0 || limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+	nodes, _, err := search.Search(ctx, model.SearchReq{
+		Parent:   scopePath,
+		Keywords: bs.Where.keywords,
+		Scope:    bs.Where.scope,
+		PageReq:  model.PageReq{Page: 1, PerPage: limit},
+	})
+	if err != nil {
+		if errors.Is(err, errs.SearchNotAvailable) {
+			return http.StatusNotImplemented, err
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	mw := multistatusWriter{w: w}
+	for _, node := range nodes {
+		if scope.Depth == "0" || scope.Depth == "1" && node.Parent != scopePath {
+			continue
+		}
+		reqPath := path.Join(node.Parent, node.Name)
+		if !strings.HasPrefix(reqPath, user.BasePath) {
+			continue
+		}
+		meta, err := op.GetNearestMeta(node.Parent)
+		if err != nil && !errors.Is(err, errs.MetaNotFound) {
+			continue
+		}
+		if !common.CanAccess(user, meta, reqPath, "") {
+			continue
+		}
+		// the index may be outdated, so the objects are got again
+		obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
+		if err != nil {
+			continue
+		}
+		var pstats []Propstat
+		if bs.Select.Allprop != nil {
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, obj, nil)
+		} else {
+			pstats, err = props(ctx, h.LockSystem, reqPath, obj, bs.Select.Prop)
+		}
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if err = mw.write(makePropstatResponse(h.href(user, reqPath, obj.IsDir()), pstats)); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	if mw.enc == nil {
+		// an empty result is still a multistatus
+		if err = mw.writeHeader(); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	if err = mw.close(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// href returns the url path of reqPath for the responses
+func (h *Handler) href(user *model.User, reqPath string, isDir bool) string {
+	href := path.Join(h.Prefix, strings.TrimPrefix(reqPath, user.BasePath))
+	if href != "/" && isDir {
+		href += "/"
+	}
+	return href
+}
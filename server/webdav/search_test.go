@@ -0,0 +1,60 @@
+package webdav
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadSearchRequest(t *testing.T) {
+	const header = `<?xml version="1.0"?><D:searchrequest xmlns:D="DAV:"><D:basicsearch>` +
+		`<D:select><D:prop><D:displayname/></D:prop></D:select>` +
+		`<D:from><D:scope><D:href>/dav/a</D:href><D:depth>infinity</D:depth></D:scope></D:from>`
+	const footer = `</D:basicsearch></D:searchrequest>`
+	testCases := []struct {
+		desc         string
+		where        string
+		wantKeywords string
+		wantScope    int
+		wantStatus   int
+	}{{
+		desc:         "like",
+		where:        `<D:like><D:prop><D:displayname/></D:prop><D:literal>%report\_2024%</D:literal></D:like>`,
+		wantKeywords: "report_2024",
+	}, {
+		desc: "contains and not is-collection",
+		where: `<D:and><D:contains>holiday</D:contains>` +
+			`<D:not><D:is-collection/></D:not></D:and>`,
+		wantKeywords: "holiday",
+		wantScope:    2,
+	}, {
+		desc:       "other properties",
+		where:      `<D:like><D:prop><D:getcontenttype/></D:prop><D:literal>image/%</D:literal></D:like>`,
+		wantStatus: StatusUnprocessableEntity,
+	}, {
+		desc:       "or",
+		where:      `<D:or><D:contains>a</D:contains><D:contains>b</D:contains></D:or>`,
+		wantStatus: StatusUnprocessableEntity,
+	}, {
+		desc:       "no condition",
+		where:      ``,
+		wantStatus: StatusUnprocessableEntity,
+	}}
+	for _, tc := range testCases {
+		body := header + `<D:where>` + tc.where + `</D:where>` + footer
+		bs, status, err := readSearchRequest(strings.NewReader(body))
+		if status != tc.wantStatus {
+			t.Errorf("%s: status %d, want %d (%v)", tc.desc, status, tc.wantStatus, err)
+			continue
+		}
+		if status != 0 {
+			continue
+		}
+		if bs.Where.keywords != tc.wantKeywords || bs.Where.scope != tc.wantScope {
+			t.Errorf("%s: got %q %d, want %q %d", tc.desc, bs.Where.keywords, bs.Where.scope, tc.wantKeywords, tc.wantScope)
+		}
+	}
+	if _, status, _ := readSearchRequest(strings.NewReader(`<D:searchrequest xmlns:D="DAV:"/>`)); status != http.StatusBadRequest {
+		t.Errorf("missing basicsearch: status %d, want %d", status, http.StatusBadRequest)
+	}
+}
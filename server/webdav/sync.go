@@ -0,0 +1,242 @@
+package webdav
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	ixml "github.com/alist-org/alist/v3/server/webdav/internal/xml"
+)
+
+const syncTokenPrefix = "urn:alist:sync:"
+
+var errUnsupportedReport = errors.New("webdav: unsupported report")
+
+// syncEntry is a member of a collection, only the hash of its etag is kept
+type syncEntry struct {
+	Etag  uint64
+	IsDir bool
+}
+
+// syncSnapshot is the listing of a collection when a sync token was given out,
+// the entries map the paths to the members
+type syncSnapshot struct {
+	UserID  uint
+	Path    string
+	Entries map[string]syncEntry
+	expire  time.Time
+}
+
+const (
+	// the clients that come back later than the expiration get a full listing again
+	syncSnapshotExpiration = 24 * time.Hour
+	// maxSyncEntries is the most members kept of all the snapshots, the oldest snapshots are
+	// dropped beyond it, and the collections larger than it get no token to sync from
+	maxSyncEntries = 1 << 20
+)
+
+// syncStore keeps the snapshots in the order they are given out
+type syncStore struct {
+	mu      sync.Mutex
+	m       map[string]*syncSnapshot
+	order   []string
+	entries int
+}
+
+var syncSnapshots = &syncStore{m: make(map[string]*syncSnapshot)}
+
+func (s *syncStore) Get(token string) (*syncSnapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.m[token]
+	if !ok || time.Now().After(snap.expire) {
+		return nil, false
+	}
+	return snap, true
+}
+
+func (s *syncStore) Set(token string, snap *syncSnapshot) {
+	if len(snap.Entries) > maxSyncEntries {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap.expire = time.Now().Add(syncSnapshotExpiration)
+	if old, ok := s.m[token]; ok {
+		// the same listing, the expiration is renewed
+		old.expire = snap.expire
+		return
+	}
+	s.m[token] = snap
+	s.order = append(s.order, token)
+	s.entries += len(snap.Entries)
+	for len(s.order) > 0 {
+		oldest := s.m[s.order[0]]
+		if s.entries <= maxSyncEntries && time.Now().Before(oldest.expire) {
+			break
+		}
+		delete(s.m, s.order[0])
+		s.entries -= len(oldest.Entries)
+		s.order = s.order[1:]
+	}
+}
+
+// https://www.rfc-editor.org/rfc/rfc6578#section-6.1
+type syncCollection struct {
+	XMLName   ixml.Name     `xml:"DAV: sync-collection"`
+	SyncToken string        `xml:"DAV: sync-token"`
+	SyncLevel string        `xml:"DAV: sync-level"`
+	Prop      propfindProps `xml:"DAV: prop"`
+}
+
+func (s *syncSnapshot) token() string {
+	keys := make([]string, 0, len(s.Entries))
+	for k := range s.Entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "%d\x00%s\x00", s.UserID, s.Path)
+	for _, k := range keys {
+		e := s.Entries[k]
+		_, _ = fmt.Fprintf(h, "%s\x00%x\x00%t\x00", k, e.Etag, e.IsDir)
+	}
+	return syncTokenPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// entry returns the member at p of the snapshot, which may be nil
+func (s *syncSnapshot) entry(p string) (syncEntry, bool) {
+	if s == nil {
+		return syncEntry{}, false
+	}
+	e, ok := s.Entries[p]
+	return e, ok
+}
+
+func writeDAVError(w http.ResponseWriter, status int, condition string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><D:error xmlns:D="DAV:"><D:%s/></D:error>`, condition)
+}
+
+func (h *Handler) handleReport(w http.ResponseWriter, r *http.Request) (status int, err error) {
+	reqPath, status, err := h.stripPrefix(r.URL.Path)
+	if err != nil {
+		return status, err
+	}
+	var sc syncCollection
+	if err = ixml.NewDecoder(r.Body).Decode(&sc); err != nil {
+		// sync-collection is the only report supported
+		writeDAVError(w, http.StatusForbidden, "supported-report")
+		return 0, errUnsupportedReport
+	}
+	depth := 1
+	switch sc.SyncLevel {
+	case "1":
+	case "infinite":
+		depth = infiniteDepth
+	default:
+		return http.StatusBadRequest, errInvalidDepth
+	}
+	if sc.Prop == nil {
+		return http.StatusBadRequest, errInvalidPropfind
+	}
+
+	ctx := context.WithValue(r.Context(), "userAgent", r.Header.Get("User-Agent"))
+	ctx = context.WithValue(ctx, "storage_details", make(map[string]*model.StorageDetails))
+	user := ctx.Value("user").(*model.User)
+	reqPath, err = user.JoinPath(reqPath)
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+	fi, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return http.StatusNotFound, err
+		}
+		return http.StatusMethodNotAllowed, err
+	}
+	if !fi.IsDir() {
+		writeDAVError(w, http.StatusForbidden, "supported-report")
+		return 0, errNotADirectory
+	}
+
+	var old *syncSnapshot
+	if sc.SyncToken != "" {
+		var ok bool
+		old, ok = syncSnapshots.Get(sc.SyncToken)
+		if !ok || old.UserID != user.ID || old.Path != reqPath {
+			writeDAVError(w, http.StatusForbidden, "valid-sync-token")
+			return 0, nil
+		}
+	}
+
+	type member struct {
+		path string
+		obj  model.Obj
+	}
+	current := &syncSnapshot{UserID: user.ID, Path: reqPath, Entries: make(map[string]syncEntry)}
+	var changed []member
+	err = walkFS(ctx, depth, reqPath, fi, func(p string, info model.Obj, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == reqPath {
+			return nil
+		}
+		eh := fnv.New64a()
+		_, _ = eh.Write([]byte(common.GetEtag(info)))
+		e := syncEntry{Etag: eh.Sum64(), IsDir: info.IsDir()}
+		current.Entries[p] = e
+		if oe, ok := old.entry(p); !ok || oe != e {
+			changed = append(changed, member{path: p, obj: info})
+		}
+		return nil
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	token := current.token()
+	syncSnapshots.Set(token, current)
+
+	mw := multistatusWriter{w: w, syncToken: token}
+	for _, m := range changed {
+		pstats, err := props(ctx, h.LockSystem, m.path, m.obj, sc.Prop)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if err = mw.write(makePropstatResponse(h.href(user, m.path, m.obj.IsDir()), pstats)); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	if old != nil {
+		for p, e := range old.Entries {
+			if _, ok := current.Entries[p]; ok {
+				continue
+			}
+			err = mw.write(&response{
+				Href:   []string{(&url.URL{Path: h.href(user, p, e.IsDir)}).EscapedPath()},
+				Status: fmt.Sprintf("HTTP/1.1 %d %s", http.StatusNotFound, StatusText(http.StatusNotFound)),
+			})
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+	}
+	if err = mw.close(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
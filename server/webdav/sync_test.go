@@ -0,0 +1,156 @@
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+// newTestHandler serves a local storage at /local to an admin
+func newTestHandler(t *testing.T) (http.Handler, string) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"mkdir_perm":"777"}`, dir),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	h := &Handler{Prefix: "/dav", LockSystem: NewMemLS()}
+	user := &model.User{ID: 1, Username: "admin", BasePath: "/", Role: model.ADMIN}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user)))
+	}), dir
+}
+
+func serve(h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+var syncTokenRegexp = regexp.MustCompile(`<D:sync-token>([^<]+)</D:sync-token>`)
+
+func TestSyncCollection(t *testing.T) {
+	h, dir := newTestHandler(t)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report := func(token string) (string, string) {
+		w := serve(h, "REPORT", "/dav/local/", `<?xml version="1.0"?><D:sync-collection xmlns:D="DAV:">`+
+			`<D:sync-token>`+token+`</D:sync-token><D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`)
+		if w.Code != StatusMulti {
+			t.Fatalf("report: status %d, body %s", w.Code, w.Body)
+		}
+		m := syncTokenRegexp.FindStringSubmatch(w.Body.String())
+		if m == nil {
+			t.Fatalf("no sync token in %s", w.Body)
+		}
+		return w.Body.String(), m[1]
+	}
+	body, token := report("")
+	if !strings.Contains(body, "/dav/local/a.txt") || !strings.Contains(body, "/dav/local/b.txt") {
+		t.Errorf("initial sync lacks the members: %s", body)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("c"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	body, _ = report(token)
+	if strings.Contains(body, "/dav/local/a.txt") {
+		t.Errorf("unchanged member is reported: %s", body)
+	}
+	if !strings.Contains(body, "/dav/local/c.txt") {
+		t.Errorf("new member is not reported: %s", body)
+	}
+	if !strings.Contains(body, "/dav/local/b.txt") || !strings.Contains(body, "404") {
+		t.Errorf("removed member is not reported: %s", body)
+	}
+
+	w := serve(h, "REPORT", "/dav/local/", `<?xml version="1.0"?><D:sync-collection xmlns:D="DAV:">`+
+		`<D:sync-token>urn:alist:sync:unknown</D:sync-token><D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "valid-sync-token") {
+		t.Errorf("unknown token: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestSyncStoreBound(t *testing.T) {
+	s := &syncStore{m: make(map[string]*syncSnapshot)}
+	entries := func(n int) map[string]syncEntry {
+		m := make(map[string]syncEntry, n)
+		for i := 0; i < n; i++ {
+			m[fmt.Sprint(i)] = syncEntry{}
+		}
+		return m
+	}
+	s.Set("a", &syncSnapshot{Entries: entries(maxSyncEntries / 2)})
+	s.Set("b", &syncSnapshot{Entries: entries(maxSyncEntries / 2)})
+	s.Set("c", &syncSnapshot{Entries: entries(1)})
+	if _, ok := s.Get("a"); ok {
+		t.Error("the oldest snapshot is kept beyond the bound")
+	}
+	if _, ok := s.Get("c"); !ok {
+		t.Error("the newest snapshot is dropped")
+	}
+	s.Set("d", &syncSnapshot{Entries: entries(maxSyncEntries + 1)})
+	if _, ok := s.Get("d"); ok {
+		t.Error("a snapshot larger than the bound is kept")
+	}
+}
+
+func TestQuotaProps(t *testing.T) {
+	h, _ := newTestHandler(t)
+	w := serve(h, "PROPFIND", "/dav/local/", `<?xml version="1.0"?><D:propfind xmlns:D="DAV:"><D:prop>`+
+		`<D:quota-available-bytes/><D:quota-used-bytes/></D:prop></D:propfind>`, "Depth", "0")
+	if w.Code != StatusMulti {
+		t.Fatalf("propfind: status %d, body %s", w.Code, w.Body)
+	}
+	body := w.Body.String()
+	if !regexp.MustCompile(`<D:quota-available-bytes>\d+</D:quota-available-bytes>`).MatchString(body) ||
+		!regexp.MustCompile(`<D:quota-used-bytes>\d+</D:quota-used-bytes>`).MatchString(body) {
+		t.Errorf("quota properties are missing: %s", body)
+	}
+
+	// allprop leaves them out, they are only given when asked
+	w = serve(h, "PROPFIND", "/dav/local/", `<?xml version="1.0"?><D:propfind xmlns:D="DAV:"><D:allprop/></D:propfind>`, "Depth", "0")
+	if strings.Contains(w.Body.String(), "quota-available-bytes") {
+		t.Errorf("allprop includes the quota: %s", w.Body)
+	}
+}
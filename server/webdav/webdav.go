@@ -18,10 +18,11 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/stream"
 
+	"github.com/alist-org/alist/v3/internal/blockcache"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
-	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/internal/proxypool"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 )
@@ -83,6 +84,10 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 		case "PROPPATCH":
 			status, err = h.handleProppatch(brw, r)
+		case "SEARCH":
+			status, err = h.handleSearch(brw, r)
+		case "REPORT":
+			status, err = h.handleReport(brw, r)
 		}
 	}
 
@@ -201,7 +206,7 @@ func (h *Handler) handleOptions(w http.ResponseWriter, r *http.Request) (status
 	allow := "OPTIONS, LOCK, PUT, MKCOL"
 	if fi, err := fs.Get(ctx, reqPath, &fs.GetArgs{}); err == nil {
 		if fi.IsDir() {
-			allow = "OPTIONS, LOCK, DELETE, PROPPATCH, COPY, MOVE, UNLOCK, PROPFIND"
+			allow = "OPTIONS, LOCK, DELETE, PROPPATCH, COPY, MOVE, UNLOCK, PROPFIND, SEARCH, REPORT"
 		} else {
 			allow = "OPTIONS, LOCK, GET, HEAD, POST, DELETE, PROPPATCH, COPY, MOVE, UNLOCK, PROPFIND, PUT"
 		}
@@ -211,6 +216,8 @@ func (h *Handler) handleOptions(w http.ResponseWriter, r *http.Request) (status
 	w.Header().Set("DAV", "1, 2")
 	// http://msdn.microsoft.com/en-au/library/cc250217.aspx
 	w.Header().Set("MS-Author-Via", "DAV")
+	// https://www.rfc-editor.org/rfc/rfc5323#section-3.2
+	w.Header().Set("DASL", "<DAV:basicsearch>")
 	return 0, nil
 }
 
@@ -239,8 +246,11 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 	}
 	// Let ServeContent determine the Content-Type header.
 	storage, _ := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
-	downProxyUrl := storage.GetStorage().DownProxyUrl
-	if storage.GetStorage().WebdavNative() || (storage.GetStorage().WebdavProxy() && downProxyUrl == "") {
+	downProxy := ""
+	if downProxyUrl := storage.GetStorage().DownProxyUrl; downProxyUrl != "" {
+		downProxy = proxypool.Pick(downProxyUrl, reqPath, utils.ClientIP(r))
+	}
+	if storage.GetStorage().WebdavNative() || (storage.GetStorage().WebdavProxy() && downProxy == "") {
 		link, _, err := fs.Link(ctx, reqPath, model.LinkArgs{Header: r.Header, HttpReq: r})
 		if err != nil {
 			return http.StatusInternalServerError, err
@@ -248,15 +258,15 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 		if storage.GetStorage().ProxyRange {
 			common.ProxyRange(link, fi.GetSize())
 		}
-		err = common.Proxy(w, r, link, fi)
+		err = common.Proxy(w, r, blockcache.Wrap(reqPath, fi, link), fi)
 		if err != nil {
 			return http.StatusInternalServerError, fmt.Errorf("webdav proxy error: %+v", err)
 		}
-	} else if storage.GetStorage().WebdavProxy() && downProxyUrl != "" {
+	} else if storage.GetStorage().WebdavProxy() && downProxy != "" {
 		u := fmt.Sprintf("%s%s?sign=%s",
-			strings.Split(downProxyUrl, "\n")[0],
+			downProxy,
 			utils.EncodePath(reqPath, true),
-			sign.Sign(reqPath))
+			common.SignDownProxy(reqPath, utils.ClientIP(r)))
 		w.Header().Set("Cache-Control", "max-age=0, no-cache, no-store, must-revalidate")
 		http.Redirect(w, r, u, http.StatusFound)
 	} else {
@@ -343,6 +353,9 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if errs.IsNotFoundError(err) {
 		return http.StatusNotFound, err
 	}
+	if errors.Is(err, errs.QuotaExceeded) {
+		return StatusInsufficientStorage, err
+	}
 
 	_ = r.Body.Close()
 	_ = fsStream.Close()
@@ -622,6 +635,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 	ctx := r.Context()
 	userAgent := r.Header.Get("User-Agent")
 	ctx = context.WithValue(ctx, "userAgent", userAgent)
+	ctx = context.WithValue(ctx, "storage_details", make(map[string]*model.StorageDetails))
 	user := ctx.Value("user").(*model.User)
 	reqPath, err = user.JoinPath(reqPath)
 	if err != nil {
@@ -664,18 +678,14 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err
 		}
-		href := path.Join(h.Prefix, strings.TrimPrefix(reqPath, user.BasePath))
-		if href != "/" && info.IsDir() {
-			href += "/"
-		}
-		return mw.write(makePropstatResponse(href, pstats))
+		return mw.write(makePropstatResponse(h.href(user, reqPath, info.IsDir()), pstats))
 	}
 
 	walkErr := walkFS(ctx, depth, reqPath, fi, walkFn)
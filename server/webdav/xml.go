@@ -312,6 +312,9 @@ type multistatusWriter struct {
 	// close will be emitted. Empty response descriptions are not
 	// written.
 	responseDescription string
+	// syncToken is the sync-token element of a sync-collection report, see RFC 6578.
+	// The multistatus is written even if there is no response when it is set.
+	syncToken string
 
 	w   http.ResponseWriter
 	enc *ixml.Encoder
@@ -376,6 +379,11 @@ func (w *multistatusWriter) writeHeader() error {
 // return value and field enc of w are nil, then no multistatus response has
 // been written.
 func (w *multistatusWriter) close() error {
+	if w.syncToken != "" {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
 	if w.enc == nil {
 		return nil
 	}
@@ -388,6 +396,14 @@ func (w *multistatusWriter) close() error {
 			ixml.EndElement{Name: name},
 		)
 	}
+	if w.syncToken != "" {
+		name := ixml.Name{Space: "DAV:", Local: "sync-token"}
+		end = append(end,
+			ixml.StartElement{Name: name},
+			ixml.CharData(w.syncToken),
+			ixml.EndElement{Name: name},
+		)
+	}
 	end = append(end, ixml.EndElement{
 		Name: ixml.Name{Space: "DAV:", Local: "multistatus"},
 	})